@@ -2,11 +2,12 @@
 package alpacago
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/go-resty/resty/v2"
 )
 
@@ -19,8 +20,36 @@ const (
 	West
 )
 
+/*
+ASCOMNotImplemented is the ASCOM error number of a member the driver does not implement.
+*/
+const ASCOMNotImplemented int32 = 0x400
+
+/*
+ASCOMError is an error reported by the driver in the response envelope e.g., {"ErrorNumber": 1024,
+"ErrorMessage": "Property not implemented"}.
+*/
+type ASCOMError struct {
+	Number  int32
+	Message string
+}
+
+func (e *ASCOMError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Number, e.Message)
+}
+
 type ASCOMAlpacaAPIClient struct {
-	Client        *resty.Client
+	Client *resty.Client
+	// The transport requests are sent over, overriding HTTP with the resty Client (optional)
+	Transport Transport
+	// The structured logger of every request e.g., NewLogrusLogger(logrus.StandardLogger()) (optional)
+	Logger Logger
+	// Called before every request is sent, and with its outcome once answered (optional, and called
+	// concurrently by GetProperties)
+	OnRequest  func(ctx context.Context, request TransportRequest)
+	OnResponse func(ctx context.Context, trace RequestTrace)
+	// Records the request counts, latencies and errors (optional)
+	Metrics       Metrics
 	UrlBase       string
 	ClientId      uint32
 	TransactionId uint32
@@ -80,285 +109,231 @@ func (a *ASCOMAlpacaAPIClient) getEndpoint(deviceType string, deviceNumber uint,
 	return fmt.Sprintf("%s/api/v1/%s/%d/%s", a.UrlBase, deviceType, deviceNumber, method)
 }
 
-type stringResponse struct {
-	Value               string `json:"Value"`
-	ClientTransactionID uint32 `json:"ClientTransactionID"`
-	ServerTransactionID uint32 `json:"ServerTransactionID"`
-	ErrorNumber         int32  `json:"ErrorNumber"`
-	ErrorMessage        string `json:"ErrorMessage"`
+/*
+getTransport()
+
+@returns the client's Transport, or a resty transport over its Client if none is set.
+*/
+func (a *ASCOMAlpacaAPIClient) getTransport() Transport {
+	if a.Transport != nil {
+		return a.Transport
+	}
+
+	return NewRestyTransport(a.Client)
 }
 
 /*
-GetStringResponse()
+send()
 
-Global public method to work with calls returning stringResponse
+Sends a GET (with its query string) or PUT (with its form) request for the device method over the
+client's Transport, logging, tracing and measuring it.
 */
-func (a *ASCOMAlpacaAPIClient) GetStringResponse(deviceType string, deviceNumber uint, method string) (string, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
+func (a *ASCOMAlpacaAPIClient) send(ctx context.Context, verb string, deviceType string, deviceNumber uint, method string, querystring string, form map[string]string) (TransportResponse, error) {
+	request := TransportRequest{
+		Verb:         verb,
+		URL:          a.getEndpoint(deviceType, deviceNumber, method),
+		DeviceType:   deviceType,
+		DeviceNumber: deviceNumber,
+		Method:       method,
+		Query:        querystring,
+		Form:         form,
+	}
+
+	if a.OnRequest != nil {
+		a.OnRequest(ctx, request)
+	}
 
-	// Setup the resty client:
-	resp, err := a.Client.R().SetResult(&stringResponse{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
+	start := time.Now()
 
-	if err != nil {
-		return "", err
+	var resp TransportResponse
+
+	var err error
+
+	if verb == http.MethodPut {
+		resp, err = a.getTransport().Put(ctx, request)
+	} else {
+		resp, err = a.getTransport().Get(ctx, request)
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
+	if a.Logger == nil && a.OnResponse == nil && a.Metrics == nil {
+		return resp, err
 	}
 
-	// Return the result:
-	result := (resp.Result().(*stringResponse))
+	trace := getRequestTrace(request, resp, err, time.Since(start))
 
-	return result.Value, nil
-}
+	if a.Logger != nil {
+		trace.log(ctx, a.Logger)
+	}
+
+	if a.Metrics != nil {
+		a.Metrics.ObserveRequest(trace)
+	}
 
-type stringlistResponse struct {
-	Value               []string `json:"Value"`
-	ClientTransactionID uint32   `json:"ClientTransactionID"`
-	ServerTransactionID uint32   `json:"ServerTransactionID"`
-	ErrorNumber         int32    `json:"ErrorNumber"`
-	ErrorMessage        string   `json:"ErrorMessage"`
+	if a.OnResponse != nil {
+		a.OnResponse(ctx, trace)
+	}
+
+	return resp, err
 }
 
 /*
-GetStringListResponse()
+get()
 
-Global public method to work with calls returning stringListResponse
-*/
-func (a *ASCOMAlpacaAPIClient) GetStringListResponse(deviceType string, deviceNumber uint, method string) ([]string, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
+Sends a GET request for the device method, decoding the Value of the response envelope into the given
+pointer. A REST error is recorded in the client's ErrorNumber and ErrorMessage, leaving the value untouched.
 
-	resp, err := a.Client.R().SetResult(&stringlistResponse{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
+@returns the response envelope.
+*/
+func (a *ASCOMAlpacaAPIClient) get(ctx context.Context, deviceType string, deviceNumber uint, method string, querystring string, value interface{}) (TransportEnvelope, error) {
+	resp, err := a.send(ctx, http.MethodGet, deviceType, deviceNumber, method, querystring, nil)
 
 	if err != nil {
-		return []string{""}, err
+		return TransportEnvelope{}, err
 	}
 
 	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
+	if resp.StatusCode >= http.StatusBadRequest {
+		a.ErrorNumber = resp.StatusCode
+		a.ErrorMessage = resp.Text
+		return TransportEnvelope{}, nil
 	}
 
-	// Return the result:
-	result := (resp.Result().(*stringlistResponse))
+	return resp.Envelope, resp.Envelope.decodeValue(value)
+}
 
-	return result.Value, nil
+/*
+GetStringResponse()
+
+Global public method to work with calls returning a string Value
+*/
+func (a *ASCOMAlpacaAPIClient) GetStringResponse(deviceType string, deviceNumber uint, method string) (string, error) {
+	var value string
+
+	if _, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value); err != nil {
+		return "", err
+	}
+
+	return value, nil
 }
 
-type booleanResponse struct {
-	Value               bool   `json:"Value"`
-	ClientTransactionID uint32 `json:"ClientTransactionID"`
-	ServerTransactionID uint32 `json:"ServerTransactionID"`
-	ErrorNumber         int32  `json:"ErrorNumber"`
-	ErrorMessage        string `json:"ErrorMessage"`
+/*
+GetStringListResponse()
+
+Global public method to work with calls returning a []string Value
+*/
+func (a *ASCOMAlpacaAPIClient) GetStringListResponse(deviceType string, deviceNumber uint, method string) ([]string, error) {
+	var value []string
+
+	if _, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value); err != nil {
+		return []string{""}, err
+	}
+
+	return value, nil
 }
 
 /*
 GetBooleanResponse()
 
-Global public method to work with calls returning booleanResponse
+Global public method to work with calls returning a bool Value
 */
 func (a *ASCOMAlpacaAPIClient) GetBooleanResponse(deviceType string, deviceNumber uint, method string) (bool, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
-
-	resp, err := a.Client.R().SetResult(&booleanResponse{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
+	var value bool
 
-	if err != nil {
+	if _, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value); err != nil {
 		return false, err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*booleanResponse))
-
-	return result.Value, nil
-}
-
-type float64Response struct {
-	Value               float64 `json:"Value"`
-	ClientTransactionID int32   `json:"ClientTransactionID"`
-	ServerTransactionID int32   `json:"ServerTransactionID"`
-	ErrorNumber         int32   `json:"ErrorNumber"`
-	ErrorMessage        string  `json:"ErrorMessage"`
+	return value, nil
 }
 
 /*
 GetFloat64Response()
 
-Global public method to work with calls returning float64Response
+Global public method to work with calls returning a float64 Value
 */
 func (a *ASCOMAlpacaAPIClient) GetFloat64Response(deviceType string, deviceNumber uint, method string) (float64, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
+	var value float64
 
-	resp, err := a.Client.R().SetResult(&float64Response{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
-
-	if err != nil {
+	if _, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value); err != nil {
 		return 0, err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*float64Response))
-
-	return result.Value, nil
-}
-
-type int32Response struct {
-	Value               int32  `json:"Value"`
-	ClientTransactionID uint32 `json:"ClientTransactionID"`
-	ServerTransactionID uint32 `json:"ServerTransactionID"`
-	ErrorNumber         int32  `json:"ErrorNumber"`
-	ErrorMessage        string `json:"ErrorMessage"`
+	return value, nil
 }
 
 /*
 GetInt32Response()
 
-Global public method to work with calls returning int32Response
+Global public method to work with calls returning an int32 Value
 */
 func (a *ASCOMAlpacaAPIClient) GetInt32Response(deviceType string, deviceNumber uint, method string) (int32, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
-
-	resp, err := a.Client.R().SetResult(&int32Response{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
+	var value int32
 
-	if err != nil {
+	if _, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value); err != nil {
 		return 0, err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*int32Response))
-
-	return result.Value, nil
-}
-
-type uint32listResponse struct {
-	Value               []uint32 `json:"Value"`
-	ClientTransactionID int32    `json:"ClientTransactionID"`
-	ServerTransactionID int32    `json:"ServerTransactionID"`
-	ErrorNumber         int32    `json:"ErrorNumber"`
-	ErrorMessage        string   `json:"ErrorMessage"`
+	return value, nil
 }
 
 /*
-GetInt32ListResponse()
+GetUInt32ListResponse()
 
-Global public method to work with calls returning int32listResponse
+Global public method to work with calls returning a []uint32 Value
 */
 func (a *ASCOMAlpacaAPIClient) GetUInt32ListResponse(deviceType string, deviceNumber uint, method string) ([]uint32, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
-
-	resp, err := a.Client.R().SetResult(&uint32listResponse{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
+	var value []uint32
 
-	if err != nil {
+	if _, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value); err != nil {
 		return []uint32{}, err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*uint32listResponse))
-
-	return result.Value, nil
-}
-
-type uint32Rank2ArrayResponse struct {
-	Value               [][]uint32 `json:"Value"`
-	Rank                uint32     `json:"Rank"`
-	ClientTransactionID int32      `json:"ClientTransactionID"`
-	ServerTransactionID int32      `json:"ServerTransactionID"`
-	ErrorNumber         int32      `json:"ErrorNumber"`
-	ErrorMessage        string     `json:"ErrorMessage"`
+	return value, nil
 }
 
 /*
-GetUInt32RankArrayResponse()
+GetUInt32Rank2ArrayResponse()
 
-Global public method to work with calls returning a uint32Rank2ArrayResponse
+Global public method to work with calls returning a [][]uint32 Value, with its Rank
 */
 func (a *ASCOMAlpacaAPIClient) GetUInt32Rank2ArrayResponse(deviceType string, deviceNumber uint, method string) ([][]uint32, uint32, error) {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
+	var value [][]uint32
 
-	resp, err := a.Client.R().SetResult(&uint32Rank2ArrayResponse{}).SetQueryString(a.getQueryString()).SetHeader("Accept", "application/json").Get(url)
+	envelope, err := a.get(context.Background(), deviceType, deviceNumber, method, a.getQueryString(), &value)
 
 	if err != nil {
 		return [][]uint32{}, 0, err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*uint32Rank2ArrayResponse))
-
-	return result.Value, result.Rank, nil
+	return value, envelope.Rank, nil
 }
 
-type putResponse struct {
-	ClientTransactionID uint32 `json:"ClientTransactionID"`
-	ServerTransactionID uint32 `json:"ServerTransactionID"`
-	ErrorNumber         int32  `json:"ErrorNumber"`
-	ErrorMessage        string `json:"ErrorMessage"`
-}
+/*
+Put()
 
-func (a *ASCOMAlpacaAPIClient) Put(deviceType string, deviceNumber uint, method string, form map[string]string) error {
-	// Build the ASCOM endpoint:
-	url := a.getEndpoint(deviceType, deviceNumber, method)
+Sends a PUT request for the device method. A REST error is recorded in the client's ErrorNumber and
+ErrorMessage; as with resty's SetResult before transports, a successful response which is not an Alpaca
+JSON envelope is not an error.
 
-	resp, err := a.Client.R().SetHeader("Content-Type", "application/x-www-form-urlencoded").SetResult(&putResponse{}).SetHeader("Accept", "application/json").SetFormData(form).Put(url)
+@returns the ASCOM error of the response envelope, if any.
+*/
+func (a *ASCOMAlpacaAPIClient) Put(deviceType string, deviceNumber uint, method string, form map[string]string) error {
+	resp, err := a.send(context.Background(), http.MethodPut, deviceType, deviceNumber, method, "", form)
 
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrInvalidEnvelope) {
 		return err
 	}
 
 	// If the response object has a REST error:
-	if resp.IsError() {
-		a.ErrorNumber = resp.StatusCode()
-		a.ErrorMessage = resp.String()
+	if resp.StatusCode >= http.StatusBadRequest {
+		a.ErrorNumber = resp.StatusCode
+		a.ErrorMessage = resp.Text
 	}
 
-	// Return the result:
-	result := (resp.Result().(*putResponse))
-
-	if result.ErrorNumber != 0 {
-		return fmt.Errorf("%d: %s", result.ErrorNumber, result.ErrorMessage)
+	if resp.Envelope.ErrorNumber != 0 {
+		return &ASCOMError{Number: resp.Envelope.ErrorNumber, Message: resp.Envelope.ErrorMessage}
 	}
 
-	log.Debugf("%v", result)
-
 	return nil
 }
 
@@ -0,0 +1,566 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type AutofocusFocuser interface {
+	IsAbsolute() (bool, error)
+	IsMoving() (bool, error)
+	GetPosition() (int32, error)
+	SetMove(position int32) error
+}
+
+type FocusFitMethod int32
+
+const (
+	// HFR = a·√(1 + ((x - c)/b)²), fitted as a quadratic in HFR²
+	FocusFitHyperbola FocusFitMethod = iota
+	// HFR = a·x² + b·x + c
+	FocusFitParabola
+	// Two straight lines, fitted either side of the minimum, intersecting at best focus
+	FocusFitVCurve
+)
+
+func (m FocusFitMethod) String() string {
+	name := []string{"hyperbola", "parabola", "vcurve"}
+
+	i := uint8(m)
+
+	switch {
+	case i <= uint8(FocusFitVCurve):
+		return name[i]
+	default:
+		return strconv.Itoa(int(i))
+	}
+}
+
+type FocusPoint struct {
+	Position int32
+	// The median half-flux radius (pixels), or NaN if too few stars were detected
+	HFR float64
+	// The median full width at half maximum (pixels), or NaN if too few stars were detected
+	FWHM  float64
+	Stars int
+}
+
+type FocusFit struct {
+	Method FocusFitMethod
+	// The (fractional) focuser position of best focus
+	Position float64
+	// The modelled half-flux radius at best focus
+	HFR float64
+	// The fitted model coefficients: [a, b, c] for the hyperbola and parabola, and
+	// [slope, intercept] for the left then right lines of the V-curve
+	Coefficients []float64
+	// The coefficient of determination of the fit against the measured HFR
+	RSquared float64
+}
+
+type AutofocusOptions struct {
+	// The focuser step between successive samples
+	StepSize int32
+	// The number of samples taken either side of the starting position
+	StepsOut int
+	// The exposure duration (seconds) of each sample
+	ExposureDuration float64
+	// Moves inwards overshoot by this many steps, so every final approach is outwards
+	Backlash int32
+	Method   FocusFitMethod
+	// Samples with fewer stars are excluded from the fit
+	MinStars     int
+	Detection    StarDetectionOptions
+	PollInterval time.Duration
+}
+
+func NewAutofocusOptions() AutofocusOptions {
+	return AutofocusOptions{
+		StepSize:         100,
+		StepsOut:         4,
+		ExposureDuration: 2,
+		Backlash:         0,
+		Method:           FocusFitHyperbola,
+		MinStars:         3,
+		Detection:        NewStarDetectionOptions(),
+		PollInterval:     250 * time.Millisecond,
+	}
+}
+
+type AutofocusReport struct {
+	Start           time.Time
+	End             time.Time
+	InitialPosition int32
+	// Whether the focuser is absolute, otherwise the positions are relative to the start of the run
+	Absolute     bool
+	Points       []FocusPoint
+	Fit          FocusFit
+	BestPosition int32
+	// The measured HFR of a confirmation exposure at the best position
+	FinalHFR float64
+	// The focuser temperature (°C) at the start of the run, or NaN if unavailable
+	Temperature float64
+	// The error which ended the run, or nil if it succeeded
+	Error error
+}
+
+type Autofocus struct {
+	Focuser AutofocusFocuser
+	Camera  ExposureCamera
+	Options AutofocusOptions
+
+	absolute bool
+	position int32
+	// The maximum position of an absolute focuser, or zero if unknown
+	maxStep int32
+}
+
+func NewAutofocus(focuser AutofocusFocuser, camera ExposureCamera, options AutofocusOptions) *Autofocus {
+	autofocus := Autofocus{
+		Focuser: focuser,
+		Camera:  camera,
+		Options: options,
+	}
+
+	return &autofocus
+}
+
+/*
+moveTo()
+
+Moves the focuser to the target position, overshooting inward moves by the backlash so that
+the final approach is always outwards, and waits for the move to complete.
+*/
+func (a *Autofocus) moveTo(ctx context.Context, target int32) error {
+	if target < a.position && a.Options.Backlash > 0 {
+		overshoot := target - a.Options.Backlash
+
+		if a.absolute {
+			overshoot = a.limit(overshoot)
+		}
+
+		if overshoot < target {
+			if err := a.step(ctx, overshoot); err != nil {
+				return err
+			}
+		}
+	}
+
+	return a.step(ctx, target)
+}
+
+/*
+limit()
+
+@returns the position clamped to the travel of an absolute focuser, from 0 to its maximum step (if known).
+*/
+func (a *Autofocus) limit(position int32) int32 {
+	if a.maxStep > 0 {
+		position = min(position, a.maxStep)
+	}
+
+	return max(position, 0)
+}
+
+func (a *Autofocus) step(ctx context.Context, target int32) error {
+	var err error
+
+	// Relative focusers are commanded with the step distance, rather than the position:
+	if a.absolute {
+		err = a.Focuser.SetMove(target)
+	} else {
+		err = a.Focuser.SetMove(target - a.position)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	a.position = target
+
+	return WaitWhileMoving(ctx, a.Options.PollInterval, a.Focuser.IsMoving)
+}
+
+/*
+measure()
+
+@returns the focus point measured from a single exposure at the current position.
+*/
+func (a *Autofocus) measure(ctx context.Context) (FocusPoint, error) {
+	image, err := Capture(ctx, a.Camera, a.Options.ExposureDuration, true, a.Options.PollInterval)
+
+	if err != nil {
+		return FocusPoint{}, err
+	}
+
+	stars := DetectStars(image, a.Options.Detection)
+
+	point := FocusPoint{
+		Position: a.position,
+		HFR:      math.NaN(),
+		FWHM:     math.NaN(),
+		Stars:    len(stars),
+	}
+
+	if len(stars) >= a.Options.MinStars && len(stars) > 0 {
+		point.HFR = GetMedianHFR(stars)
+		point.FWHM = GetMedianFWHM(stars)
+	}
+
+	return point, nil
+}
+
+/*
+Run()
+
+Steps the focuser outwards across the configured range centred on the current position, measures
+the median HFR at each step, fits the focus curve and moves to the best focus position. If the fit
+fails, or best focus lies outside of the sampled range, the focuser is returned to its initial position.
+
+@returns the report of the run, including the curve data, even when the run fails.
+*/
+func (a *Autofocus) Run(ctx context.Context) (_ *AutofocusReport, err error) {
+	report := AutofocusReport{
+		Start:       time.Now(),
+		Points:      []FocusPoint{},
+		FinalHFR:    math.NaN(),
+		Temperature: math.NaN(),
+	}
+
+	defer func() {
+		report.End, report.Error = time.Now(), err
+	}()
+
+	absolute, err := a.Focuser.IsAbsolute()
+
+	if err != nil {
+		return &report, err
+	}
+
+	a.absolute, report.Absolute = absolute, absolute
+
+	a.position, a.maxStep = 0, 0
+
+	if absolute {
+		if a.position, err = a.Focuser.GetPosition(); err != nil {
+			return &report, err
+		}
+
+		if limited, ok := a.Focuser.(interface{ GetMaxStep() (int32, error) }); ok {
+			if a.maxStep, err = limited.GetMaxStep(); err != nil {
+				return &report, err
+			}
+		}
+	}
+
+	report.InitialPosition = a.position
+
+	if thermometer, ok := a.Focuser.(interface{ GetTemperature() (float64, error) }); ok {
+		if temperature, err := thermometer.GetTemperature(); err == nil {
+			report.Temperature = temperature
+		}
+	}
+
+	span := 2 * int32(a.Options.StepsOut) * a.Options.StepSize
+
+	start := a.position - span/2
+
+	// The sweep is shifted, rather than truncated, to keep it within the focuser's travel:
+	if absolute {
+		if a.maxStep > 0 && start+span > a.maxStep {
+			start = a.maxStep - span
+		}
+
+		start = a.limit(start)
+	}
+
+	for i := 0; i <= 2*a.Options.StepsOut; i++ {
+		if err := a.moveTo(ctx, start+int32(i)*a.Options.StepSize); err != nil {
+			return &report, err
+		}
+
+		point, err := a.measure(ctx)
+
+		if err != nil {
+			return &report, err
+		}
+
+		report.Points = append(report.Points, point)
+	}
+
+	fit, err := FitFocusCurve(report.Points, a.Options.Method)
+
+	if err == nil {
+		first, last := report.Points[0].Position, report.Points[len(report.Points)-1].Position
+
+		if fit.Position < float64(first) || fit.Position > float64(last) {
+			err = fmt.Errorf("best focus at %.0f lies outside of the sampled range %d to %d", fit.Position, first, last)
+		}
+	}
+
+	report.Fit = fit
+
+	if err != nil {
+		if moveErr := a.moveTo(ctx, report.InitialPosition); moveErr != nil {
+			return &report, errors.Join(err, moveErr)
+		}
+
+		return &report, err
+	}
+
+	report.BestPosition = int32(math.Round(fit.Position))
+
+	if err := a.moveTo(ctx, report.BestPosition); err != nil {
+		return &report, err
+	}
+
+	point, err := a.measure(ctx)
+
+	if err != nil {
+		return &report, err
+	}
+
+	report.FinalHFR = point.HFR
+
+	return &report, nil
+}
+
+/*
+FitFocusCurve()
+
+Fits the focus curve through the points with a valid HFR.
+
+@returns the fitted curve, or an error if there are too few points or the curve has no minimum.
+*/
+func FitFocusCurve(points []FocusPoint, method FocusFitMethod) (FocusFit, error) {
+	xs, ys := []float64{}, []float64{}
+
+	for _, p := range points {
+		if !math.IsNaN(p.HFR) {
+			xs = append(xs, float64(p.Position))
+			ys = append(ys, p.HFR)
+		}
+	}
+
+	fit := FocusFit{Method: method}
+
+	if len(xs) < 3 {
+		return fit, fmt.Errorf("at least 3 focus points with stars are required, but only %d were measured", len(xs))
+	}
+
+	var model func(x float64) float64
+
+	switch method {
+	case FocusFitParabola:
+		a, b, c, err := fitQuadratic(xs, ys)
+
+		if err != nil {
+			return fit, err
+		}
+
+		if a <= 0 {
+			return fit, errors.New("the parabolic focus curve has no minimum")
+		}
+
+		model = func(x float64) float64 { return a*x*x + b*x + c }
+
+		fit.Position = -b / (2 * a)
+		fit.Coefficients = []float64{a, b, c}
+
+	case FocusFitHyperbola:
+		squares := make([]float64, len(ys))
+
+		for i, y := range ys {
+			squares[i] = y * y
+		}
+
+		A, B, C, err := fitQuadratic(xs, squares)
+
+		if err != nil {
+			return fit, err
+		}
+
+		centre := -B / (2 * A)
+
+		minimum := C - B*B/(4*A)
+
+		if A <= 0 || minimum <= 0 {
+			return fit, errors.New("the hyperbolic focus curve has no minimum")
+		}
+
+		a := math.Sqrt(minimum)
+
+		b := a / math.Sqrt(A)
+
+		model = func(x float64) float64 { return a * math.Sqrt(1+math.Pow((x-centre)/b, 2)) }
+
+		fit.Position = centre
+		fit.Coefficients = []float64{a, b, centre}
+
+	case FocusFitVCurve:
+		// Split the points either side of the lowest HFR, which is excluded from both lines:
+		order := make([]int, len(xs))
+
+		for i := range order {
+			order[i] = i
+		}
+
+		sort.Slice(order, func(i, j int) bool { return xs[order[i]] < xs[order[j]] })
+
+		lowest := 0
+
+		for i, k := range order {
+			if ys[k] < ys[order[lowest]] {
+				lowest = i
+			}
+		}
+
+		lx, ly, rx, ry := []float64{}, []float64{}, []float64{}, []float64{}
+
+		for i, k := range order {
+			if i < lowest {
+				lx, ly = append(lx, xs[k]), append(ly, ys[k])
+			}
+
+			if i > lowest {
+				rx, ry = append(rx, xs[k]), append(ry, ys[k])
+			}
+		}
+
+		if len(lx) < 2 || len(rx) < 2 {
+			return fit, errors.New("the V-curve requires at least 2 focus points either side of the minimum")
+		}
+
+		ml, bl := fitLine(lx, ly)
+
+		mr, br := fitLine(rx, ry)
+
+		if ml >= 0 || mr <= 0 {
+			return fit, errors.New("the V-curve focus lines do not form a minimum")
+		}
+
+		model = func(x float64) float64 { return math.Max(ml*x+bl, mr*x+br) }
+
+		fit.Position = (br - bl) / (ml - mr)
+		fit.Coefficients = []float64{ml, bl, mr, br}
+
+	default:
+		return fit, fmt.Errorf("unknown focus fit method %v", method)
+	}
+
+	fit.HFR = model(fit.Position)
+
+	mean := 0.
+
+	for _, y := range ys {
+		mean += y / float64(len(ys))
+	}
+
+	var residual, total float64 = 0, 0
+
+	for i, x := range xs {
+		residual += math.Pow(ys[i]-model(x), 2)
+		total += math.Pow(ys[i]-mean, 2)
+	}
+
+	fit.RSquared = 1
+
+	if total > 0 {
+		fit.RSquared = 1 - residual/total
+	}
+
+	return fit, nil
+}
+
+/*
+fitLine()
+
+@returns the least-squares slope and intercept of y = m·x + b.
+*/
+func fitLine(xs []float64, ys []float64) (float64, float64) {
+	n := float64(len(xs))
+
+	var sx, sy, sxx, sxy float64 = 0, 0, 0, 0
+
+	for i, x := range xs {
+		sx += x
+		sy += ys[i]
+		sxx += x * x
+		sxy += x * ys[i]
+	}
+
+	m := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+
+	return m, (sy - m*sx) / n
+}
+
+/*
+fitQuadratic()
+
+@returns the least-squares coefficients of y = a·x² + b·x + c.
+*/
+func fitQuadratic(xs []float64, ys []float64) (float64, float64, float64, error) {
+	// Centre the abscissae to keep the normal equations well conditioned:
+	x0 := 0.
+
+	for _, x := range xs {
+		x0 += x / float64(len(xs))
+	}
+
+	var s [5]float64
+
+	var t [3]float64
+
+	for i, x := range xs {
+		u := x - x0
+
+		p := 1.
+
+		for k := 0; k < 5; k++ {
+			s[k] += p
+
+			if k < 3 {
+				t[k] += p * ys[i]
+			}
+
+			p *= u
+		}
+	}
+
+	// Solve [s4 s3 s2; s3 s2 s1; s2 s1 s0]·[a b c] = [t2 t1 t0] by Cramer's rule:
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+
+	m := [3][3]float64{{s[4], s[3], s[2]}, {s[3], s[2], s[1]}, {s[2], s[1], s[0]}}
+
+	d := det(m)
+
+	if math.Abs(d) < 1e-12 {
+		return 0, 0, 0, errors.New("the focus points are degenerate and cannot be fitted")
+	}
+
+	solution := [3]float64{}
+
+	for k := 0; k < 3; k++ {
+		mk := m
+
+		for r := 0; r < 3; r++ {
+			mk[r][k] = t[2-r]
+		}
+
+		solution[k] = det(mk) / d
+	}
+
+	a, b, c := solution[0], solution[1], solution[2]
+
+	// Expand a·(x - x0)² + b·(x - x0) + c back to the original abscissae:
+	return a, b - 2*a*x0, a*x0*x0 - b*x0 + c, nil
+}
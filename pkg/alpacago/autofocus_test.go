@@ -0,0 +1,290 @@
+package alpacago
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+type simulatedFocuser struct {
+	position    int32
+	absolute    bool
+	temperature float64
+	maxStep     int32
+	moves       []int32
+}
+
+func (f *simulatedFocuser) IsAbsolute() (bool, error) { return f.absolute, nil }
+
+func (f *simulatedFocuser) IsMoving() (bool, error) { return false, nil }
+
+func (f *simulatedFocuser) GetPosition() (int32, error) { return f.position, nil }
+
+func (f *simulatedFocuser) GetMaxStep() (int32, error) { return f.maxStep, nil }
+
+func (f *simulatedFocuser) GetTemperature() (float64, error) { return f.temperature, nil }
+
+func (f *simulatedFocuser) SetMove(position int32) error {
+	if f.absolute {
+		f.position = position
+	} else {
+		f.position += position
+	}
+
+	f.moves = append(f.moves, f.position)
+
+	return nil
+}
+
+/*
+simulatedFocusCamera renders a star field whose blur grows hyperbolically with the
+focuser's distance from best focus.
+*/
+type simulatedFocusCamera struct {
+	focuser   *simulatedFocuser
+	best      float64
+	exposures int
+}
+
+func (c *simulatedFocusCamera) StartExposure(duration float64, light bool) error {
+	c.exposures++
+	return nil
+}
+
+func (c *simulatedFocusCamera) IsImageReady() (bool, error) { return true, nil }
+
+func (c *simulatedFocusCamera) GetExposure() ([][]uint32, uint32, error) {
+	d := (float64(c.focuser.position) - c.best) / 150
+
+	sigma := 1.2 * math.Sqrt(1+d*d)
+
+	stars := []syntheticStar{{30, 30, 60000}, {90, 35, 50000}, {40, 90, 45000}, {95, 95, 55000}}
+
+	return renderStarField(128, 128, 200, sigma, stars), 2, nil
+}
+
+func newTestAutofocusOptions() AutofocusOptions {
+	options := NewAutofocusOptions()
+
+	options.PollInterval = time.Millisecond
+
+	options.Detection.Radius = 24
+
+	return options
+}
+
+func TestFocusFitMethodString(t *testing.T) {
+	var got string = FocusFitVCurve.String()
+	var want string = "vcurve"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestFitFocusCurveHyperbola(t *testing.T) {
+	points := []FocusPoint{}
+
+	for x := int32(1000); x <= 2000; x += 100 {
+		d := (float64(x) - 1530) / 120
+		points = append(points, FocusPoint{Position: x, HFR: 2 * math.Sqrt(1+d*d)})
+	}
+
+	fit, err := FitFocusCurve(points, FocusFitHyperbola)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(fit.Position-1530) > 0.01 {
+		t.Errorf("got %f, wanted %f", fit.Position, 1530.0)
+	}
+
+	if math.Abs(fit.HFR-2) > 0.0001 {
+		t.Errorf("got %f, wanted %f", fit.HFR, 2.0)
+	}
+
+	if fit.RSquared < 0.9999 {
+		t.Errorf("got %f, wanted a near perfect fit", fit.RSquared)
+	}
+}
+
+func TestFitFocusCurveParabola(t *testing.T) {
+	points := []FocusPoint{}
+
+	for x := int32(-300); x <= 300; x += 100 {
+		points = append(points, FocusPoint{Position: x, HFR: 0.0001*float64(x-40)*float64(x-40) + 1.5})
+	}
+
+	fit, err := FitFocusCurve(points, FocusFitParabola)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(fit.Position-40) > 0.01 {
+		t.Errorf("got %f, wanted %f", fit.Position, 40.0)
+	}
+}
+
+func TestFitFocusCurveVCurve(t *testing.T) {
+	points := []FocusPoint{}
+
+	for x := int32(0); x <= 1000; x += 100 {
+		points = append(points, FocusPoint{Position: x, HFR: 1 + math.Abs(float64(x)-620)/100})
+	}
+
+	fit, err := FitFocusCurve(points, FocusFitVCurve)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(fit.Position-620) > 0.01 {
+		t.Errorf("got %f, wanted %f", fit.Position, 620.0)
+	}
+}
+
+func TestFitFocusCurveIgnoresEmptyPoints(t *testing.T) {
+	points := []FocusPoint{{Position: 0, HFR: 3}, {Position: 100, HFR: math.NaN()}, {Position: 200, HFR: 3}}
+
+	if _, err := FitFocusCurve(points, FocusFitParabola); err == nil {
+		t.Errorf("got nil, wanted an error for too few points")
+	}
+}
+
+func TestFitFocusCurveWithoutMinimum(t *testing.T) {
+	points := []FocusPoint{{Position: 0, HFR: 1}, {Position: 100, HFR: 2}, {Position: 200, HFR: 2.5}, {Position: 300, HFR: 2.7}}
+
+	if _, err := FitFocusCurve(points, FocusFitParabola); err == nil {
+		t.Errorf("got nil, wanted an error for a curve without a minimum")
+	}
+}
+
+func TestAutofocusRun(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: true, temperature: 8.5}
+
+	camera := &simulatedFocusCamera{focuser: focuser, best: 5230}
+
+	autofocus := NewAutofocus(focuser, camera, newTestAutofocusOptions())
+
+	report, err := autofocus.Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(report.Points) != 9 {
+		t.Errorf("got %d points, wanted %d", len(report.Points), 9)
+	}
+
+	if math.Abs(float64(report.BestPosition)-5230) > 15 {
+		t.Errorf("got %d, wanted %d", report.BestPosition, 5230)
+	}
+
+	if focuser.position != report.BestPosition {
+		t.Errorf("got %d, wanted the focuser at %d", focuser.position, report.BestPosition)
+	}
+
+	if report.Temperature != 8.5 {
+		t.Errorf("got %f, wanted %f", report.Temperature, 8.5)
+	}
+
+	if report.FinalHFR > report.Points[0].HFR {
+		t.Errorf("got a final HFR of %f, wanted better than %f", report.FinalHFR, report.Points[0].HFR)
+	}
+
+	if camera.exposures != 10 {
+		t.Errorf("got %d exposures, wanted %d", camera.exposures, 10)
+	}
+}
+
+func TestAutofocusRunBacklashApproachesOutwards(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: true}
+
+	camera := &simulatedFocusCamera{focuser: focuser, best: 5100}
+
+	options := newTestAutofocusOptions()
+
+	options.Backlash = 50
+
+	report, err := NewAutofocus(focuser, camera, options).Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	// The final move inwards overshoots, before approaching best focus outwards:
+	n := len(focuser.moves)
+
+	if focuser.moves[n-2] != report.BestPosition-50 || focuser.moves[n-1] != report.BestPosition {
+		t.Errorf("got %v, wanted an overshoot to %d then %d", focuser.moves[n-2:], report.BestPosition-50, report.BestPosition)
+	}
+}
+
+func TestAutofocusRunStaysWithinTravel(t *testing.T) {
+	focuser := &simulatedFocuser{position: 9900, absolute: true, maxStep: 10000}
+
+	camera := &simulatedFocusCamera{focuser: focuser, best: 9700}
+
+	options := newTestAutofocusOptions()
+
+	options.Backlash = 500
+
+	if _, err := NewAutofocus(focuser, camera, options).Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	for _, position := range focuser.moves {
+		if position < 0 || position > 10000 {
+			t.Errorf("got a move to %d, wanted moves between 0 and 10000", position)
+		}
+	}
+
+	focuser = &simulatedFocuser{position: 100, absolute: true, maxStep: 10000}
+
+	camera = &simulatedFocusCamera{focuser: focuser, best: 300}
+
+	if _, err := NewAutofocus(focuser, camera, options).Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	for _, position := range focuser.moves {
+		if position < 0 {
+			t.Errorf("got a move to %d, wanted no moves below 0", position)
+		}
+	}
+}
+
+func TestAutofocusRunRelativeFocuser(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: false}
+
+	camera := &simulatedFocusCamera{focuser: focuser, best: 4900}
+
+	_, err := NewAutofocus(focuser, camera, newTestAutofocusOptions()).Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(float64(focuser.position)-4900) > 15 {
+		t.Errorf("got %d, wanted %d", focuser.position, 4900)
+	}
+}
+
+func TestAutofocusRunOutOfRangeReturnsToStart(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: true}
+
+	camera := &simulatedFocusCamera{focuser: focuser, best: 8000}
+
+	report, err := NewAutofocus(focuser, camera, newTestAutofocusOptions()).Run(context.Background())
+
+	if err == nil || report.Error != err {
+		t.Errorf("got %v (report %v), wanted an error for best focus outside of the sampled range", err, report.Error)
+	}
+
+	if focuser.position != 5000 {
+		t.Errorf("got %d, wanted the focuser returned to %d", focuser.position, 5000)
+	}
+}
@@ -0,0 +1,224 @@
+package alpacago
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+type PropertyType uint8
+
+const (
+	PropertyBoolean PropertyType = iota
+	PropertyFloat64
+	PropertyInt32
+	PropertyString
+	PropertyStringList
+	PropertyUInt32List
+)
+
+func (p PropertyType) String() string {
+	name := []string{"boolean", "float64", "int32", "string", "stringlist", "uint32list"}
+
+	i := uint8(p)
+
+	switch {
+	case i <= uint8(PropertyUInt32List):
+		return name[i]
+	default:
+		return fmt.Sprintf("PropertyType(%d)", i)
+	}
+}
+
+/*
+PropertyRequest identifies a single GET property of a device e.g., {"telescope", 0, "altitude", PropertyFloat64}.
+*/
+type PropertyRequest struct {
+	DeviceType   string
+	DeviceNumber uint
+	Method       string
+	Type         PropertyType
+}
+
+/*
+Key()
+
+@returns the key of the request in PropertyResults e.g., "telescope/0/altitude".
+*/
+func (r PropertyRequest) Key() string {
+	return fmt.Sprintf("%s/%d/%s", r.DeviceType, r.DeviceNumber, r.Method)
+}
+
+type PropertyResult struct {
+	Request PropertyRequest
+	// The value, of the Go type corresponding to the request's PropertyType (bool, float64, int32, string,
+	// []string or []uint32), or nil on error
+	Value interface{}
+	Error error
+}
+
+/*
+PropertyResults are the results of GetProperties(), keyed by PropertyRequest.Key().
+*/
+type PropertyResults map[string]PropertyResult
+
+/*
+getPropertyValue()
+
+@returns the value of the keyed property, or an error if it is missing, failed, or of another type.
+*/
+func getPropertyValue[T any](results PropertyResults, key string) (T, error) {
+	var value T
+
+	result, ok := results[key]
+
+	if !ok {
+		return value, fmt.Errorf("no property %q was requested", key)
+	}
+
+	if result.Error != nil {
+		return value, result.Error
+	}
+
+	value, ok = result.Value.(T)
+
+	if !ok {
+		return value, fmt.Errorf("property %q is a %s, not a %T", key, result.Request.Type, value)
+	}
+
+	return value, nil
+}
+
+func (p PropertyResults) GetBoolean(key string) (bool, error) { return getPropertyValue[bool](p, key) }
+
+func (p PropertyResults) GetFloat64(key string) (float64, error) {
+	return getPropertyValue[float64](p, key)
+}
+
+func (p PropertyResults) GetInt32(key string) (int32, error) { return getPropertyValue[int32](p, key) }
+
+func (p PropertyResults) GetString(key string) (string, error) {
+	return getPropertyValue[string](p, key)
+}
+
+func (p PropertyResults) GetStringList(key string) ([]string, error) {
+	return getPropertyValue[[]string](p, key)
+}
+
+func (p PropertyResults) GetUInt32List(key string) ([]uint32, error) {
+	return getPropertyValue[[]uint32](p, key)
+}
+
+/*
+getProperty()
+
+Reads a single property, without touching the client's shared ErrorNumber and ErrorMessage, so that it is
+safe to call concurrently.
+*/
+func (a *ASCOMAlpacaAPIClient) getProperty(ctx context.Context, request PropertyRequest) (interface{}, error) {
+	resp, err := a.send(ctx, http.MethodGet, request.DeviceType, request.DeviceNumber, request.Method, a.getQueryString(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%d: %s", resp.StatusCode, resp.Text)
+	}
+
+	result := resp.Envelope
+
+	if result.ErrorNumber != 0 {
+		return nil, &ASCOMError{Number: result.ErrorNumber, Message: result.ErrorMessage}
+	}
+
+	var value interface{}
+
+	switch request.Type {
+	case PropertyBoolean:
+		value, err = decodeProperty[bool](result.Value)
+	case PropertyFloat64:
+		value, err = decodeProperty[float64](result.Value)
+	case PropertyInt32:
+		value, err = decodeProperty[int32](result.Value)
+	case PropertyString:
+		value, err = decodeProperty[string](result.Value)
+	case PropertyStringList:
+		value, err = decodeProperty[[]string](result.Value)
+	case PropertyUInt32List:
+		value, err = decodeProperty[[]uint32](result.Value)
+	default:
+		return nil, fmt.Errorf("unsupported property type %s", request.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", request.Key(), err)
+	}
+
+	return value, nil
+}
+
+/*
+decodeProperty()
+
+@returns the JSON value decoded as the given type.
+*/
+func decodeProperty[T any](data json.RawMessage) (T, error) {
+	var value T
+
+	err := json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+/*
+GetProperties()
+
+Reads many properties at once, issuing the requests concurrently over the shared client with at most
+the given number of workers (all at once if workers <= 0), so that the total latency stays close to that
+of a single round trip.
+
+@returns the typed value, or error, of every request, keyed by PropertyRequest.Key().
+*/
+func (a *ASCOMAlpacaAPIClient) GetProperties(ctx context.Context, requests []PropertyRequest, workers int) PropertyResults {
+	if workers <= 0 || workers > len(requests) {
+		workers = len(requests)
+	}
+
+	results := make([]PropertyResult, len(requests))
+
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				value, err := a.getProperty(ctx, requests[i])
+				results[i] = PropertyResult{Request: requests[i], Value: value, Error: err}
+			}
+		}()
+	}
+
+	for i := range requests {
+		queue <- i
+	}
+
+	close(queue)
+
+	wg.Wait()
+
+	properties := PropertyResults{}
+
+	for _, result := range results {
+		properties[result.Request.Key()] = result
+	}
+
+	return properties
+}
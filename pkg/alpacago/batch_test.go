@@ -0,0 +1,161 @@
+package alpacago
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+newTestPropertyServer serves fixed property values, each after a delay, recording the peak number of
+requests in flight.
+*/
+func newTestPropertyServer(t *testing.T, delay time.Duration, values map[string]string) (*ASCOMAlpacaAPIClient, *int) {
+	var mu sync.Mutex
+
+	inflight, peak := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inflight++
+		peak = max(peak, inflight)
+		mu.Unlock()
+
+		time.Sleep(delay)
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+
+		value, ok := values[strings.TrimPrefix(r.URL.Path, "/api/v1/")]
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if !ok {
+			w.Write([]byte(`{"Value": null, "ErrorNumber": 1024, "ErrorMessage": "Property not implemented"}`))
+			return
+		}
+
+		w.Write([]byte(`{"Value": ` + value + `, "ErrorNumber": 0, "ErrorMessage": ""}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	p, _ := strconv.Atoi(port)
+
+	return NewAlpacaAPI(65535, false, "", host, int32(p)), &peak
+}
+
+func TestGetProperties(t *testing.T) {
+	values := map[string]string{
+		"telescope/0/altitude":       "45.5",
+		"telescope/0/tracking":       "true",
+		"telescope/0/sideofpier":     "1",
+		"telescope/0/name":           `"Simulator"`,
+		"filterwheel/0/names":        `["Red", "Green"]`,
+		"filterwheel/0/focusoffsets": "[0, 10]",
+	}
+
+	client, _ := newTestPropertyServer(t, 0, values)
+
+	requests := []PropertyRequest{
+		{"telescope", 0, "altitude", PropertyFloat64},
+		{"telescope", 0, "tracking", PropertyBoolean},
+		{"telescope", 0, "sideofpier", PropertyInt32},
+		{"telescope", 0, "name", PropertyString},
+		{"filterwheel", 0, "names", PropertyStringList},
+		{"filterwheel", 0, "focusoffsets", PropertyUInt32List},
+		{"telescope", 0, "azimuth", PropertyFloat64},
+	}
+
+	results := client.GetProperties(context.Background(), requests, 4)
+
+	if got, err := results.GetFloat64("telescope/0/altitude"); err != nil || got != 45.5 {
+		t.Errorf("got %f (%v), wanted %f", got, err, 45.5)
+	}
+
+	if got, err := results.GetBoolean("telescope/0/tracking"); err != nil || !got {
+		t.Errorf("got %t (%v), wanted true", got, err)
+	}
+
+	if got, err := results.GetInt32("telescope/0/sideofpier"); err != nil || got != 1 {
+		t.Errorf("got %d (%v), wanted %d", got, err, 1)
+	}
+
+	if got, err := results.GetString("telescope/0/name"); err != nil || got != "Simulator" {
+		t.Errorf("got %q (%v), wanted %q", got, err, "Simulator")
+	}
+
+	if got, err := results.GetStringList("filterwheel/0/names"); err != nil || len(got) != 2 || got[1] != "Green" {
+		t.Errorf("got %v (%v), wanted [Red Green]", got, err)
+	}
+
+	if got, err := results.GetUInt32List("filterwheel/0/focusoffsets"); err != nil || len(got) != 2 || got[1] != 10 {
+		t.Errorf("got %v (%v), wanted [0 10]", got, err)
+	}
+
+	// Errors are reported per property:
+	if _, err := results.GetFloat64("telescope/0/azimuth"); err == nil || !strings.Contains(err.Error(), "1024") {
+		t.Errorf("got %v, wanted the ASCOM error", err)
+	}
+
+	if _, err := results.GetBoolean("telescope/0/altitude"); err == nil {
+		t.Errorf("got nil, wanted an error reading a float64 as a boolean")
+	}
+
+	if _, err := results.GetFloat64("telescope/0/declination"); err == nil {
+		t.Errorf("got nil, wanted an error for a property which was not requested")
+	}
+}
+
+func TestGetPropertiesConcurrency(t *testing.T) {
+	values := map[string]string{}
+
+	requests := []PropertyRequest{}
+
+	for i := 0; i < 20; i++ {
+		method := "property" + strconv.Itoa(i)
+
+		values["camera/0/"+method] = strconv.Itoa(i)
+
+		requests = append(requests, PropertyRequest{"camera", 0, method, PropertyInt32})
+	}
+
+	delay := 100 * time.Millisecond
+
+	client, peak := newTestPropertyServer(t, delay, values)
+
+	start := time.Now()
+
+	results := client.GetProperties(context.Background(), requests, 10)
+
+	// Twenty requests over ten workers take two round trips, rather than twenty:
+	if elapsed := time.Since(start); elapsed > 5*delay {
+		t.Errorf("got %s, wanted close to %s", elapsed, 2*delay)
+	}
+
+	if *peak > 10 {
+		t.Errorf("got %d requests in flight, wanted at most %d", *peak, 10)
+	}
+
+	if got, err := results.GetInt32("camera/0/property19"); err != nil || got != 19 {
+		t.Errorf("got %d (%v), wanted %d", got, err, 19)
+	}
+}
+
+func TestPropertyTypeString(t *testing.T) {
+	var got string = PropertyUInt32List.String()
+	var want string = "uint32list"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
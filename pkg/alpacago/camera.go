@@ -688,6 +688,35 @@ func (c *Camera) SetSubFrameHeight(numY int32) error {
 	return c.Alpaca.Put("camera", c.DeviceNumber, "numy", form)
 }
 
+/*
+GetOffset()
+
+@returns the camera's offset (OFFSET VALUE MODE) OR the index of the selected camera offset description in the Offsets array (OFFSETS INDEX MODE).
+@see https://ascom-standards.org/api/#/Camera%20Specific%20Methods/get_camera__device_number__offset
+*/
+func (c *Camera) GetOffset() (int32, error) {
+	return c.Alpaca.GetInt32Response("camera", c.DeviceNumber, "offset")
+}
+
+/*
+SetOffset()
+
+@returns an error or nil, if nil it sets the offset to the specified value.
+@see https://ascom-standards.org/api/#/Camera%20Specific%20Methods/put_camera__device_number__offset
+*/
+func (c *Camera) SetOffset(offset int32) error {
+	c.Alpaca.TransactionId++
+
+	var form map[string]string = map[string]string{
+		// Set the offset (OFFSET VALUE MODE) OR the index of the selected camera offset description in the Offsets array (OFFSETS INDEX MODE).
+		"Offset":              fmt.Sprintf("%d", offset),
+		"ClientID":            fmt.Sprintf("%d", c.Alpaca.ClientId),
+		"ClientTransactionID": fmt.Sprintf("%d", c.Alpaca.TransactionId),
+	}
+
+	return c.Alpaca.Put("camera", c.DeviceNumber, "offset", form)
+}
+
 /*
 GetCurrentOperationPercentageComplete()
 
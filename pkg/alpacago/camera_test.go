@@ -1083,6 +1083,44 @@ func TestNewCameraGetSubFrameHeight(t *testing.T) {
 	}
 }
 
+func TestNewCameraSetOffset(t *testing.T) {
+	camera.SetConnected(true)
+
+	camera.SetOffset(10)
+
+	var got, err = camera.GetOffset()
+
+	if err != nil {
+		t.Errorf("got %q", err)
+	}
+
+	if got < 0 || got > 100 {
+		t.Errorf("got %v, but expected the offset value to be a realistic value", got)
+	}
+
+	if camera.Alpaca.ErrorNumber != 0 {
+		t.Errorf("got %q", camera.Alpaca.ErrorMessage)
+	}
+}
+
+func TestNewCameraGetOffset(t *testing.T) {
+	camera.SetConnected(true)
+
+	var got, err = camera.GetOffset()
+
+	if err != nil {
+		t.Errorf("got %q", err)
+	}
+
+	if got < 0 || got > 100 {
+		t.Errorf("got %v, but expected the offset value to be a realistic value", got)
+	}
+
+	if camera.Alpaca.ErrorNumber != 0 {
+		t.Errorf("got %q", camera.Alpaca.ErrorMessage)
+	}
+}
+
 func TestNewCameraGetCurrentOperationPercentageComplete(t *testing.T) {
 	camera.SetConnected(true)
 
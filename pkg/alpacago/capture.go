@@ -0,0 +1,155 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ExposureCamera interface {
+	StartExposure(duration float64, light bool) error
+	IsImageReady() (bool, error)
+	GetExposure() ([][]uint32, uint32, error)
+}
+
+/*
+operationalStateCamera is an ExposureCamera which reports its operational state, as Camera does, so that
+an exposure ending without an image is noticed rather than waited on forever.
+*/
+type operationalStateCamera interface {
+	GetOperationalState() (string, error)
+}
+
+/*
+ErrExposureAborted is returned, wrapped, by Capture when the exposure ends without an image e.g., aborted
+by a SafetySupervisor.
+*/
+var ErrExposureAborted = errors.New("the exposure was aborted")
+
+/*
+WaitUntil()
+
+Polls the condition at the given interval until it returns true, it errors, or the context is done.
+
+@returns nil once the condition is met, otherwise the condition or context error.
+*/
+func WaitUntil(ctx context.Context, interval time.Duration, condition func() (bool, error)) error {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		done, err := condition()
+
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+WaitWhileMoving()
+
+@returns nil once isMoving (e.g., Focuser.IsMoving or Rotator.IsMoving) reports false.
+*/
+func WaitWhileMoving(ctx context.Context, interval time.Duration, isMoving func() (bool, error)) error {
+	return WaitUntil(ctx, interval, func() (bool, error) {
+		moving, err := isMoving()
+		return !moving, err
+	})
+}
+
+/*
+Capture()
+
+Starts an exposure, waits for the image to become ready, and downloads it. A camera reporting its
+operational state (e.g., Camera) which returns to idle without an image, or reports an error, ends the
+wait with ErrExposureAborted.
+
+@param duration float64 (the exposure duration in seconds)
+@param light bool (true for a light frame, false for a dark frame)
+@returns the image array, indexed as image[x][y].
+*/
+func Capture(ctx context.Context, camera ExposureCamera, duration float64, light bool, interval time.Duration) ([][]uint32, error) {
+	return capture(ctx, camera, duration, light, interval, nil)
+}
+
+/*
+capture()
+
+Captures as Capture does, also ending the wait with the error returned by interrupt (if not nil) e.g.,
+once conditions become unsafe.
+*/
+func capture(ctx context.Context, camera ExposureCamera, duration float64, light bool, interval time.Duration, interrupt func() error) ([][]uint32, error) {
+	if err := camera.StartExposure(duration, light); err != nil {
+		return nil, err
+	}
+
+	busy := false
+
+	err := WaitUntil(ctx, interval, func() (bool, error) {
+		ready, err := camera.IsImageReady()
+
+		if err != nil || ready {
+			return ready, err
+		}
+
+		if interrupt != nil {
+			if err := interrupt(); err != nil {
+				return false, err
+			}
+		}
+
+		stater, ok := camera.(operationalStateCamera)
+
+		if !ok {
+			return false, nil
+		}
+
+		state, err := stater.GetOperationalState()
+
+		if err != nil {
+			return false, err
+		}
+
+		switch state {
+		case CameraError.String():
+			return false, fmt.Errorf("%w: the camera state is error", ErrExposureAborted)
+		case CameraIdle.String():
+			// A camera may still be idle just after the exposure starts, but idle once busy is the exposure
+			// ending, with an image unless it was aborted:
+			if busy {
+				ready, err := camera.IsImageReady()
+
+				if err == nil && !ready {
+					err = ErrExposureAborted
+				}
+
+				return ready, err
+			}
+		default:
+			busy = true
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	image, _, err := camera.GetExposure()
+
+	return image, err
+}
@@ -0,0 +1,272 @@
+package alpacago
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+type CenteringTelescope interface {
+	CoordinateTelescope
+	SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error
+	SetSyncToCoordinates(rightAscension float64, declination float64) error
+	IsSlewing() (bool, error)
+}
+
+/*
+PlateSolution is the result of plate solving an image.
+*/
+type PlateSolution struct {
+	// The J2000 coordinates of the centre of the image
+	Center EquatorialCoordinate `json:"center"`
+	// The image scale (arcseconds per pixel)
+	PixelScale float64 `json:"pixelScale"`
+	// The position angle of the image's "up" direction (degrees, East of North)
+	Rotation float64 `json:"rotation"`
+}
+
+/*
+Solver plate solves an image, e.g., by handing it to an external solver such as astrometry.net or ASTAP.
+*/
+type Solver interface {
+	// Solve is given the J2000 coordinates the image is expected to be centred on, as a hint
+	Solve(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error)
+}
+
+/*
+FileSolver is a Solver that replays the plate solutions recorded in a JSON file, as an array of
+PlateSolution, returning the next solution on each call. It stands in for a real solver when
+testing, or rehearsing a session, without a sky.
+*/
+type FileSolver struct {
+	Path string
+	mu   sync.Mutex
+	next int
+}
+
+func NewFileSolver(path string) *FileSolver {
+	return &FileSolver{Path: path}
+}
+
+/*
+Solve()
+
+@returns the next solution recorded in the file, ignoring the image and the hint.
+*/
+func (s *FileSolver) Solve(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error) {
+	s.mu.Lock()
+
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.Path)
+
+	if err != nil {
+		return PlateSolution{}, err
+	}
+
+	solutions := []PlateSolution{}
+
+	if err := json.Unmarshal(data, &solutions); err != nil {
+		return PlateSolution{}, fmt.Errorf("invalid plate solutions file %s: %w", s.Path, err)
+	}
+
+	if s.next >= len(solutions) {
+		return PlateSolution{}, fmt.Errorf("all %d plate solutions in %s have been used", len(solutions), s.Path)
+	}
+
+	s.next++
+
+	return solutions[s.next-1], nil
+}
+
+var ErrNotCentered = errors.New("the target was not centred within tolerance")
+
+/*
+CenteringIteration records the pointing error found by one exposure and plate solve.
+*/
+type CenteringIteration struct {
+	Iteration int
+	Solution  PlateSolution
+	// The pointing error (arcseconds), in total and along each axis (the right ascension error on the sky)
+	Error               float64
+	RightAscensionError float64
+	DeclinationError    float64
+}
+
+type CenteringReport struct {
+	Target     EquatorialCoordinate
+	Iterations []CenteringIteration
+	Centered   bool
+	// The pointing error (arcseconds) after the final iteration
+	Residual float64
+}
+
+/*
+Centering slews to a target, then repeatedly takes an exposure, plate solves it and corrects the
+pointing until the target is centred to within Tolerance.
+*/
+type Centering struct {
+	Telescope CenteringTelescope
+	Camera    ExposureCamera
+	Solver    Solver
+	// The exposure duration (seconds) of the images to solve
+	Exposure float64
+	// The pointing error (arcseconds) within which the target is centred
+	Tolerance     float64
+	MaxIterations int
+	// Whether to sync the mount to the solved position before re-slewing to the target, rather than
+	// offsetting the slew by the pointing error (for mounts which do not support syncing)
+	Sync         bool
+	PollInterval time.Duration
+	// Called after each exposure has been solved
+	OnIteration func(iteration CenteringIteration)
+	now         func() time.Time
+}
+
+func NewCentering(telescope CenteringTelescope, camera ExposureCamera, solver Solver) *Centering {
+	centering := Centering{
+		Telescope:     telescope,
+		Camera:        camera,
+		Solver:        solver,
+		Exposure:      5,
+		Tolerance:     30,
+		MaxIterations: 5,
+		Sync:          true,
+		PollInterval:  250 * time.Millisecond,
+		now:           time.Now,
+	}
+
+	return &centering
+}
+
+/*
+getTime()
+
+@returns the current time, for which the target is converted into the telescope's equatorial system.
+*/
+func (c *Centering) getTime() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+
+	return c.now()
+}
+
+/*
+slew()
+
+Slews to the given coordinates, in the telescope's equatorial system, and waits for the slew to complete.
+*/
+func (c *Centering) slew(ctx context.Context, position EquatorialCoordinate) error {
+	// Telescope.SetSlewToCoordinatesAsync() takes the right ascension in degrees:
+	if err := c.Telescope.SetSlewToCoordinatesAsync(position.RightAscension*15, position.Declination); err != nil {
+		return err
+	}
+
+	return WaitWhileMoving(ctx, c.PollInterval, c.Telescope.IsSlewing)
+}
+
+/*
+Center()
+
+Slews to the target and iterates until it is centred, or MaxIterations exposures have been solved.
+
+@param target EquatorialCoordinate (the J2000 coordinates to centre)
+@returns a report of each iteration, and an error wrapping ErrNotCentered if the target was not centred.
+*/
+func (c *Centering) Center(ctx context.Context, target EquatorialCoordinate) (CenteringReport, error) {
+	report := CenteringReport{Target: target}
+
+	if c.MaxIterations < 1 {
+		return report, errors.New("the maximum number of centering iterations must be at least 1")
+	}
+
+	position, err := ToTelescopeCoordinates(c.Telescope, target, c.getTime())
+
+	if err != nil {
+		return report, err
+	}
+
+	if err := c.slew(ctx, position); err != nil {
+		return report, err
+	}
+
+	for i := 1; i <= c.MaxIterations; i++ {
+		image, err := Capture(ctx, c.Camera, c.Exposure, true, c.PollInterval)
+
+		if err != nil {
+			return report, err
+		}
+
+		solution, err := c.Solver.Solve(ctx, image, target)
+
+		if err != nil {
+			return report, err
+		}
+
+		iteration := CenteringIteration{
+			Iteration:           i,
+			Solution:            solution,
+			Error:               GetEquatorialSeparation(solution.Center, target) * 3600,
+			RightAscensionError: getNormalisedAngle(solution.Center.RightAscension-target.RightAscension+12, 24) - 12,
+			DeclinationError:    (solution.Center.Declination - target.Declination) * 3600,
+		}
+
+		iteration.RightAscensionError *= 15 * 3600 * math.Cos(target.Declination*degreesToRadians)
+
+		report.Iterations = append(report.Iterations, iteration)
+
+		report.Residual = iteration.Error
+
+		if c.OnIteration != nil {
+			c.OnIteration(iteration)
+		}
+
+		if iteration.Error <= c.Tolerance {
+			report.Centered = true
+			return report, nil
+		}
+
+		if i == c.MaxIterations {
+			break
+		}
+
+		solved, err := ToTelescopeCoordinates(c.Telescope, solution.Center, c.getTime())
+
+		if err != nil {
+			return report, err
+		}
+
+		if c.Sync {
+			if err := c.Telescope.SetSyncToCoordinates(solved.RightAscension*15, solved.Declination); err != nil {
+				return report, err
+			}
+
+			if position, err = ToTelescopeCoordinates(c.Telescope, target, c.getTime()); err != nil {
+				return report, err
+			}
+		} else {
+			// The mount points where the image was solved, rather than where it was sent, so offset the
+			// slew by the difference:
+			desired, err := ToTelescopeCoordinates(c.Telescope, target, c.getTime())
+
+			if err != nil {
+				return report, err
+			}
+
+			position.RightAscension = getNormalisedAngle(position.RightAscension+desired.RightAscension-solved.RightAscension, 24)
+			position.Declination = math.Max(-90, math.Min(90, position.Declination+desired.Declination-solved.Declination))
+		}
+
+		if err := c.slew(ctx, position); err != nil {
+			return report, err
+		}
+	}
+
+	return report, fmt.Errorf("%w: %.1f\" after %d iterations, wanted %.1f\"", ErrNotCentered, report.Residual, len(report.Iterations), c.Tolerance)
+}
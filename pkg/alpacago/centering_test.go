@@ -0,0 +1,185 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+/*
+fakeCenteringTelescope is a J2000 mount whose pointing is off by a fixed error, until it is synced.
+*/
+type fakeCenteringTelescope struct {
+	fakeCoordinateTelescope
+	// The error (degrees) between where the mount reports it points and where it actually points
+	offset EquatorialCoordinate
+	syncs  [][2]float64
+	slews  [][2]float64
+}
+
+func (t *fakeCenteringTelescope) SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error {
+	t.slews = append(t.slews, [2]float64{rightAscension, declination})
+	t.c = EquatorialCoordinate{RightAscension: rightAscension / 15, Declination: declination}
+	return nil
+}
+
+func (t *fakeCenteringTelescope) SetSyncToCoordinates(rightAscension float64, declination float64) error {
+	t.syncs = append(t.syncs, [2]float64{rightAscension, declination})
+	t.offset = EquatorialCoordinate{}
+	t.c = EquatorialCoordinate{RightAscension: rightAscension / 15, Declination: declination}
+	return nil
+}
+
+func (t *fakeCenteringTelescope) IsSlewing() (bool, error) { return false, nil }
+
+/*
+getPointing()
+
+@returns where the mount actually points.
+*/
+func (t *fakeCenteringTelescope) getPointing() EquatorialCoordinate {
+	return EquatorialCoordinate{
+		RightAscension: t.c.RightAscension + t.offset.RightAscension,
+		Declination:    t.c.Declination + t.offset.Declination,
+	}
+}
+
+type solverFunc func(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error)
+
+func (f solverFunc) Solve(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error) {
+	return f(ctx, image, hint)
+}
+
+func newTestCentering(telescope *fakeCenteringTelescope, solver Solver) *Centering {
+	centering := NewCentering(telescope, &fakeSequenceCamera{}, solver)
+
+	centering.PollInterval = time.Millisecond
+
+	return centering
+}
+
+func TestCenteringSyncWithFileSolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "solutions.json")
+
+	// The first image is solved three arcminutes North of the target, the second within tolerance:
+	solutions := `[
+		{"center": {"RightAscension": 5.588, "Declination": -5.34}, "pixelScale": 1.2, "rotation": 10},
+		{"center": {"RightAscension": 5.588, "Declination": -5.3910}, "pixelScale": 1.2, "rotation": 10}
+	]`
+
+	if err := os.WriteFile(path, []byte(solutions), 0644); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	telescope := &fakeCenteringTelescope{fakeCoordinateTelescope: fakeCoordinateTelescope{system: J2000.String()}}
+
+	target := EquatorialCoordinate{RightAscension: 5.588, Declination: -5.39}
+
+	report, err := newTestCentering(telescope, NewFileSolver(path)).Center(context.Background(), target)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if !report.Centered || len(report.Iterations) != 2 || math.Abs(report.Residual-3.6) > 0.01 {
+		t.Errorf("got %+v, wanted centred after 2 iterations to 3.6\"", report)
+	}
+
+	if got := report.Iterations[0].DeclinationError; math.Abs(got-180) > 0.01 {
+		t.Errorf("got %f, wanted a declination error of %f", got, 180.0)
+	}
+
+	if len(telescope.syncs) != 1 || math.Abs(telescope.syncs[0][0]-83.82) > 1e-9 || telescope.syncs[0][1] != -5.34 {
+		t.Errorf("got %v, wanted a sync to the first solution", telescope.syncs)
+	}
+
+	if len(telescope.slews) != 2 {
+		t.Errorf("got %d slews, wanted %d", len(telescope.slews), 2)
+	}
+}
+
+func TestCenteringOffset(t *testing.T) {
+	telescope := &fakeCenteringTelescope{
+		fakeCoordinateTelescope: fakeCoordinateTelescope{system: J2000.String()},
+		offset:                  EquatorialCoordinate{RightAscension: 0.01, Declination: -0.2},
+	}
+
+	solver := solverFunc(func(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error) {
+		return PlateSolution{Center: telescope.getPointing()}, nil
+	})
+
+	centering := newTestCentering(telescope, solver)
+
+	centering.Sync = false
+
+	iterations := 0
+
+	centering.OnIteration = func(iteration CenteringIteration) { iterations++ }
+
+	target := EquatorialCoordinate{RightAscension: 23.99, Declination: 60}
+
+	report, err := centering.Center(context.Background(), target)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(telescope.syncs) != 0 || iterations != 2 || report.Residual > 1e-6 {
+		t.Errorf("got %d syncs and %d iterations to %f\", wanted the offset slew to centre the target", len(telescope.syncs), iterations, report.Residual)
+	}
+
+	// The offset slew wraps around 0h:
+	if got := telescope.slews[1][0] / 15; math.Abs(got-23.98) > 1e-9 {
+		t.Errorf("got %f, wanted %f", got, 23.98)
+	}
+}
+
+func TestCenteringNotCentred(t *testing.T) {
+	telescope := &fakeCenteringTelescope{fakeCoordinateTelescope: fakeCoordinateTelescope{system: J2000.String()}}
+
+	// A solver which never agrees with the mount:
+	solver := solverFunc(func(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error) {
+		return PlateSolution{Center: EquatorialCoordinate{RightAscension: hint.RightAscension, Declination: hint.Declination + 0.1}}, nil
+	})
+
+	centering := newTestCentering(telescope, solver)
+
+	centering.MaxIterations = 3
+
+	report, err := centering.Center(context.Background(), EquatorialCoordinate{RightAscension: 12, Declination: 0})
+
+	if !errors.Is(err, ErrNotCentered) {
+		t.Errorf("got %v, wanted %q", err, ErrNotCentered)
+	}
+
+	if report.Centered || len(report.Iterations) != 3 || len(telescope.syncs) != 2 {
+		t.Errorf("got %d iterations and %d syncs, wanted 3 and 2", len(report.Iterations), len(telescope.syncs))
+	}
+}
+
+func TestCenteringWithoutConstructor(t *testing.T) {
+	telescope := &fakeCenteringTelescope{fakeCoordinateTelescope: fakeCoordinateTelescope{system: J2000.String()}}
+
+	solver := solverFunc(func(ctx context.Context, image [][]uint32, hint EquatorialCoordinate) (PlateSolution, error) {
+		return PlateSolution{Center: telescope.getPointing()}, nil
+	})
+
+	centering := &Centering{
+		Telescope:     telescope,
+		Camera:        &fakeSequenceCamera{},
+		Solver:        solver,
+		Tolerance:     30,
+		MaxIterations: 1,
+		PollInterval:  time.Millisecond,
+	}
+
+	report, err := centering.Center(context.Background(), EquatorialCoordinate{RightAscension: 12, Declination: 0})
+
+	if err != nil || !report.Centered {
+		t.Errorf("got %+v (%v), wanted the target centred", report, err)
+	}
+}
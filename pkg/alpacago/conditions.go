@@ -1,6 +1,9 @@
 package alpacago
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type ObservingConditions struct {
 	Alpaca       *ASCOMAlpacaAPIClient
@@ -214,27 +217,15 @@ GetSensorDescription()
 @see https://ascom-standards.org/api/#/ObservingConditions%20Specific%20Methods/get_observingconditions__device_number__sensordescription
 */
 func (c *ObservingConditions) GetSensorDescription(sensorName string) (string, error) {
-	url := c.Alpaca.getEndpoint("observingconditions", c.DeviceNumber, "sensordescription")
-
 	querystring := fmt.Sprintf("sensorName=%v&%s", sensorName, c.Alpaca.getQueryString())
 
-	// Setup the resty client:
-	resp, err := c.Alpaca.Client.R().SetResult(&stringResponse{}).SetQueryString(querystring).SetHeader("Accept", "application/json").Get(url)
+	var value string
 
-	if err != nil {
+	if _, err := c.Alpaca.get(context.Background(), "observingconditions", c.DeviceNumber, "sensordescription", querystring, &value); err != nil {
 		return "", err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		c.Alpaca.ErrorNumber = resp.StatusCode()
-		c.Alpaca.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*stringResponse))
-
-	return result.Value, nil
+	return value, nil
 }
 
 /*
@@ -244,25 +235,13 @@ GetTimeSinceLastUpdate()
 @see https://ascom-standards.org/api/#/ObservingConditions%20Specific%20Methods/get_observingconditions__device_number__timesincelastupdate
 */
 func (c *ObservingConditions) GetTimeSinceLastUpdate(sensorName string) (float64, error) {
-	url := c.Alpaca.getEndpoint("observingconditions", c.DeviceNumber, "timesincelastupdate")
-
 	querystring := fmt.Sprintf("sensorName=%v&%s", sensorName, c.Alpaca.getQueryString())
 
-	// Setup the resty client:
-	resp, err := c.Alpaca.Client.R().SetResult(&float64Response{}).SetQueryString(querystring).SetHeader("Accept", "application/json").Get(url)
+	var value float64
 
-	if err != nil {
+	if _, err := c.Alpaca.get(context.Background(), "observingconditions", c.DeviceNumber, "timesincelastupdate", querystring, &value); err != nil {
 		return 0., err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		c.Alpaca.ErrorNumber = resp.StatusCode()
-		c.Alpaca.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*float64Response))
-
-	return result.Value, nil
+	return value, nil
 }
@@ -0,0 +1,356 @@
+package alpacago
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+type CoolingCamera interface {
+	GetCCDTemperature() (float64, error)
+	SetCCDTemperatureCoolerSetPoint(temperature float64) error
+	IsCoolerOn() (bool, error)
+	TurnCoolerOn() error
+	TurnCoolerOff() error
+	GetCoolerPowerLevel() (float64, error)
+	GetHeatSinkTemperature() (float64, error)
+}
+
+type DewPointSource interface {
+	GetDewPoint() (float64, error)
+}
+
+type CoolerStatus struct {
+	Time time.Time
+	// The setpoint most recently commanded (°C)
+	SetPoint float64
+	// The sensor temperature (°C)
+	Temperature float64
+	// The cooler power level (%)
+	Power float64
+	// The heat sink temperature (°C)
+	HeatSink float64
+}
+
+type CoolerController struct {
+	Camera CoolingCamera
+	// The rate at which the setpoint is ramped (°C per minute)
+	Rate float64
+	// The setpoint is changed in increments of this many degrees (°C)
+	StepSize float64
+	// The sensor is stable once within this many degrees of the setpoint (°C)
+	Tolerance float64
+	// The period the sensor must remain within tolerance to be considered stable
+	SettleTime time.Duration
+	// Cooling is abandoned if the cooler power reaches this level (%), or 0 to disable
+	MaxPower float64
+	// Cooling is abandoned if the heat sink exceeds this temperature (°C), or 0 to disable
+	MaxHeatSinkTemperature float64
+	// If set, targets below the dew point plus the margin are refused (optional)
+	DewPoint       DewPointSource
+	DewPointMargin float64
+	PollInterval   time.Duration
+	// Called each time the setpoint is changed, and each time the sensor is polled whilst settling
+	OnStatus func(status CoolerStatus)
+
+	setpoint float64
+	now      func() time.Time
+}
+
+func NewCoolerController(camera CoolingCamera) *CoolerController {
+	controller := CoolerController{
+		Camera:                 camera,
+		Rate:                   2,
+		StepSize:               1,
+		Tolerance:              0.5,
+		SettleTime:             time.Minute,
+		MaxPower:               100,
+		MaxHeatSinkTemperature: 0,
+		DewPointMargin:         0,
+		PollInterval:           5 * time.Second,
+		now:                    time.Now,
+	}
+
+	return &controller
+}
+
+/*
+getTime()
+
+@returns the current time, used to time the settling of the sensor temperature.
+*/
+func (c *CoolerController) getTime() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+
+	return c.now()
+}
+
+/*
+validate()
+
+@returns an error if the ramp rate or step size would stall the ramp, or never let it finish.
+*/
+func (c *CoolerController) validate() error {
+	if c.Rate <= 0 {
+		return fmt.Errorf("the ramp rate of %g°C per minute must be positive", c.Rate)
+	}
+
+	if c.StepSize <= 0 {
+		return fmt.Errorf("the step size of %g°C must be positive", c.StepSize)
+	}
+
+	return nil
+}
+
+/*
+getStepInterval()
+
+@returns the period between setpoint increments needed to ramp at the configured rate.
+*/
+func (c *CoolerController) getStepInterval() time.Duration {
+	return time.Duration(c.StepSize / c.Rate * float64(time.Minute))
+}
+
+/*
+GetStatus()
+
+@returns the current setpoint, sensor temperature, cooler power and heat sink temperature.
+*/
+func (c *CoolerController) GetStatus() (CoolerStatus, error) {
+	status := CoolerStatus{Time: c.getTime(), SetPoint: c.setpoint}
+
+	var err error
+
+	if status.Temperature, err = c.Camera.GetCCDTemperature(); err != nil {
+		return status, err
+	}
+
+	if status.Power, err = c.Camera.GetCoolerPowerLevel(); err != nil {
+		return status, err
+	}
+
+	if status.HeatSink, err = c.Camera.GetHeatSinkTemperature(); err != nil {
+		return status, err
+	}
+
+	return status, nil
+}
+
+/*
+report()
+
+Reads and reports the cooler status, checking the power and heat sink limits when cooling.
+
+@returns the status read.
+*/
+func (c *CoolerController) report(cooling bool) (CoolerStatus, error) {
+	status, err := c.GetStatus()
+
+	if err != nil {
+		return status, err
+	}
+
+	if c.OnStatus != nil {
+		c.OnStatus(status)
+	}
+
+	if !cooling {
+		return status, nil
+	}
+
+	if c.MaxPower > 0 && status.Power >= c.MaxPower {
+		return status, fmt.Errorf("the cooler power of %.0f%% has reached the limit of %.0f%% at a setpoint of %.1f°C", status.Power, c.MaxPower, c.setpoint)
+	}
+
+	if c.MaxHeatSinkTemperature != 0 && status.HeatSink > c.MaxHeatSinkTemperature {
+		return status, fmt.Errorf("the heat sink temperature of %.1f°C exceeds the limit of %.1f°C", status.HeatSink, c.MaxHeatSinkTemperature)
+	}
+
+	return status, nil
+}
+
+/*
+ramp()
+
+Moves the setpoint from its current value to the target in increments of StepSize, pausing
+between increments so as to change at the configured rate.
+*/
+func (c *CoolerController) ramp(ctx context.Context, target float64) error {
+	cooling := target < c.setpoint
+
+	for c.setpoint != target {
+		if math.Abs(target-c.setpoint) <= c.StepSize {
+			c.setpoint = target
+		} else if cooling {
+			c.setpoint -= c.StepSize
+		} else {
+			c.setpoint += c.StepSize
+		}
+
+		if err := c.Camera.SetCCDTemperatureCoolerSetPoint(c.setpoint); err != nil {
+			return err
+		}
+
+		if _, err := c.report(cooling); err != nil {
+			return err
+		}
+
+		// There is no need to pause once the target has been reached:
+		if c.setpoint == target {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.getStepInterval()):
+		}
+	}
+
+	return nil
+}
+
+/*
+waitForStable()
+
+Waits until the sensor temperature has remained within tolerance of the setpoint for the settle time.
+*/
+func (c *CoolerController) waitForStable(ctx context.Context, cooling bool) error {
+	var since time.Time
+
+	return WaitUntil(ctx, c.PollInterval, func() (bool, error) {
+		status, err := c.report(cooling)
+
+		if err != nil {
+			return false, err
+		}
+
+		if math.Abs(status.Temperature-c.setpoint) > c.Tolerance {
+			since = time.Time{}
+			return false, nil
+		}
+
+		if since.IsZero() {
+			since = status.Time
+		}
+
+		return status.Time.Sub(since) >= c.SettleTime, nil
+	})
+}
+
+/*
+begin()
+
+Turns the cooler on if needed, starting the ramp from the current sensor temperature.
+*/
+func (c *CoolerController) begin() error {
+	on, err := c.Camera.IsCoolerOn()
+
+	if err != nil {
+		return err
+	}
+
+	temperature, err := c.Camera.GetCCDTemperature()
+
+	if err != nil {
+		return err
+	}
+
+	c.setpoint = math.Round(temperature*10) / 10
+
+	if on {
+		return nil
+	}
+
+	if err := c.Camera.SetCCDTemperatureCoolerSetPoint(c.setpoint); err != nil {
+		return err
+	}
+
+	return c.Camera.TurnCoolerOn()
+}
+
+/*
+CoolTo()
+
+Turns the cooler on and ramps the setpoint from the current sensor temperature to the target,
+then waits for the sensor temperature to stabilise. Cooling is abandoned, leaving the setpoint
+where it is, if the cooler power or heat sink limits are exceeded.
+
+@param target float64 (°C)
+*/
+func (c *CoolerController) CoolTo(ctx context.Context, target float64) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	if c.DewPoint != nil {
+		dewPoint, err := c.DewPoint.GetDewPoint()
+
+		if err != nil {
+			return err
+		}
+
+		if target < dewPoint+c.DewPointMargin {
+			return fmt.Errorf("the target of %.1f°C is below the dew point of %.1f°C plus a margin of %.1f°C", target, dewPoint, c.DewPointMargin)
+		}
+	}
+
+	if err := c.begin(); err != nil {
+		return err
+	}
+
+	cooling := target < c.setpoint
+
+	if err := c.ramp(ctx, target); err != nil {
+		return err
+	}
+
+	return c.waitForStable(ctx, cooling)
+}
+
+/*
+WarmUp()
+
+Ramps the setpoint up to the heat sink (approximately ambient) temperature, waits for the sensor
+to follow, and then turns the cooler off.
+*/
+func (c *CoolerController) WarmUp(ctx context.Context) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	on, err := c.Camera.IsCoolerOn()
+
+	if err != nil {
+		return err
+	}
+
+	if !on {
+		return nil
+	}
+
+	if err := c.begin(); err != nil {
+		return err
+	}
+
+	ambient, err := c.Camera.GetHeatSinkTemperature()
+
+	if err != nil {
+		return err
+	}
+
+	if ambient > c.setpoint {
+		if err := c.ramp(ctx, math.Round(ambient*10)/10); err != nil {
+			return err
+		}
+
+		if err := c.waitForStable(ctx, false); err != nil {
+			return err
+		}
+	}
+
+	return c.Camera.TurnCoolerOff()
+}
@@ -0,0 +1,190 @@
+package alpacago
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+/*
+simulatedCoolingCamera has a sensor temperature that closes half the distance to the setpoint
+(or to ambient, with the cooler off) each time it is read, and a cooler power proportional to
+the difference between the setpoint and ambient.
+*/
+type simulatedCoolingCamera struct {
+	ambient     float64
+	temperature float64
+	setpoint    float64
+	on          bool
+	setpoints   []float64
+}
+
+func (c *simulatedCoolingCamera) GetCCDTemperature() (float64, error) {
+	target := c.ambient
+
+	if c.on {
+		target = c.setpoint
+	}
+
+	c.temperature += (target - c.temperature) / 2
+
+	return c.temperature, nil
+}
+
+func (c *simulatedCoolingCamera) SetCCDTemperatureCoolerSetPoint(temperature float64) error {
+	c.setpoint = temperature
+	c.setpoints = append(c.setpoints, temperature)
+	return nil
+}
+
+func (c *simulatedCoolingCamera) IsCoolerOn() (bool, error) { return c.on, nil }
+
+func (c *simulatedCoolingCamera) TurnCoolerOn() error {
+	c.on = true
+	return nil
+}
+
+func (c *simulatedCoolingCamera) TurnCoolerOff() error {
+	c.on = false
+	return nil
+}
+
+func (c *simulatedCoolingCamera) GetCoolerPowerLevel() (float64, error) {
+	if !c.on {
+		return 0, nil
+	}
+
+	return math.Max(0, math.Min(100, (c.ambient-c.setpoint)*2.5)), nil
+}
+
+func (c *simulatedCoolingCamera) GetHeatSinkTemperature() (float64, error) { return c.ambient, nil }
+
+type fakeDewPointSource struct {
+	dewPoint float64
+}
+
+func (s fakeDewPointSource) GetDewPoint() (float64, error) { return s.dewPoint, nil }
+
+func newTestCoolerController(camera *simulatedCoolingCamera) *CoolerController {
+	controller := NewCoolerController(camera)
+
+	// Ramp at 1°C per millisecond:
+	controller.Rate = 60000
+
+	controller.SettleTime = 0
+
+	controller.PollInterval = time.Millisecond
+
+	return controller
+}
+
+func TestCoolerControllerCoolToRamps(t *testing.T) {
+	camera := &simulatedCoolingCamera{ambient: 20, temperature: 20}
+
+	if err := newTestCoolerController(camera).CoolTo(context.Background(), -10); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if !camera.on {
+		t.Errorf("got false, wanted the cooler on")
+	}
+
+	// The initial setpoint is the sensor temperature, then steps of no more than one degree:
+	if camera.setpoints[0] != 20 {
+		t.Errorf("got %f, wanted %f", camera.setpoints[0], 20.0)
+	}
+
+	for i := 1; i < len(camera.setpoints); i++ {
+		if camera.setpoints[i-1]-camera.setpoints[i] > 1 {
+			t.Errorf("got a step from %f to %f, wanted at most 1°C", camera.setpoints[i-1], camera.setpoints[i])
+		}
+	}
+
+	if camera.setpoint != -10 || math.Abs(camera.temperature+10) > 0.5 {
+		t.Errorf("got %f at a setpoint of %f, wanted %f", camera.temperature, camera.setpoint, -10.0)
+	}
+}
+
+func TestCoolerControllerMaxPower(t *testing.T) {
+	camera := &simulatedCoolingCamera{ambient: 20, temperature: 20}
+
+	controller := newTestCoolerController(camera)
+
+	controller.MaxPower = 90
+
+	if err := controller.CoolTo(context.Background(), -30); err == nil {
+		t.Errorf("got nil, wanted an error when the cooler power limit is reached")
+	}
+
+	// 90% power corresponds to a setpoint 36°C below ambient:
+	if camera.setpoint != -16 {
+		t.Errorf("got %f, wanted the ramp held at %f", camera.setpoint, -16.0)
+	}
+}
+
+func TestCoolerControllerRefusesTargetBelowDewPoint(t *testing.T) {
+	camera := &simulatedCoolingCamera{ambient: 20, temperature: 20}
+
+	controller := newTestCoolerController(camera)
+
+	controller.DewPoint = fakeDewPointSource{dewPoint: 5}
+
+	controller.DewPointMargin = 2
+
+	if err := controller.CoolTo(context.Background(), 6); err == nil {
+		t.Errorf("got nil, wanted an error for a target below the dew point margin")
+	}
+
+	if camera.on || len(camera.setpoints) != 0 {
+		t.Errorf("got the cooler on with setpoints %v, wanted it left untouched", camera.setpoints)
+	}
+}
+
+func TestCoolerControllerWarmUp(t *testing.T) {
+	camera := &simulatedCoolingCamera{ambient: 15, temperature: -10, setpoint: -10, on: true}
+
+	if err := newTestCoolerController(camera).WarmUp(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if camera.on {
+		t.Errorf("got true, wanted the cooler off")
+	}
+
+	if n := len(camera.setpoints); n < 25 || camera.setpoints[n-1] != 15 {
+		t.Errorf("got %v, wanted a gradual ramp to %f", camera.setpoints, 15.0)
+	}
+}
+
+func TestCoolerControllerRejectsInvalidRamp(t *testing.T) {
+	camera := &simulatedCoolingCamera{ambient: 20, temperature: 20}
+
+	controller := newTestCoolerController(camera)
+
+	controller.StepSize = 0
+
+	if err := controller.CoolTo(context.Background(), -10); err == nil {
+		t.Errorf("got nil, wanted an error for a step size of 0")
+	}
+
+	controller.StepSize, controller.Rate = 1, 0
+
+	if err := controller.CoolTo(context.Background(), -10); err == nil {
+		t.Errorf("got nil, wanted an error for a rate of 0")
+	}
+
+	if len(camera.setpoints) != 0 {
+		t.Errorf("got %v, wanted no setpoints commanded", camera.setpoints)
+	}
+}
+
+func TestCoolerControllerWithoutConstructor(t *testing.T) {
+	controller := &CoolerController{Camera: &simulatedCoolingCamera{ambient: 20, temperature: 20}}
+
+	status, err := controller.GetStatus()
+
+	if err != nil || status.Time.IsZero() {
+		t.Errorf("got %v (%v), wanted the current time", status.Time, err)
+	}
+}
@@ -0,0 +1,515 @@
+package alpacago
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EquatorialCoordinate struct {
+	// The right ascension (hours)
+	RightAscension float64
+	// The declination (degrees)
+	Declination float64
+}
+
+type HorizontalCoordinate struct {
+	// The altitude above the horizon (degrees)
+	Altitude float64
+	// The azimuth (degrees), measured from North towards East
+	Azimuth float64
+}
+
+const (
+	degreesToRadians = math.Pi / 180
+	// The Julian date of the J2000.0 epoch (2000 January 1.5 TT)
+	julianDateJ2000 = 2451545.0
+)
+
+/*
+getNormalisedAngle()
+
+@returns the angle wrapped into the range [0, period).
+*/
+func getNormalisedAngle(angle float64, period float64) float64 {
+	angle = math.Mod(angle, period)
+
+	if angle < 0 {
+		angle += period
+	}
+
+	return angle
+}
+
+/*
+GetJulianDate()
+
+@returns the Julian date of the given instant (the difference between UTC and TT is ignored).
+*/
+func GetJulianDate(t time.Time) float64 {
+	return float64(t.UnixNano())/86400e9 + 2440587.5
+}
+
+/*
+GetGreenwichMeanSiderealTime()
+
+@returns the Greenwich mean sidereal time (hours) at the given instant.
+@see Meeus, Astronomical Algorithms, equation 12.4
+*/
+func GetGreenwichMeanSiderealTime(t time.Time) float64 {
+	jd := GetJulianDate(t)
+
+	T := (jd - julianDateJ2000) / 36525
+
+	theta := 280.46061837 + 360.98564736629*(jd-julianDateJ2000) + 0.000387933*T*T - T*T*T/38710000
+
+	return getNormalisedAngle(theta, 360) / 15
+}
+
+/*
+GetLocalSiderealTime()
+
+@param longitude float64 (degrees, positive East)
+@returns the local mean sidereal time (hours) at the given instant.
+*/
+func GetLocalSiderealTime(t time.Time, longitude float64) float64 {
+	return getNormalisedAngle(GetGreenwichMeanSiderealTime(t)+longitude/15, 24)
+}
+
+/*
+GetHourAngle()
+
+@returns the hour angle (hours, in the range -12 to +12) of the coordinate at the given local sidereal time.
+*/
+func GetHourAngle(c EquatorialCoordinate, siderealTime float64) float64 {
+	return getNormalisedAngle(siderealTime-c.RightAscension+12, 24) - 12
+}
+
+/*
+Precess()
+
+Precesses mean equatorial coordinates between two epochs, given as Julian dates.
+
+@see Meeus, Astronomical Algorithms, equations 21.2 to 21.4
+*/
+func Precess(c EquatorialCoordinate, from float64, to float64) EquatorialCoordinate {
+	T := (from - julianDateJ2000) / 36525
+
+	t := (to - from) / 36525
+
+	arcseconds := degreesToRadians / 3600
+
+	zeta := ((2306.2181+1.39656*T-0.000139*T*T)*t + (0.30188-0.000344*T)*t*t + 0.017998*t*t*t) * arcseconds
+
+	z := ((2306.2181+1.39656*T-0.000139*T*T)*t + (1.09468+0.000066*T)*t*t + 0.018203*t*t*t) * arcseconds
+
+	theta := ((2004.3109-0.85330*T-0.000217*T*T)*t - (0.42665+0.000217*T)*t*t - 0.041833*t*t*t) * arcseconds
+
+	ra := c.RightAscension * 15 * degreesToRadians
+
+	dec := c.Declination * degreesToRadians
+
+	A := math.Cos(dec) * math.Sin(ra+zeta)
+
+	B := math.Cos(theta)*math.Cos(dec)*math.Cos(ra+zeta) - math.Sin(theta)*math.Sin(dec)
+
+	C := math.Sin(theta)*math.Cos(dec)*math.Cos(ra+zeta) + math.Cos(theta)*math.Sin(dec)
+
+	return EquatorialCoordinate{
+		RightAscension: getNormalisedAngle((math.Atan2(A, B)+z)/degreesToRadians, 360) / 15,
+		Declination:    math.Asin(C) / degreesToRadians,
+	}
+}
+
+/*
+GetNutation()
+
+@returns the nutation in longitude and obliquity, and the true obliquity of the ecliptic (degrees),
+to an accuracy of around half an arcsecond.
+@see Meeus, Astronomical Algorithms, chapter 22
+*/
+func GetNutation(jd float64) (float64, float64, float64) {
+	T := (jd - julianDateJ2000) / 36525
+
+	omega := (125.04452 - 1934.136261*T) * degreesToRadians
+
+	L := (280.4665 + 36000.7698*T) * degreesToRadians
+
+	Lm := (218.3165 + 481267.8813*T) * degreesToRadians
+
+	dpsi := -17.20*math.Sin(omega) - 1.32*math.Sin(2*L) - 0.23*math.Sin(2*Lm) + 0.21*math.Sin(2*omega)
+
+	deps := 9.20*math.Cos(omega) + 0.57*math.Cos(2*L) + 0.10*math.Cos(2*Lm) - 0.09*math.Cos(2*omega)
+
+	eps0 := 23.43929111 - (46.8150*T+0.00059*T*T-0.001813*T*T*T)/3600
+
+	return dpsi / 3600, deps / 3600, eps0 + deps/3600
+}
+
+/*
+getApparentCorrection()
+
+@returns the corrections (degrees) to right ascension and declination for nutation and annual aberration.
+@see Meeus, Astronomical Algorithms, equations 23.1 and 23.3
+*/
+func getApparentCorrection(c EquatorialCoordinate, jd float64) (float64, float64) {
+	T := (jd - julianDateJ2000) / 36525
+
+	dpsi, deps, eps := GetNutation(jd)
+
+	ra, dec := c.RightAscension*15*degreesToRadians, c.Declination*degreesToRadians
+
+	e := eps * degreesToRadians
+
+	dra := (math.Cos(e)+math.Sin(e)*math.Sin(ra)*math.Tan(dec))*dpsi - math.Cos(ra)*math.Tan(dec)*deps
+
+	ddec := math.Sin(e)*math.Cos(ra)*dpsi + math.Sin(ra)*deps
+
+	// Annual aberration, from the Sun's true longitude and the Earth's orbital eccentricity and perihelion:
+	kappa := 20.49552 / 3600
+
+	eccentricity := 0.016708634 - 0.000042037*T
+
+	perihelion := (102.93735 + 1.71946*T) * degreesToRadians
+
+	M := (357.52911 + 35999.05029*T) * degreesToRadians
+
+	sun := (280.46646 + 36000.76983*T + (1.914602-0.004817*T)*math.Sin(M) + (0.019993-0.000101*T)*math.Sin(2*M) + 0.000289*math.Sin(3*M)) * degreesToRadians
+
+	dra += -kappa*(math.Cos(ra)*math.Cos(sun)*math.Cos(e)+math.Sin(ra)*math.Sin(sun))/math.Cos(dec) +
+		eccentricity*kappa*(math.Cos(ra)*math.Cos(perihelion)*math.Cos(e)+math.Sin(ra)*math.Sin(perihelion))/math.Cos(dec)
+
+	ddec += -kappa*(math.Cos(sun)*math.Cos(e)*(math.Tan(e)*math.Cos(dec)-math.Sin(ra)*math.Sin(dec))+math.Cos(ra)*math.Sin(dec)*math.Sin(sun)) +
+		eccentricity*kappa*(math.Cos(perihelion)*math.Cos(e)*(math.Tan(e)*math.Cos(dec)-math.Sin(ra)*math.Sin(dec))+math.Cos(ra)*math.Sin(dec)*math.Sin(perihelion))
+
+	return dra, ddec
+}
+
+/*
+J2000ToJNow()
+
+Converts J2000 catalogue coordinates to apparent coordinates of date (JNow), applying precession,
+nutation and annual aberration.
+*/
+func J2000ToJNow(c EquatorialCoordinate, t time.Time) EquatorialCoordinate {
+	jd := GetJulianDate(t)
+
+	p := Precess(c, julianDateJ2000, jd)
+
+	dra, ddec := getApparentCorrection(p, jd)
+
+	return EquatorialCoordinate{
+		RightAscension: getNormalisedAngle(p.RightAscension*15+dra, 360) / 15,
+		Declination:    p.Declination + ddec,
+	}
+}
+
+/*
+JNowToJ2000()
+
+Converts apparent coordinates of date (JNow) to J2000 coordinates, by iteratively inverting J2000ToJNow().
+*/
+func JNowToJ2000(c EquatorialCoordinate, t time.Time) EquatorialCoordinate {
+	j2000 := Precess(c, GetJulianDate(t), julianDateJ2000)
+
+	for i := 0; i < 3; i++ {
+		jnow := J2000ToJNow(j2000, t)
+
+		j2000.RightAscension = getNormalisedAngle(j2000.RightAscension+GetHourAngle(jnow, c.RightAscension), 24)
+
+		j2000.Declination += c.Declination - jnow.Declination
+	}
+
+	return j2000
+}
+
+/*
+EquatorialToHorizontal()
+
+@param siderealTime float64 (the local sidereal time in hours)
+@param latitude float64 (the site latitude in degrees, positive North)
+@returns the altitude and azimuth of the coordinate.
+*/
+func EquatorialToHorizontal(c EquatorialCoordinate, siderealTime float64, latitude float64) HorizontalCoordinate {
+	H := GetHourAngle(c, siderealTime) * 15 * degreesToRadians
+
+	dec, lat := c.Declination*degreesToRadians, latitude*degreesToRadians
+
+	altitude := math.Asin(math.Sin(dec)*math.Sin(lat) + math.Cos(dec)*math.Cos(lat)*math.Cos(H))
+
+	azimuth := math.Atan2(-math.Cos(dec)*math.Sin(H), math.Sin(dec)*math.Cos(lat)-math.Cos(dec)*math.Sin(lat)*math.Cos(H))
+
+	return HorizontalCoordinate{
+		Altitude: altitude / degreesToRadians,
+		Azimuth:  getNormalisedAngle(azimuth/degreesToRadians, 360),
+	}
+}
+
+/*
+HorizontalToEquatorial()
+
+@param siderealTime float64 (the local sidereal time in hours)
+@param latitude float64 (the site latitude in degrees, positive North)
+@returns the right ascension and declination of the coordinate.
+*/
+func HorizontalToEquatorial(h HorizontalCoordinate, siderealTime float64, latitude float64) EquatorialCoordinate {
+	alt, az, lat := h.Altitude*degreesToRadians, h.Azimuth*degreesToRadians, latitude*degreesToRadians
+
+	dec := math.Asin(math.Sin(alt)*math.Sin(lat) + math.Cos(alt)*math.Cos(lat)*math.Cos(az))
+
+	H := math.Atan2(-math.Sin(az)*math.Cos(alt), math.Sin(alt)*math.Cos(lat)-math.Cos(alt)*math.Sin(lat)*math.Cos(az))
+
+	return EquatorialCoordinate{
+		RightAscension: getNormalisedAngle(siderealTime-H/degreesToRadians/15, 24),
+		Declination:    dec / degreesToRadians,
+	}
+}
+
+/*
+GetEquatorialSeparation()
+
+@returns the great circle distance (degrees) between two equatorial coordinates.
+*/
+func GetEquatorialSeparation(a EquatorialCoordinate, b EquatorialCoordinate) float64 {
+	ra1, dec1 := a.RightAscension*15*degreesToRadians, a.Declination*degreesToRadians
+
+	ra2, dec2 := b.RightAscension*15*degreesToRadians, b.Declination*degreesToRadians
+
+	// The haversine formula, which remains accurate for the small separations of pointing errors:
+	h := math.Pow(math.Sin((dec2-dec1)/2), 2) + math.Cos(dec1)*math.Cos(dec2)*math.Pow(math.Sin((ra2-ra1)/2), 2)
+
+	return 2 * math.Asin(math.Min(1, math.Sqrt(h))) / degreesToRadians
+}
+
+/*
+getRefractionScale()
+
+@returns the correction to standard refraction for the given pressure (hPa) and temperature (°C).
+*/
+func getRefractionScale(pressure float64, temperature float64) float64 {
+	return (pressure / 1010) * (283 / (273 + temperature))
+}
+
+/*
+ApplyRefraction()
+
+@param altitude float64 (the true, geometric altitude in degrees)
+@param pressure float64 (the atmospheric pressure in hPa, e.g., 1010)
+@param temperature float64 (the air temperature in °C, e.g., 10)
+@returns the apparent altitude (degrees), as raised by atmospheric refraction.
+@see Sæmundsson, Sky and Telescope 72, 70 (1986)
+*/
+func ApplyRefraction(altitude float64, pressure float64, temperature float64) float64 {
+	if altitude < -1 {
+		return altitude
+	}
+
+	R := 1.02 / math.Tan((altitude+10.3/(altitude+5.11))*degreesToRadians)
+
+	return altitude + R*getRefractionScale(pressure, temperature)/60
+}
+
+/*
+RemoveRefraction()
+
+@param altitude float64 (the apparent, observed altitude in degrees)
+@param pressure float64 (the atmospheric pressure in hPa, e.g., 1010)
+@param temperature float64 (the air temperature in °C, e.g., 10)
+@returns the true, geometric altitude (degrees).
+@see Bennett, Journal of Navigation 35, 255 (1982)
+*/
+func RemoveRefraction(altitude float64, pressure float64, temperature float64) float64 {
+	if altitude < -1 {
+		return altitude
+	}
+
+	R := 1 / math.Tan((altitude+7.31/(altitude+4.4))*degreesToRadians)
+
+	return altitude - R*getRefractionScale(pressure, temperature)/60
+}
+
+/*
+ParseSexagesimal()
+
+Parses an angle given as decimal, or as degrees (hours), minutes and seconds separated by spaces,
+colons, or unit symbols e.g., "05:35:17.3", "5h35m17.3s", "-05° 23' 28\"" or "-5 23 28".
+
+@returns the angle in decimal degrees (or hours).
+*/
+func ParseSexagesimal(s string) (float64, error) {
+	value := strings.TrimSpace(s)
+
+	sign := 1.
+
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "−") {
+		sign = -1
+	}
+
+	value = strings.TrimLeft(value, "+-−")
+
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return strings.ContainsRune(" :hHmMsSdD°'\"′″", r)
+	})
+
+	if len(fields) == 0 || len(fields) > 3 {
+		return 0, fmt.Errorf("%q is not a sexagesimal angle", s)
+	}
+
+	var total float64 = 0
+
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("%q is not a sexagesimal angle", s)
+		}
+
+		if i > 0 && v >= 60 {
+			return 0, fmt.Errorf("%q has minutes or seconds of 60 or more", s)
+		}
+
+		total += v / math.Pow(60, float64(i))
+	}
+
+	return sign * total, nil
+}
+
+/*
+FormatSexagesimal()
+
+@param decimals int (the number of decimal places of the seconds)
+@param signed bool (always prefix the sign, as for declinations)
+@returns the angle formatted as "DD:MM:SS.s".
+*/
+func FormatSexagesimal(value float64, decimals int, signed bool) string {
+	sign := ""
+
+	if value < 0 {
+		sign = "-"
+	} else if signed {
+		sign = "+"
+	}
+
+	// Round the total seconds first, so 59.99… carries into the minutes:
+	scale := math.Pow(10, float64(decimals))
+
+	seconds := math.Round(math.Abs(value)*3600*scale) / scale
+
+	d := math.Floor(seconds / 3600)
+
+	m := math.Floor((seconds - d*3600) / 60)
+
+	seconds -= d*3600 + m*60
+
+	width := 2
+
+	if decimals > 0 {
+		width = 3 + decimals
+	}
+
+	return fmt.Sprintf("%s%02d:%02d:%0*.*f", sign, int(d), int(m), width, decimals, seconds)
+}
+
+/*
+FormatHours()
+
+@returns the right ascension (hours) formatted as "HH:MM:SS.ss".
+*/
+func FormatHours(hours float64) string {
+	return FormatSexagesimal(getNormalisedAngle(hours, 24), 2, false)
+}
+
+/*
+FormatDegrees()
+
+@returns the declination (degrees) formatted as "+DD:MM:SS.s".
+*/
+func FormatDegrees(degrees float64) string {
+	return FormatSexagesimal(degrees, 1, true)
+}
+
+type CoordinateTelescope interface {
+	GetRightAscension() (float64, error)
+	GetDeclination() (float64, error)
+	GetEquatorialSystem() (string, error)
+	GetSiderealTime() (float64, error)
+	GetSiteLatitude() (float64, error)
+}
+
+/*
+ToTelescopeCoordinates()
+
+Converts J2000 catalogue coordinates into the equatorial system the telescope works in, ready for e.g.,
+Telescope.SetSlewToCoordinatesAsync() (which takes the right ascension in degrees, rather than hours).
+
+@returns the coordinates in the telescope's equatorial system.
+*/
+func ToTelescopeCoordinates(telescope CoordinateTelescope, c EquatorialCoordinate, t time.Time) (EquatorialCoordinate, error) {
+	system, err := telescope.GetEquatorialSystem()
+
+	if err != nil {
+		return c, err
+	}
+
+	switch system {
+	case J2000.String():
+		return c, nil
+	case Topocentric.String():
+		return J2000ToJNow(c, t), nil
+	default:
+		return c, fmt.Errorf("unsupported equatorial system %q", system)
+	}
+}
+
+/*
+GetTelescopePosition()
+
+Reads the telescope's pointing position, in whichever equatorial system it works in.
+
+@returns the J2000 coordinates and the horizontal coordinates of the telescope's position.
+*/
+func GetTelescopePosition(telescope CoordinateTelescope, t time.Time) (EquatorialCoordinate, HorizontalCoordinate, error) {
+	var c EquatorialCoordinate
+
+	var h HorizontalCoordinate
+
+	system, err := telescope.GetEquatorialSystem()
+
+	if err != nil {
+		return c, h, err
+	}
+
+	if c.RightAscension, err = telescope.GetRightAscension(); err != nil {
+		return c, h, err
+	}
+
+	if c.Declination, err = telescope.GetDeclination(); err != nil {
+		return c, h, err
+	}
+
+	lst, err := telescope.GetSiderealTime()
+
+	if err != nil {
+		return c, h, err
+	}
+
+	latitude, err := telescope.GetSiteLatitude()
+
+	if err != nil {
+		return c, h, err
+	}
+
+	switch system {
+	case J2000.String():
+		h = EquatorialToHorizontal(J2000ToJNow(c, t), lst, latitude)
+	case Topocentric.String():
+		h = EquatorialToHorizontal(c, lst, latitude)
+		c = JNowToJ2000(c, t)
+	default:
+		return c, h, fmt.Errorf("unsupported equatorial system %q", system)
+	}
+
+	return c, h, nil
+}
@@ -0,0 +1,327 @@
+package alpacago
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCoordinateTelescope struct {
+	system string
+	c      EquatorialCoordinate
+	lst    float64
+}
+
+func (t *fakeCoordinateTelescope) GetRightAscension() (float64, error) {
+	return t.c.RightAscension, nil
+}
+
+func (t *fakeCoordinateTelescope) GetDeclination() (float64, error) { return t.c.Declination, nil }
+
+func (t *fakeCoordinateTelescope) GetEquatorialSystem() (string, error) { return t.system, nil }
+
+func (t *fakeCoordinateTelescope) GetSiderealTime() (float64, error) { return t.lst, nil }
+
+func (t *fakeCoordinateTelescope) GetSiteLatitude() (float64, error) { return 51.5, nil }
+
+/*
+getTimeFromJulianDate()
+
+@returns the instant of the given Julian date.
+*/
+func getTimeFromJulianDate(jd float64) time.Time {
+	return time.Unix(0, int64((jd-2440587.5)*86400e9)).UTC()
+}
+
+func TestGetGreenwichMeanSiderealTime(t *testing.T) {
+	// Meeus, example 12.a: 1987 April 10, 0h UT
+	got := GetGreenwichMeanSiderealTime(time.Date(1987, 4, 10, 0, 0, 0, 0, time.UTC))
+
+	want, _ := ParseSexagesimal("13:10:46.3668")
+
+	if math.Abs(got-want)*3600 > 0.01 {
+		t.Errorf("got %s, wanted %s", FormatHours(got), FormatHours(want))
+	}
+}
+
+func TestJ2000ToJNow(t *testing.T) {
+	// Meeus, example 23.a: θ Persei at 2028 November 13.19 TD, with proper motion applied to J2000:
+	ra, _ := ParseSexagesimal("2h44m12.975s")
+
+	dec, _ := ParseSexagesimal("+49°13'39.896\"")
+
+	got := J2000ToJNow(EquatorialCoordinate{ra, dec}, getTimeFromJulianDate(2462088.69))
+
+	wantRA, _ := ParseSexagesimal("2h46m14.390s")
+
+	wantDec, _ := ParseSexagesimal("+49°21'07.45\"")
+
+	if math.Abs(got.RightAscension-wantRA)*15*3600 > 1 {
+		t.Errorf("got %s, wanted %s", FormatHours(got.RightAscension), FormatHours(wantRA))
+	}
+
+	if math.Abs(got.Declination-wantDec)*3600 > 1 {
+		t.Errorf("got %s, wanted %s", FormatDegrees(got.Declination), FormatDegrees(wantDec))
+	}
+}
+
+func TestJNowToJ2000RoundTrip(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	want := EquatorialCoordinate{RightAscension: 23.95, Declination: -62.3}
+
+	got := JNowToJ2000(J2000ToJNow(want, now), now)
+
+	if math.Abs(got.RightAscension-want.RightAscension)*15*3600 > 0.01 || math.Abs(got.Declination-want.Declination)*3600 > 0.01 {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+}
+
+func TestEquatorialToHorizontal(t *testing.T) {
+	// The celestial pole sits due North at an altitude equal to the latitude:
+	got := EquatorialToHorizontal(EquatorialCoordinate{RightAscension: 3, Declination: 90}, 10, 51.5)
+
+	if math.Abs(got.Altitude-51.5) > 1e-9 || math.Abs(math.Mod(got.Azimuth+180, 360)-180) > 1e-6 {
+		t.Errorf("got %+v, wanted an altitude of 51.5° due North", got)
+	}
+
+	// An object on the celestial equator, rising six hours before transit, is due East on the horizon:
+	got = EquatorialToHorizontal(EquatorialCoordinate{RightAscension: 16, Declination: 0}, 10, 51.5)
+
+	if math.Abs(got.Altitude) > 1e-9 || math.Abs(got.Azimuth-90) > 1e-9 {
+		t.Errorf("got %+v, wanted 0° altitude at 90° azimuth", got)
+	}
+}
+
+func TestHorizontalToEquatorialRoundTrip(t *testing.T) {
+	want := EquatorialCoordinate{RightAscension: 7.25, Declination: 22.5}
+
+	got := HorizontalToEquatorial(EquatorialToHorizontal(want, 4.1, -33.9), 4.1, -33.9)
+
+	if math.Abs(got.RightAscension-want.RightAscension) > 1e-9 || math.Abs(got.Declination-want.Declination) > 1e-9 {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+}
+
+func TestGetEquatorialSeparation(t *testing.T) {
+	// Meeus, example 17.a: Arcturus and Spica
+	arcturus := EquatorialCoordinate{RightAscension: 213.9154 / 15, Declination: 19.1825}
+
+	spica := EquatorialCoordinate{RightAscension: 201.2983 / 15, Declination: -11.1614}
+
+	if got := GetEquatorialSeparation(arcturus, spica); math.Abs(got-32.7930) > 1e-4 {
+		t.Errorf("got %f, wanted %f", got, 32.7930)
+	}
+}
+
+func TestRefraction(t *testing.T) {
+	// Refraction at the horizon is a little over half a degree:
+	got := RemoveRefraction(0, 1010, 10)
+
+	if math.Abs(got+0.575) > 0.01 {
+		t.Errorf("got %f, wanted %f", got, -0.575)
+	}
+
+	apparent := ApplyRefraction(20, 1010, 10)
+
+	if math.Abs(RemoveRefraction(apparent, 1010, 10)-20)*3600 > 5 {
+		t.Errorf("got %f, wanted refraction to round trip to 20°", RemoveRefraction(apparent, 1010, 10))
+	}
+}
+
+func TestParseSexagesimal(t *testing.T) {
+	tests := map[string]float64{
+		"05:35:17.3":       5.588138888888889,
+		"5h35m17.3s":       5.588138888888889,
+		"-05° 23' 28\"":    -5.391111111111111,
+		"-0 30 00":         -0.5,
+		"+12.5":            12.5,
+		" 101°17′24.0″ ":   101.29,
+		"-00:00:36.0":      -0.01,
+		"359:59:59.999999": 359.99999999972224,
+	}
+
+	for s, want := range tests {
+		got, err := ParseSexagesimal(s)
+
+		if err != nil {
+			t.Errorf("got %q for %q, wanted nil", err, s)
+			continue
+		}
+
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("got %f for %q, wanted %f", got, s, want)
+		}
+	}
+
+	for _, s := range []string{"", "12:61:00", "1:2:3:4", "abc"} {
+		if _, err := ParseSexagesimal(s); err == nil {
+			t.Errorf("got nil for %q, wanted an error", s)
+		}
+	}
+}
+
+func TestFormatSexagesimal(t *testing.T) {
+	if got := FormatHours(5.588138888888889); got != "05:35:17.30" {
+		t.Errorf("got %q, wanted %q", got, "05:35:17.30")
+	}
+
+	if got := FormatDegrees(-5.391111111111111); got != "-05:23:28.0" {
+		t.Errorf("got %q, wanted %q", got, "-05:23:28.0")
+	}
+
+	// Seconds that round up to 60 carry into the minutes:
+	if got := FormatDegrees(10.99999999); got != "+11:00:00.0" {
+		t.Errorf("got %q, wanted %q", got, "+11:00:00.0")
+	}
+}
+
+func TestToTelescopeCoordinates(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	c := EquatorialCoordinate{RightAscension: 5.588, Declination: -5.39}
+
+	got, err := ToTelescopeCoordinates(&fakeCoordinateTelescope{system: J2000.String()}, c, now)
+
+	if err != nil || got != c {
+		t.Errorf("got %+v, %v, wanted %+v unchanged for a J2000 mount", got, err, c)
+	}
+
+	got, err = ToTelescopeCoordinates(&fakeCoordinateTelescope{system: Topocentric.String()}, c, now)
+
+	if err != nil || got != J2000ToJNow(c, now) {
+		t.Errorf("got %+v, %v, wanted %+v for a JNow mount", got, err, J2000ToJNow(c, now))
+	}
+}
+
+/*
+testTelescopeServer serves the property values of telescope 0 over HTTP, and records the query string of
+each request it is sent.
+*/
+type testTelescopeServer struct {
+	mu      sync.Mutex
+	values  map[string]interface{}
+	queries []string
+}
+
+func (s *testTelescopeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+
+	defer s.mu.Unlock()
+
+	s.queries = append(s.queries, r.URL.RawQuery)
+
+	method := path.Base(r.URL.Path)
+
+	envelope := map[string]interface{}{"ClientTransactionID": 0, "ServerTransactionID": 1, "ErrorNumber": 0, "ErrorMessage": ""}
+
+	if value, ok := s.values[method]; ok {
+		envelope["Value"] = value
+	} else {
+		envelope["ErrorNumber"], envelope["ErrorMessage"] = 1024, fmt.Sprintf("%s is not implemented", method)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(envelope)
+}
+
+func (s *testTelescopeServer) setValue(method string, value interface{}) {
+	s.mu.Lock()
+
+	defer s.mu.Unlock()
+
+	s.values[method] = value
+}
+
+func (s *testTelescopeServer) getQueries() []string {
+	s.mu.Lock()
+
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.queries...)
+}
+
+func newTestTelescopeServer(t *testing.T) (*Telescope, *testTelescopeServer) {
+	s := &testTelescopeServer{values: map[string]interface{}{}}
+
+	server := httptest.NewServer(s)
+
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	p, _ := strconv.Atoi(port)
+
+	return NewTelescope(65535, false, "", host, int32(p), 0, 1), s
+}
+
+func TestToTelescopeCoordinatesEquatorialSystemValues(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	c := EquatorialCoordinate{RightAscension: 5.588, Declination: -5.39}
+
+	telescope, server := newTestTelescopeServer(t)
+
+	// ASCOM's equJ2000 (2):
+	server.setValue("equatorialsystem", 2)
+
+	got, err := ToTelescopeCoordinates(telescope, c, now)
+
+	if err != nil || got != c {
+		t.Errorf("got %+v, %v, wanted %+v unchanged for equJ2000", got, err, c)
+	}
+
+	// ASCOM's equTopocentric (1):
+	server.setValue("equatorialsystem", 1)
+
+	got, err = ToTelescopeCoordinates(telescope, c, now)
+
+	if err != nil || got != J2000ToJNow(c, now) {
+		t.Errorf("got %+v, %v, wanted %+v for equTopocentric", got, err, J2000ToJNow(c, now))
+	}
+
+	// ASCOM's equOther (0):
+	server.setValue("equatorialsystem", 0)
+
+	if _, err := ToTelescopeCoordinates(telescope, c, now); err == nil {
+		t.Errorf("got nil, wanted an error for equOther")
+	}
+}
+
+func TestGetTelescopePosition(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	want := EquatorialCoordinate{RightAscension: 5.588, Declination: -5.39}
+
+	jnow := J2000ToJNow(want, now)
+
+	telescope := &fakeCoordinateTelescope{system: Topocentric.String(), c: jnow, lst: jnow.RightAscension}
+
+	got, h, err := GetTelescopePosition(telescope, now)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(got.RightAscension-want.RightAscension)*15*3600 > 0.01 || math.Abs(got.Declination-want.Declination)*3600 > 0.01 {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+
+	// The target is on the meridian, to the South:
+	if math.Abs(h.Azimuth-180) > 0.1 {
+		t.Errorf("got %f, wanted %f", h.Azimuth, 180.0)
+	}
+}
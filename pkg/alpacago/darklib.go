@@ -0,0 +1,607 @@
+package alpacago
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type CalibrationFrameType int32
+
+const (
+	// A zero length (minimum exposure) dark frame, recording the readout signal
+	CalibrationBias CalibrationFrameType = iota
+	// A shuttered exposure, recording the thermal signal accumulated over its duration
+	CalibrationDark
+)
+
+func (t CalibrationFrameType) String() string {
+	name := []string{"bias", "dark"}
+
+	i := uint8(t)
+
+	switch {
+	case i <= uint8(CalibrationDark):
+		return name[i]
+	default:
+		return strconv.Itoa(int(i))
+	}
+}
+
+type CalibrationSettings struct {
+	// The sensor temperature (°C)
+	Temperature float64 `json:"temperature"`
+	Gain        int32   `json:"gain"`
+	Offset      int32   `json:"offset"`
+	BinX        int32   `json:"binx"`
+	BinY        int32   `json:"biny"`
+	ReadOutMode int32   `json:"readoutmode"`
+}
+
+type CalibrationMaster struct {
+	Type     CalibrationFrameType `json:"type"`
+	Settings CalibrationSettings  `json:"settings"`
+	// The exposure duration (seconds)
+	Exposure float64 `json:"exposure"`
+	// The number of frames median combined into the master
+	Frames  int       `json:"frames"`
+	Created time.Time `json:"created"`
+	// The image file, relative to the library directory
+	File string `json:"file"`
+}
+
+type CalibrationLibrary struct {
+	Directory string
+	Masters   []CalibrationMaster
+	// Masters further than this from the requested sensor temperature (°C) are never matched
+	TemperatureTolerance float64
+}
+
+const calibrationLibraryIndex = "library.json"
+
+/*
+OpenCalibrationLibrary()
+
+Opens the calibration library in the given directory, creating the directory if needed.
+
+@returns the library, with the masters listed in its index.
+*/
+func OpenCalibrationLibrary(directory string) (*CalibrationLibrary, error) {
+	library := CalibrationLibrary{
+		Directory:            directory,
+		Masters:              []CalibrationMaster{},
+		TemperatureTolerance: 1,
+	}
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(directory, calibrationLibraryIndex))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return &library, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &library.Masters); err != nil {
+		return nil, err
+	}
+
+	return &library, nil
+}
+
+/*
+Save()
+
+Writes the library index listing every master.
+*/
+func (l *CalibrationLibrary) Save() error {
+	data, err := json.MarshalIndent(l.Masters, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(l.Directory, calibrationLibraryIndex)
+
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+/*
+AddMaster()
+
+Writes the master image into the library, replacing any master with identical settings, and saves the index.
+
+@returns the master as recorded, including its image file name.
+*/
+func (l *CalibrationLibrary) AddMaster(master CalibrationMaster, image [][]uint32) (CalibrationMaster, error) {
+	s := master.Settings
+
+	master.File = fmt.Sprintf("%s_%gs_%.1fC_g%d_o%d_b%dx%d_m%d.bin", master.Type, master.Exposure, s.Temperature, s.Gain, s.Offset, s.BinX, s.BinY, s.ReadOutMode)
+
+	if err := writeCalibrationImage(filepath.Join(l.Directory, master.File), image); err != nil {
+		return master, err
+	}
+
+	masters := []CalibrationMaster{}
+
+	for _, m := range l.Masters {
+		if m.File != master.File {
+			masters = append(masters, m)
+		}
+	}
+
+	l.Masters = append(masters, master)
+
+	return master, l.Save()
+}
+
+/*
+LoadImage()
+
+@returns the master image, indexed as image[x][y].
+*/
+func (l *CalibrationLibrary) LoadImage(master CalibrationMaster) ([][]uint32, error) {
+	return readCalibrationImage(filepath.Join(l.Directory, master.File))
+}
+
+/*
+FindMaster()
+
+Selects the master of the given type taken with the same gain, offset, binning and readout mode,
+and a sensor temperature within tolerance. Of these, darks closest in exposure duration are preferred,
+then those closest in temperature.
+
+@param settings CalibrationSettings (the settings of the light frame to be calibrated)
+@param exposure float64 (the light frame's exposure duration in seconds; ignored for bias)
+@returns the best matching master, or an error if none is suitable.
+*/
+func (l *CalibrationLibrary) FindMaster(frameType CalibrationFrameType, settings CalibrationSettings, exposure float64) (CalibrationMaster, error) {
+	var best CalibrationMaster
+
+	found := false
+
+	bestExposure, bestTemperature := math.Inf(1), math.Inf(1)
+
+	for _, m := range l.Masters {
+		s := m.Settings
+
+		if m.Type != frameType || s.Gain != settings.Gain || s.Offset != settings.Offset || s.BinX != settings.BinX || s.BinY != settings.BinY || s.ReadOutMode != settings.ReadOutMode {
+			continue
+		}
+
+		dt := math.Abs(s.Temperature - settings.Temperature)
+
+		if dt > l.TemperatureTolerance {
+			continue
+		}
+
+		de := 0.
+
+		if frameType == CalibrationDark {
+			de = math.Abs(m.Exposure - exposure)
+		}
+
+		if de < bestExposure || (de == bestExposure && dt < bestTemperature) {
+			best, bestExposure, bestTemperature, found = m, de, dt, true
+		}
+	}
+
+	if !found {
+		return best, fmt.Errorf("no %s master matches %+v within %.1f°C", frameType, settings, l.TemperatureTolerance)
+	}
+
+	return best, nil
+}
+
+/*
+writeCalibrationImage()
+
+Writes the image as its width and height followed by its pixels, column by column, as little endian uint32s.
+*/
+func writeCalibrationImage(path string, image [][]uint32) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+
+	height := 0
+
+	if len(image) > 0 {
+		height = len(image[0])
+	}
+
+	err = binary.Write(w, binary.LittleEndian, []uint32{uint32(len(image)), uint32(height)})
+
+	for x := 0; x < len(image) && err == nil; x++ {
+		if len(image[x]) != height {
+			err = errors.New("the image columns are not all the same height")
+			break
+		}
+
+		err = binary.Write(w, binary.LittleEndian, image[x])
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+
+	return errors.Join(err, file.Close())
+}
+
+/*
+readCalibrationImage()
+
+@returns the image written by writeCalibrationImage().
+*/
+func readCalibrationImage(path string) ([][]uint32, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+
+	size := make([]uint32, 2)
+
+	if err := binary.Read(r, binary.LittleEndian, size); err != nil {
+		return nil, err
+	}
+
+	image := make([][]uint32, size[0])
+
+	for x := range image {
+		image[x] = make([]uint32, size[1])
+
+		if err := binary.Read(r, binary.LittleEndian, image[x]); err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
+/*
+CombineMedian()
+
+@returns the per-pixel median of the images, which must all be the same size.
+*/
+func CombineMedian(images [][][]uint32) ([][]uint32, error) {
+	if len(images) == 0 {
+		return nil, errors.New("there are no images to combine")
+	}
+
+	width, height := len(images[0]), 0
+
+	if width > 0 {
+		height = len(images[0][0])
+	}
+
+	for _, image := range images {
+		if len(image) != width || (width > 0 && len(image[0]) != height) {
+			return nil, errors.New("the images are not all the same size")
+		}
+	}
+
+	master := make([][]uint32, width)
+
+	values := make([]float64, len(images))
+
+	for x := range master {
+		master[x] = make([]uint32, height)
+
+		for y := range master[x] {
+			for i, image := range images {
+				values[i] = float64(image[x][y])
+			}
+
+			master[x][y] = uint32(math.Round(getMedian(values)))
+		}
+	}
+
+	return master, nil
+}
+
+type CalibrationCamera interface {
+	ExposureCamera
+	GetCCDTemperature() (float64, error)
+	GetExposureMin() (float64, error)
+	GetGain() (int32, error)
+	SetGain(gain int32) error
+	GetOffset() (int32, error)
+	SetOffset(offset int32) error
+	GetBinX() (int32, error)
+	SetBinX(binX int32) error
+	GetBinY() (int32, error)
+	SetBinY(binY int32) error
+	GetCCDSizeX() (int32, error)
+	GetCCDSizeY() (int32, error)
+	SetStartX(startX int32) error
+	SetStartY(startY int32) error
+	SetSubFrameWidth(numX int32) error
+	SetSubFrameHeight(numY int32) error
+	GetReadOutMode() (int32, error)
+	SetReadOutMode(readOutMode int32) error
+}
+
+type CalibrationPlan struct {
+	// The sensor temperatures (°C) to capture at, which require a cooler; empty to capture as is
+	Temperatures []float64
+	Gains        []int32
+	Offsets      []int32
+	// Symmetric binning factors
+	Binnings     []int32
+	ReadOutModes []int32
+	// The dark exposure durations (seconds)
+	Exposures []float64
+	// Capture a bias master for every combination of settings
+	Bias bool
+	// The number of frames combined into each master
+	Frames int
+}
+
+type CalibrationCapture struct {
+	Camera  CalibrationCamera
+	Library *CalibrationLibrary
+	// The cooler used to reach each of the plan's temperatures (optional)
+	Cooler       *CoolerController
+	PollInterval time.Duration
+	// Called as each master is added to the library
+	OnMaster func(master CalibrationMaster)
+}
+
+func NewCalibrationCapture(camera CalibrationCamera, library *CalibrationLibrary) *CalibrationCapture {
+	capture := CalibrationCapture{
+		Camera:       camera,
+		Library:      library,
+		PollInterval: 250 * time.Millisecond,
+	}
+
+	return &capture
+}
+
+/*
+captureMaster()
+
+Takes the frames of a single master with the shutter closed, and adds their median to the library.
+*/
+func (c *CalibrationCapture) captureMaster(ctx context.Context, frameType CalibrationFrameType, settings CalibrationSettings, exposure float64, frames int) error {
+	images := [][][]uint32{}
+
+	var temperature float64 = 0
+
+	for i := 0; i < frames; i++ {
+		t, err := c.Camera.GetCCDTemperature()
+
+		if err != nil {
+			return err
+		}
+
+		temperature += t / float64(frames)
+
+		image, err := Capture(ctx, c.Camera, exposure, false, c.PollInterval)
+
+		if err != nil {
+			return err
+		}
+
+		images = append(images, image)
+	}
+
+	image, err := CombineMedian(images)
+
+	if err != nil {
+		return err
+	}
+
+	// Record the measured rather than the requested temperature:
+	settings.Temperature = math.Round(temperature*10) / 10
+
+	master, err := c.Library.AddMaster(CalibrationMaster{Type: frameType, Settings: settings, Exposure: exposure, Frames: frames, Created: time.Now()}, image)
+
+	if err != nil {
+		return err
+	}
+
+	if c.OnMaster != nil {
+		c.OnMaster(master)
+	}
+
+	return nil
+}
+
+/*
+Run()
+
+Captures masters across every combination of the plan's temperatures, gains, offsets, binnings and
+readout modes: a bias (if requested) and a dark for each exposure duration.
+*/
+func (c *CalibrationCapture) Run(ctx context.Context, plan CalibrationPlan) error {
+	if plan.Frames < 1 {
+		return errors.New("please provide at least one frame per master")
+	}
+
+	if len(plan.Temperatures) > 0 && c.Cooler == nil {
+		return errors.New("a cooler is required to capture at set temperatures")
+	}
+
+	// An empty dimension leaves the camera's current setting in place:
+	temperatures := []*float64{nil}
+
+	if len(plan.Temperatures) > 0 {
+		temperatures = make([]*float64, len(plan.Temperatures))
+
+		for i := range plan.Temperatures {
+			temperatures[i] = &plan.Temperatures[i]
+		}
+	}
+
+	bias := 0.
+
+	if plan.Bias {
+		var err error
+
+		if bias, err = c.Camera.GetExposureMin(); err != nil {
+			return err
+		}
+	}
+
+	for _, temperature := range temperatures {
+		if temperature != nil {
+			if err := c.Cooler.CoolTo(ctx, *temperature); err != nil {
+				return err
+			}
+		}
+
+		for _, gain := range getCalibrationValues(plan.Gains) {
+			for _, offset := range getCalibrationValues(plan.Offsets) {
+				for _, binning := range getCalibrationValues(plan.Binnings) {
+					for _, mode := range getCalibrationValues(plan.ReadOutModes) {
+						settings, err := c.apply(gain, offset, binning, mode)
+
+						if err != nil {
+							return err
+						}
+
+						if plan.Bias {
+							if err := c.captureMaster(ctx, CalibrationBias, settings, bias, plan.Frames); err != nil {
+								return err
+							}
+						}
+
+						for _, exposure := range plan.Exposures {
+							if err := c.captureMaster(ctx, CalibrationDark, settings, exposure, plan.Frames); err != nil {
+								return err
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+getCalibrationValues()
+
+@returns the values to iterate over, where a nil entry means the camera's current setting is kept.
+*/
+func getCalibrationValues(values []int32) []*int32 {
+	if len(values) == 0 {
+		return []*int32{nil}
+	}
+
+	pointers := make([]*int32, len(values))
+
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	return pointers
+}
+
+/*
+resetSubFrame()
+
+The subframe is given in binned pixels, so a change of binning leaves it covering the wrong part of the
+sensor (or none of it). Calibration frames cover the full frame at the given binning.
+*/
+func (c *CalibrationCapture) resetSubFrame(binning int32) error {
+	width, err := c.Camera.GetCCDSizeX()
+
+	if err != nil {
+		return err
+	}
+
+	height, err := c.Camera.GetCCDSizeY()
+
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(
+		c.Camera.SetStartX(0),
+		c.Camera.SetStartY(0),
+		c.Camera.SetSubFrameWidth(width/binning),
+		c.Camera.SetSubFrameHeight(height/binning),
+	)
+}
+
+/*
+apply()
+
+Applies each of the given settings to the camera, leaving those that are nil unchanged.
+
+@returns the settings read back from the camera, as recorded against the master.
+*/
+func (c *CalibrationCapture) apply(gain *int32, offset *int32, binning *int32, mode *int32) (CalibrationSettings, error) {
+	settings := CalibrationSettings{}
+
+	errs := []error{}
+
+	if gain != nil {
+		errs = append(errs, c.Camera.SetGain(*gain))
+	}
+
+	if offset != nil {
+		errs = append(errs, c.Camera.SetOffset(*offset))
+	}
+
+	if binning != nil {
+		errs = append(errs, c.Camera.SetBinX(*binning), c.Camera.SetBinY(*binning), c.resetSubFrame(*binning))
+	}
+
+	if mode != nil {
+		errs = append(errs, c.Camera.SetReadOutMode(*mode))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return settings, err
+	}
+
+	var err error
+
+	if settings.Gain, err = c.Camera.GetGain(); err != nil {
+		return settings, err
+	}
+
+	if settings.Offset, err = c.Camera.GetOffset(); err != nil {
+		return settings, err
+	}
+
+	if settings.BinX, err = c.Camera.GetBinX(); err != nil {
+		return settings, err
+	}
+
+	if settings.BinY, err = c.Camera.GetBinY(); err != nil {
+		return settings, err
+	}
+
+	settings.ReadOutMode, err = c.Camera.GetReadOutMode()
+
+	return settings, err
+}
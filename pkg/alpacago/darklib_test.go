@@ -0,0 +1,213 @@
+package alpacago
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+/*
+simulatedDarkCamera returns frames whose level encodes the settings and exposure duration, so
+that masters can be told apart: 100 + offset + gain + 10 × exposure, plus a per-frame hot pixel.
+*/
+type simulatedDarkCamera struct {
+	gain, offset, binX, binY, mode int32
+	startX, startY, numX, numY     int32
+	duration                       float64
+	frames                         int
+}
+
+func (c *simulatedDarkCamera) StartExposure(duration float64, light bool) error {
+	c.duration = duration
+	c.frames++
+	return nil
+}
+
+func (c *simulatedDarkCamera) IsImageReady() (bool, error) { return true, nil }
+
+func (c *simulatedDarkCamera) GetExposure() ([][]uint32, uint32, error) {
+	v := uint32(100 + c.offset + c.gain + int32(10*c.duration))
+
+	image := [][]uint32{{v, v, v}, {v, v, v}}
+
+	// A cosmic ray hit, which the median combination rejects:
+	image[c.frames%2][c.frames%3] = 60000
+
+	return image, 2, nil
+}
+
+func (c *simulatedDarkCamera) GetCCDTemperature() (float64, error) { return -10.04, nil }
+
+func (c *simulatedDarkCamera) GetExposureMin() (float64, error) { return 0.001, nil }
+
+func (c *simulatedDarkCamera) GetGain() (int32, error) { return c.gain, nil }
+
+func (c *simulatedDarkCamera) SetGain(gain int32) error {
+	c.gain = gain
+	return nil
+}
+
+func (c *simulatedDarkCamera) GetOffset() (int32, error) { return c.offset, nil }
+
+func (c *simulatedDarkCamera) SetOffset(offset int32) error {
+	c.offset = offset
+	return nil
+}
+
+func (c *simulatedDarkCamera) GetBinX() (int32, error) { return c.binX, nil }
+
+func (c *simulatedDarkCamera) SetBinX(binX int32) error {
+	c.binX = binX
+	return nil
+}
+
+func (c *simulatedDarkCamera) GetBinY() (int32, error) { return c.binY, nil }
+
+func (c *simulatedDarkCamera) SetBinY(binY int32) error {
+	c.binY = binY
+	return nil
+}
+
+func (c *simulatedDarkCamera) GetCCDSizeX() (int32, error) { return 3000, nil }
+
+func (c *simulatedDarkCamera) GetCCDSizeY() (int32, error) { return 2000, nil }
+
+func (c *simulatedDarkCamera) SetStartX(startX int32) error {
+	c.startX = startX
+	return nil
+}
+
+func (c *simulatedDarkCamera) SetStartY(startY int32) error {
+	c.startY = startY
+	return nil
+}
+
+func (c *simulatedDarkCamera) SetSubFrameWidth(numX int32) error {
+	c.numX = numX
+	return nil
+}
+
+func (c *simulatedDarkCamera) SetSubFrameHeight(numY int32) error {
+	c.numY = numY
+	return nil
+}
+
+func (c *simulatedDarkCamera) GetReadOutMode() (int32, error) { return c.mode, nil }
+
+func (c *simulatedDarkCamera) SetReadOutMode(readOutMode int32) error {
+	c.mode = readOutMode
+	return nil
+}
+
+func TestCombineMedian(t *testing.T) {
+	images := [][][]uint32{{{1, 9}}, {{3, 2}}, {{2, 60000}}}
+
+	master, err := CombineMedian(images)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if master[0][0] != 2 || master[0][1] != 9 {
+		t.Errorf("got %v, wanted [[2 9]]", master)
+	}
+}
+
+func TestCalibrationCaptureAndMatch(t *testing.T) {
+	directory := t.TempDir()
+
+	library, err := OpenCalibrationLibrary(directory)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	camera := &simulatedDarkCamera{binX: 1, binY: 1}
+
+	capture := NewCalibrationCapture(camera, library)
+
+	capture.PollInterval = time.Millisecond
+
+	plan := CalibrationPlan{Gains: []int32{0, 100}, Offsets: []int32{10}, Exposures: []float64{60, 300}, Bias: true, Frames: 3}
+
+	if err := capture.Run(context.Background(), plan); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(library.Masters) != 6 {
+		t.Fatalf("got %d masters, wanted %d", len(library.Masters), 6)
+	}
+
+	// Reopening the library reads back its index:
+	library, err = OpenCalibrationLibrary(directory)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	settings := CalibrationSettings{Temperature: -9.5, Gain: 100, Offset: 10, BinX: 1, BinY: 1}
+
+	master, err := library.FindMaster(CalibrationDark, settings, 240)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if master.Exposure != 300 || master.Settings.Gain != 100 || master.Settings.Temperature != -10 {
+		t.Errorf("got %+v, wanted the 300s dark at gain 100 and -10°C", master)
+	}
+
+	image, err := library.LoadImage(master)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(image) != 2 || image[0][0] != 3210 || image[1][2] != 3210 {
+		t.Errorf("got %v, wanted a uniform master of %d", image, 3210)
+	}
+
+	if _, err := library.FindMaster(CalibrationBias, settings, 0); err != nil {
+		t.Errorf("got %q, wanted a matching bias", err)
+	}
+
+	settings.Temperature = -20
+
+	if _, err := library.FindMaster(CalibrationDark, settings, 300); err == nil {
+		t.Errorf("got nil, wanted an error for a temperature outside of tolerance")
+	}
+}
+
+func TestCalibrationCaptureRequiresCooler(t *testing.T) {
+	library, _ := OpenCalibrationLibrary(t.TempDir())
+
+	capture := NewCalibrationCapture(&simulatedDarkCamera{}, library)
+
+	if err := capture.Run(context.Background(), CalibrationPlan{Temperatures: []float64{-10}, Frames: 1}); err == nil {
+		t.Errorf("got nil, wanted an error when no cooler is configured")
+	}
+}
+
+func TestCalibrationCaptureResetsSubFrame(t *testing.T) {
+	library, _ := OpenCalibrationLibrary(t.TempDir())
+
+	// A subframe left over from e.g., a focus run:
+	camera := &simulatedDarkCamera{binX: 1, binY: 1, startX: 1200, startY: 800, numX: 200, numY: 200}
+
+	capture := NewCalibrationCapture(camera, library)
+
+	capture.PollInterval = time.Millisecond
+
+	if err := capture.Run(context.Background(), CalibrationPlan{Binnings: []int32{2}, Bias: true, Frames: 1}); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var got []int32 = []int32{camera.startX, camera.startY, camera.numX, camera.numY}
+
+	var want []int32 = []int32{0, 0, 1500, 1000}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, wanted the full frame at bin 2, %v", got, want)
+	}
+}
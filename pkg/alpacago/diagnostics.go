@@ -0,0 +1,443 @@
+package alpacago
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type DiagnosticStatus uint8
+
+const (
+	DiagnosticPassed DiagnosticStatus = iota
+	DiagnosticNotImplemented
+	DiagnosticFailed
+)
+
+func (d DiagnosticStatus) String() string {
+	name := []string{"passed", "not implemented", "failed"}
+
+	i := uint8(d)
+
+	switch {
+	case i <= uint8(DiagnosticFailed):
+		return name[i]
+	default:
+		return fmt.Sprintf("DiagnosticStatus(%d)", i)
+	}
+}
+
+func (d DiagnosticStatus) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (p PropertyType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+/*
+DiagnosticResult is the outcome of reading a single member of a device.
+*/
+type DiagnosticResult struct {
+	Method string           `json:"method"`
+	Type   PropertyType     `json:"type"`
+	Status DiagnosticStatus `json:"status"`
+	// The value read, if the member passed
+	Value interface{} `json:"value,omitempty"`
+	// The ASCOM error number, if the driver reported an error
+	ErrorNumber int32         `json:"error_number,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Latency     time.Duration `json:"latency_ns"`
+}
+
+/*
+DiagnosticReport is the outcome of reading every read-only member of a device.
+*/
+type DiagnosticReport struct {
+	DeviceType   string             `json:"device_type"`
+	DeviceNumber uint               `json:"device_number"`
+	Started      time.Time          `json:"started"`
+	Duration     time.Duration      `json:"duration_ns"`
+	Results      []DiagnosticResult `json:"results"`
+}
+
+/*
+GetSummary()
+
+@returns the number of members which passed, are not implemented, and failed.
+*/
+func (r *DiagnosticReport) GetSummary() (int, int, int) {
+	count := [3]int{}
+
+	for _, result := range r.Results {
+		if result.Status <= DiagnosticFailed {
+			count[result.Status]++
+		}
+	}
+
+	return count[DiagnosticPassed], count[DiagnosticNotImplemented], count[DiagnosticFailed]
+}
+
+/*
+WriteText()
+
+Writes the report as a table of members, with a summary.
+*/
+func (r *DiagnosticReport) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%s %d diagnostics, started %s and took %s\n\n", r.DeviceType, r.DeviceNumber, r.Started.UTC().Format(time.RFC3339), r.Duration.Round(time.Millisecond))
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(table, "METHOD\tTYPE\tSTATUS\tLATENCY\tVALUE")
+
+	for _, result := range r.Results {
+		value := fmt.Sprintf("%v", result.Value)
+
+		if result.Status != DiagnosticPassed {
+			value = result.Error
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", result.Method, result.Type, result.Status, result.Latency.Round(time.Microsecond), strings.ReplaceAll(value, "\n", " "))
+	}
+
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	passed, notImplemented, failed := r.GetSummary()
+
+	_, err := fmt.Fprintf(w, "\n%d passed, %d not implemented, %d failed\n", passed, notImplemented, failed)
+
+	return err
+}
+
+/*
+WriteJSON()
+
+Writes the report as indented JSON.
+*/
+func (r *DiagnosticReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+/*
+commonDiagnostics are the read-only members common to all ASCOM Alpaca compliant devices.
+*/
+var commonDiagnostics = []PropertyRequest{
+	{Method: "connected", Type: PropertyBoolean},
+	{Method: "description", Type: PropertyString},
+	{Method: "driverinfo", Type: PropertyString},
+	{Method: "driverversion", Type: PropertyString},
+	{Method: "interfaceversion", Type: PropertyInt32},
+	{Method: "name", Type: PropertyString},
+	{Method: "supportedactions", Type: PropertyStringList},
+}
+
+/*
+Diagnose()
+
+Reads each of the common members, then each of the given device members, in turn, recording whether each
+passed, is not implemented or failed (with the ASCOM error number), and how long it took. The members are
+read one at a time so as not to load a struggling driver, stopping early if the context is done.
+*/
+func (a *ASCOMAlpacaAPIClient) Diagnose(ctx context.Context, deviceType string, deviceNumber uint, members []PropertyRequest) DiagnosticReport {
+	report := DiagnosticReport{DeviceType: deviceType, DeviceNumber: deviceNumber, Started: time.Now()}
+
+	for _, member := range append(append([]PropertyRequest{}, commonDiagnostics...), members...) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		request := PropertyRequest{DeviceType: deviceType, DeviceNumber: deviceNumber, Method: member.Method, Type: member.Type}
+
+		start := time.Now()
+
+		value, err := a.getProperty(ctx, request)
+
+		result := DiagnosticResult{Method: member.Method, Type: member.Type, Latency: time.Since(start)}
+
+		var ascom *ASCOMError
+
+		switch {
+		case err == nil:
+			result.Status, result.Value = DiagnosticPassed, value
+		case errors.As(err, &ascom) && ascom.Number == ASCOMNotImplemented:
+			result.Status, result.ErrorNumber, result.Error = DiagnosticNotImplemented, ascom.Number, ascom.Message
+		case errors.As(err, &ascom):
+			result.Status, result.ErrorNumber, result.Error = DiagnosticFailed, ascom.Number, ascom.Message
+		default:
+			result.Status, result.Error = DiagnosticFailed, err.Error()
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	report.Duration = time.Since(report.Started)
+
+	return report
+}
+
+var cameraDiagnostics = []PropertyRequest{
+	{Method: "bayeroffsetx", Type: PropertyInt32},
+	{Method: "bayeroffsety", Type: PropertyInt32},
+	{Method: "binx", Type: PropertyInt32},
+	{Method: "biny", Type: PropertyInt32},
+	{Method: "camerastate", Type: PropertyInt32},
+	{Method: "cameraxsize", Type: PropertyInt32},
+	{Method: "cameraysize", Type: PropertyInt32},
+	{Method: "canabortexposure", Type: PropertyBoolean},
+	{Method: "canasymmetricbin", Type: PropertyBoolean},
+	{Method: "canfastreadout", Type: PropertyBoolean},
+	{Method: "cangetcoolerpower", Type: PropertyBoolean},
+	{Method: "canpulseguide", Type: PropertyBoolean},
+	{Method: "cansetccdtemperature", Type: PropertyBoolean},
+	{Method: "canstopexposure", Type: PropertyBoolean},
+	{Method: "ccdtemperature", Type: PropertyFloat64},
+	{Method: "cooleron", Type: PropertyBoolean},
+	{Method: "coolerpower", Type: PropertyFloat64},
+	{Method: "electronsperadu", Type: PropertyFloat64},
+	{Method: "exposuremax", Type: PropertyFloat64},
+	{Method: "exposuremin", Type: PropertyFloat64},
+	{Method: "exposureresolution", Type: PropertyFloat64},
+	{Method: "fastreadout", Type: PropertyBoolean},
+	{Method: "fullwellcapacity", Type: PropertyFloat64},
+	{Method: "gain", Type: PropertyInt32},
+	{Method: "gainmax", Type: PropertyInt32},
+	{Method: "gainmin", Type: PropertyInt32},
+	{Method: "gains", Type: PropertyStringList},
+	{Method: "hasshutter", Type: PropertyBoolean},
+	{Method: "heatsinktemperature", Type: PropertyFloat64},
+	{Method: "imageready", Type: PropertyBoolean},
+	{Method: "ispulseguiding", Type: PropertyBoolean},
+	{Method: "lastexposureduration", Type: PropertyFloat64},
+	{Method: "lastexposurestarttime", Type: PropertyString},
+	{Method: "maxadu", Type: PropertyInt32},
+	{Method: "maxbinx", Type: PropertyInt32},
+	{Method: "maxbiny", Type: PropertyInt32},
+	{Method: "numx", Type: PropertyInt32},
+	{Method: "numy", Type: PropertyInt32},
+	{Method: "offset", Type: PropertyInt32},
+	{Method: "percentcompleted", Type: PropertyInt32},
+	{Method: "pixelsizex", Type: PropertyFloat64},
+	{Method: "pixelsizey", Type: PropertyFloat64},
+	{Method: "readoutmode", Type: PropertyInt32},
+	{Method: "readoutmodes", Type: PropertyStringList},
+	{Method: "sensorname", Type: PropertyString},
+	{Method: "sensortype", Type: PropertyInt32},
+	{Method: "setccdtemperature", Type: PropertyFloat64},
+	{Method: "startx", Type: PropertyInt32},
+	{Method: "starty", Type: PropertyInt32},
+	{Method: "subexposureduration", Type: PropertyFloat64},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the camera, other than the image array.
+*/
+func (c *Camera) Diagnose(ctx context.Context) DiagnosticReport {
+	return c.Alpaca.Diagnose(ctx, "camera", c.DeviceNumber, cameraDiagnostics)
+}
+
+var coverCalibratorDiagnostics = []PropertyRequest{
+	{Method: "brightness", Type: PropertyFloat64},
+	{Method: "calibratorstate", Type: PropertyInt32},
+	{Method: "coverstate", Type: PropertyInt32},
+	{Method: "maxbrightness", Type: PropertyInt32},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the cover calibrator.
+*/
+func (c *CoverCalibrator) Diagnose(ctx context.Context) DiagnosticReport {
+	return c.Alpaca.Diagnose(ctx, "covercalibrator", c.DeviceNumber, coverCalibratorDiagnostics)
+}
+
+var domeDiagnostics = []PropertyRequest{
+	{Method: "altitude", Type: PropertyFloat64},
+	{Method: "athome", Type: PropertyBoolean},
+	{Method: "atpark", Type: PropertyBoolean},
+	{Method: "azimuth", Type: PropertyFloat64},
+	{Method: "canfindhome", Type: PropertyBoolean},
+	{Method: "canpark", Type: PropertyBoolean},
+	{Method: "cansetaltitude", Type: PropertyBoolean},
+	{Method: "cansetazimuth", Type: PropertyBoolean},
+	{Method: "cansetpark", Type: PropertyBoolean},
+	{Method: "cansetshutter", Type: PropertyBoolean},
+	{Method: "canslave", Type: PropertyBoolean},
+	{Method: "cansyncazimuth", Type: PropertyBoolean},
+	{Method: "shutterstatus", Type: PropertyInt32},
+	{Method: "slaved", Type: PropertyBoolean},
+	{Method: "slewing", Type: PropertyBoolean},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the dome.
+*/
+func (d *Dome) Diagnose(ctx context.Context) DiagnosticReport {
+	return d.Alpaca.Diagnose(ctx, "dome", d.DeviceNumber, domeDiagnostics)
+}
+
+var filterWheelDiagnostics = []PropertyRequest{
+	{Method: "focusoffsets", Type: PropertyUInt32List},
+	{Method: "names", Type: PropertyStringList},
+	{Method: "position", Type: PropertyInt32},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the filter wheel.
+*/
+func (f *FilterWheel) Diagnose(ctx context.Context) DiagnosticReport {
+	return f.Alpaca.Diagnose(ctx, "filterwheel", f.DeviceNumber, filterWheelDiagnostics)
+}
+
+var focuserDiagnostics = []PropertyRequest{
+	{Method: "absolute", Type: PropertyBoolean},
+	{Method: "ismoving", Type: PropertyBoolean},
+	{Method: "maxincrement", Type: PropertyInt32},
+	{Method: "maxstep", Type: PropertyInt32},
+	{Method: "position", Type: PropertyInt32},
+	{Method: "stepsize", Type: PropertyFloat64},
+	{Method: "tempcomp", Type: PropertyBoolean},
+	{Method: "tempcompavailable", Type: PropertyBoolean},
+	{Method: "temperature", Type: PropertyFloat64},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the focuser.
+*/
+func (f *Focuser) Diagnose(ctx context.Context) DiagnosticReport {
+	return f.Alpaca.Diagnose(ctx, "focuser", f.DeviceNumber, focuserDiagnostics)
+}
+
+var observingConditionsDiagnostics = []PropertyRequest{
+	{Method: "averageperiod", Type: PropertyFloat64},
+	{Method: "cloudcover", Type: PropertyFloat64},
+	{Method: "dewpoint", Type: PropertyFloat64},
+	{Method: "humidity", Type: PropertyFloat64},
+	{Method: "pressure", Type: PropertyFloat64},
+	{Method: "rainrate", Type: PropertyFloat64},
+	{Method: "skybrightness", Type: PropertyFloat64},
+	{Method: "skyquality", Type: PropertyFloat64},
+	{Method: "skytemperature", Type: PropertyFloat64},
+	{Method: "starfwhm", Type: PropertyFloat64},
+	{Method: "temperature", Type: PropertyFloat64},
+	{Method: "winddirection", Type: PropertyFloat64},
+	{Method: "windgust", Type: PropertyFloat64},
+	{Method: "windspeed", Type: PropertyFloat64},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the observing conditions.
+*/
+func (c *ObservingConditions) Diagnose(ctx context.Context) DiagnosticReport {
+	return c.Alpaca.Diagnose(ctx, "observingconditions", c.DeviceNumber, observingConditionsDiagnostics)
+}
+
+var rotatorDiagnostics = []PropertyRequest{
+	{Method: "canreverse", Type: PropertyBoolean},
+	{Method: "ismoving", Type: PropertyBoolean},
+	{Method: "mechanicalposition", Type: PropertyFloat64},
+	{Method: "position", Type: PropertyFloat64},
+	{Method: "reverse", Type: PropertyBoolean},
+	{Method: "stepsize", Type: PropertyFloat64},
+	{Method: "targetposition", Type: PropertyFloat64},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the rotator.
+*/
+func (r *Rotator) Diagnose(ctx context.Context) DiagnosticReport {
+	return r.Alpaca.Diagnose(ctx, "rotator", r.DeviceNumber, rotatorDiagnostics)
+}
+
+var safetyMonitorDiagnostics = []PropertyRequest{
+	{Method: "issafe", Type: PropertyBoolean},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the safety monitor.
+*/
+func (m *SafetyMonitor) Diagnose(ctx context.Context) DiagnosticReport {
+	return m.Alpaca.Diagnose(ctx, "safetymonitor", m.DeviceNumber, safetyMonitorDiagnostics)
+}
+
+var telescopeDiagnostics = []PropertyRequest{
+	{Method: "alignmentmode", Type: PropertyInt32},
+	{Method: "altitude", Type: PropertyFloat64},
+	{Method: "aperturearea", Type: PropertyFloat64},
+	{Method: "aperturediameter", Type: PropertyFloat64},
+	{Method: "athome", Type: PropertyBoolean},
+	{Method: "atpark", Type: PropertyBoolean},
+	{Method: "azimuth", Type: PropertyFloat64},
+	{Method: "canfindhome", Type: PropertyBoolean},
+	{Method: "canpark", Type: PropertyBoolean},
+	{Method: "canpulseguide", Type: PropertyBoolean},
+	{Method: "cansetdeclinationrate", Type: PropertyBoolean},
+	{Method: "cansetguiderates", Type: PropertyBoolean},
+	{Method: "cansetpark", Type: PropertyBoolean},
+	{Method: "cansetpierside", Type: PropertyBoolean},
+	{Method: "cansetrightascensionrate", Type: PropertyBoolean},
+	{Method: "cansettracking", Type: PropertyBoolean},
+	{Method: "canslew", Type: PropertyBoolean},
+	{Method: "canslewaltaz", Type: PropertyBoolean},
+	{Method: "canslewaltazasync", Type: PropertyBoolean},
+	{Method: "canslewasync", Type: PropertyBoolean},
+	{Method: "cansync", Type: PropertyBoolean},
+	{Method: "cansyncaltaz", Type: PropertyBoolean},
+	{Method: "canunpark", Type: PropertyBoolean},
+	{Method: "declination", Type: PropertyFloat64},
+	{Method: "declinationrate", Type: PropertyFloat64},
+	{Method: "doesrefraction", Type: PropertyBoolean},
+	{Method: "equatorialsystem", Type: PropertyInt32},
+	{Method: "focallength", Type: PropertyFloat64},
+	{Method: "guideratedeclination", Type: PropertyFloat64},
+	{Method: "guideraterightascension", Type: PropertyFloat64},
+	{Method: "ispulseguiding", Type: PropertyBoolean},
+	{Method: "rightascension", Type: PropertyFloat64},
+	{Method: "rightascensionrate", Type: PropertyFloat64},
+	{Method: "sideofpier", Type: PropertyInt32},
+	{Method: "siderealtime", Type: PropertyFloat64},
+	{Method: "siteelevation", Type: PropertyFloat64},
+	{Method: "sitelatitude", Type: PropertyFloat64},
+	{Method: "sitelongitude", Type: PropertyFloat64},
+	{Method: "slewing", Type: PropertyBoolean},
+	{Method: "slewsettletime", Type: PropertyInt32},
+	{Method: "targetdeclination", Type: PropertyFloat64},
+	{Method: "targetrightascension", Type: PropertyFloat64},
+	{Method: "tracking", Type: PropertyBoolean},
+	{Method: "trackingrate", Type: PropertyInt32},
+	{Method: "utcdate", Type: PropertyString},
+}
+
+/*
+Diagnose()
+
+@returns a report of reading every read-only member of the telescope.
+*/
+func (t *Telescope) Diagnose(ctx context.Context) DiagnosticReport {
+	return t.Alpaca.Diagnose(ctx, "telescope", t.DeviceNumber, telescopeDiagnostics)
+}
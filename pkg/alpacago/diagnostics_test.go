@@ -0,0 +1,129 @@
+package alpacago
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticStatusString(t *testing.T) {
+	var got string = DiagnosticNotImplemented.String()
+
+	var want string = "not implemented"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestTelescopeDiagnose(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetValue("telescope", 0, "connected", true)
+
+	transport.SetValue("telescope", 0, "name", "Synthetic Telescope")
+
+	transport.SetValue("telescope", 0, "altitude", 45.5)
+
+	transport.SetError(http.MethodGet, "telescope", 0, "targetrightascension", 0x402, "the target right ascension has not been set")
+
+	telescope := newTestLoggedTelescope(transport, nil)
+
+	report := telescope.Diagnose(context.Background())
+
+	if got, want := len(report.Results), len(commonDiagnostics)+len(telescopeDiagnostics); got != want {
+		t.Fatalf("got %d results, wanted %d", got, want)
+	}
+
+	passed, notImplemented, failed := report.GetSummary()
+
+	if passed != 3 || failed != 1 || notImplemented != len(report.Results)-4 {
+		t.Errorf("got %d passed, %d not implemented and %d failed, wanted 3, %d and 1", passed, notImplemented, failed, len(report.Results)-4)
+	}
+
+	for _, result := range report.Results {
+		switch result.Method {
+		case "altitude":
+			if result.Status != DiagnosticPassed || result.Value != 45.5 {
+				t.Errorf("got %v %v, wanted passed 45.5", result.Status, result.Value)
+			}
+		case "targetrightascension":
+			if result.Status != DiagnosticFailed || result.ErrorNumber != 0x402 {
+				t.Errorf("got %v %d, wanted failed 1026", result.Status, result.ErrorNumber)
+			}
+		case "utcdate":
+			if result.Status != DiagnosticNotImplemented || result.ErrorNumber != ASCOMNotImplemented {
+				t.Errorf("got %v %d, wanted not implemented 1024", result.Status, result.ErrorNumber)
+			}
+		}
+	}
+
+	var text bytes.Buffer
+
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	for _, want := range []string{"telescope 0 diagnostics", "METHOD", "altitude", "the target right ascension has not been set", "3 passed, "} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("got %q, wanted %q", text.String(), want)
+		}
+	}
+
+	var data bytes.Buffer
+
+	if err := report.WriteJSON(&data); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	decoded := struct {
+		DeviceType string `json:"device_type"`
+		Results    []struct {
+			Method string `json:"method"`
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"results"`
+	}{}
+
+	if err := json.Unmarshal(data.Bytes(), &decoded); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if decoded.DeviceType != "telescope" || decoded.Results[0].Method != "connected" || decoded.Results[0].Type != "boolean" || decoded.Results[0].Status != "passed" {
+		t.Errorf("got %+v, wanted the telescope report", decoded)
+	}
+}
+
+func TestSafetyMonitorDiagnose(t *testing.T) {
+	server := httptest.NewServer(NewSafetyMonitorServer(&fakeSafetySource{safe: true}, 0, "Synthetic Safety Monitor"))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	report := monitor.Diagnose(context.Background())
+
+	passed, notImplemented, failed := report.GetSummary()
+
+	if passed != len(report.Results) || notImplemented != 0 || failed != 0 {
+		t.Errorf("got %d passed, %d not implemented and %d failed, wanted all %d passed", passed, notImplemented, failed, len(report.Results))
+	}
+}
+
+func TestDiagnoseCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	telescope := newTestLoggedTelescope(NewFakeTransport(), nil)
+
+	report := telescope.Diagnose(ctx)
+
+	if len(report.Results) != 0 {
+		t.Errorf("got %d results, wanted 0", len(report.Results))
+	}
+}
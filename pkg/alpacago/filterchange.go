@@ -0,0 +1,216 @@
+package alpacago
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OffsetFilterWheel interface {
+	GetNames() ([]string, error)
+	GetFocusOffsets() ([]uint32, error)
+	GetPosition() (int32, error)
+	SetPosition(position int32) error
+}
+
+type FilterChange struct {
+	From         string
+	To           string
+	FromPosition int32
+	ToPosition   int32
+	// The focuser offset applied for the change, in steps
+	FocusDelta int32
+}
+
+type FilterChanger struct {
+	Wheel OffsetFilterWheel
+	// The focuser to which filter focus offsets are applied (optional)
+	Focuser AutofocusFocuser
+	// Focus offsets keyed by filter name, which take precedence over the wheel's own offsets
+	Offsets      map[string]int32
+	PollInterval time.Duration
+}
+
+func NewFilterChanger(wheel OffsetFilterWheel, focuser AutofocusFocuser) *FilterChanger {
+	changer := FilterChanger{
+		Wheel:        wheel,
+		Focuser:      focuser,
+		Offsets:      map[string]int32{},
+		PollInterval: 250 * time.Millisecond,
+	}
+
+	return &changer
+}
+
+/*
+getFilterIndex()
+
+@returns the slot of the named filter, matching exactly and then case-insensitively.
+*/
+func getFilterIndex(names []string, name string) (int32, error) {
+	for i, n := range names {
+		if n == name {
+			return int32(i), nil
+		}
+	}
+
+	for i, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), strings.TrimSpace(name)) {
+			return int32(i), nil
+		}
+	}
+
+	return -1, fmt.Errorf("the filter %q is not one of %q", name, names)
+}
+
+/*
+waitForPosition()
+
+The filter wheel reports a position of -1 whilst it is moving.
+
+@returns the wheel position once the wheel has come to rest.
+*/
+func (c *FilterChanger) waitForPosition(ctx context.Context) (int32, error) {
+	var position int32 = -1
+
+	err := WaitUntil(ctx, c.PollInterval, func() (bool, error) {
+		p, err := c.Wheel.GetPosition()
+		position = p
+		return p >= 0, err
+	})
+
+	return position, err
+}
+
+/*
+getOffset()
+
+@returns the focus offset of the filter slot, from the user supplied table if present, else from the wheel.
+*/
+func (c *FilterChanger) getOffset(names []string, offsets []uint32, position int32) (int32, error) {
+	if position >= 0 && int(position) < len(names) {
+		if offset, ok := c.Offsets[names[position]]; ok {
+			return offset, nil
+		}
+	}
+
+	if position < 0 || int(position) >= len(offsets) {
+		return 0, fmt.Errorf("the filter wheel has no focus offset for position %d", position)
+	}
+
+	return int32(offsets[position]), nil
+}
+
+/*
+ChangeFilter()
+
+Moves the wheel to the named filter and waits for it to arrive, then applies the difference in
+focus offsets between the previous and new filters to the focuser, and waits for it to settle.
+
+@param name string (the filter name, as returned by FilterWheel.GetNames())
+@returns the change that was made.
+*/
+func (c *FilterChanger) ChangeFilter(ctx context.Context, name string) (FilterChange, error) {
+	change := FilterChange{To: name}
+
+	names, err := c.Wheel.GetNames()
+
+	if err != nil {
+		return change, err
+	}
+
+	target, err := getFilterIndex(names, name)
+
+	if err != nil {
+		return change, err
+	}
+
+	change.To, change.ToPosition = names[target], target
+
+	current, err := c.waitForPosition(ctx)
+
+	if err != nil {
+		return change, err
+	}
+
+	change.FromPosition = current
+
+	if int(current) < len(names) {
+		change.From = names[current]
+	}
+
+	if current == target {
+		return change, nil
+	}
+
+	// Only consult the wheel's offsets when the user table does not cover both filters:
+	var offsets []uint32 = []uint32{}
+
+	_, hasFrom := c.Offsets[change.From]
+
+	_, hasTo := c.Offsets[change.To]
+
+	if c.Focuser != nil && !(hasFrom && hasTo) {
+		if offsets, err = c.Wheel.GetFocusOffsets(); err != nil {
+			return change, err
+		}
+	}
+
+	if err := c.Wheel.SetPosition(target); err != nil {
+		return change, err
+	}
+
+	if err := WaitUntil(ctx, c.PollInterval, func() (bool, error) {
+		p, err := c.Wheel.GetPosition()
+		return p == target, err
+	}); err != nil {
+		return change, err
+	}
+
+	if c.Focuser == nil {
+		return change, nil
+	}
+
+	from, err := c.getOffset(names, offsets, current)
+
+	if err != nil {
+		return change, err
+	}
+
+	to, err := c.getOffset(names, offsets, target)
+
+	if err != nil {
+		return change, err
+	}
+
+	change.FocusDelta = to - from
+
+	if change.FocusDelta == 0 {
+		return change, nil
+	}
+
+	absolute, err := c.Focuser.IsAbsolute()
+
+	if err != nil {
+		return change, err
+	}
+
+	var move int32 = change.FocusDelta
+
+	if absolute {
+		position, err := c.Focuser.GetPosition()
+
+		if err != nil {
+			return change, err
+		}
+
+		move = position + change.FocusDelta
+	}
+
+	if err := c.Focuser.SetMove(move); err != nil {
+		return change, err
+	}
+
+	return change, WaitWhileMoving(ctx, c.PollInterval, c.Focuser.IsMoving)
+}
@@ -0,0 +1,134 @@
+package alpacago
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeOffsetFilterWheel struct {
+	names    []string
+	offsets  []uint32
+	position int32
+	// The number of position reads for which the wheel reports it is moving (-1)
+	moving int
+	target int32
+}
+
+func (w *fakeOffsetFilterWheel) GetNames() ([]string, error) { return w.names, nil }
+
+func (w *fakeOffsetFilterWheel) GetFocusOffsets() ([]uint32, error) { return w.offsets, nil }
+
+func (w *fakeOffsetFilterWheel) GetPosition() (int32, error) {
+	if w.moving > 0 {
+		w.moving--
+
+		if w.moving == 0 {
+			w.position = w.target
+		}
+
+		return -1, nil
+	}
+
+	return w.position, nil
+}
+
+func (w *fakeOffsetFilterWheel) SetPosition(position int32) error {
+	w.target = position
+	w.moving = 2
+	return nil
+}
+
+func newTestFilterChanger(wheel *fakeOffsetFilterWheel, focuser *simulatedFocuser) *FilterChanger {
+	changer := NewFilterChanger(wheel, focuser)
+
+	changer.PollInterval = time.Millisecond
+
+	return changer
+}
+
+func TestFilterChangerAppliesOffsetDelta(t *testing.T) {
+	wheel := &fakeOffsetFilterWheel{names: []string{"Lum", "Red", "Green", "Blue"}, offsets: []uint32{0, 30, 45, 80}}
+
+	focuser := &simulatedFocuser{position: 10000, absolute: true}
+
+	change, err := newTestFilterChanger(wheel, focuser).ChangeFilter(context.Background(), "blue")
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if wheel.position != 3 {
+		t.Errorf("got %d, wanted %d", wheel.position, 3)
+	}
+
+	if change.From != "Lum" || change.To != "Blue" {
+		t.Errorf("got %q to %q, wanted %q to %q", change.From, change.To, "Lum", "Blue")
+	}
+
+	if focuser.position != 10080 {
+		t.Errorf("got %d, wanted %d", focuser.position, 10080)
+	}
+}
+
+func TestFilterChangerRelativeFocuser(t *testing.T) {
+	wheel := &fakeOffsetFilterWheel{names: []string{"Lum", "Red", "Green", "Blue"}, offsets: []uint32{0, 30, 45, 80}, position: 3}
+
+	focuser := &simulatedFocuser{position: 10000, absolute: false}
+
+	change, err := newTestFilterChanger(wheel, focuser).ChangeFilter(context.Background(), "Red")
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if change.FocusDelta != -50 {
+		t.Errorf("got %d, wanted %d", change.FocusDelta, -50)
+	}
+
+	if focuser.position != 9950 {
+		t.Errorf("got %d, wanted %d", focuser.position, 9950)
+	}
+}
+
+func TestFilterChangerUserOffsetsOverrideWheel(t *testing.T) {
+	wheel := &fakeOffsetFilterWheel{names: []string{"Lum", "Ha"}, offsets: []uint32{0, 10}}
+
+	focuser := &simulatedFocuser{position: 10000, absolute: true}
+
+	changer := newTestFilterChanger(wheel, focuser)
+
+	changer.Offsets = map[string]int32{"Ha": -120}
+
+	change, err := changer.ChangeFilter(context.Background(), "Ha")
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if change.FocusDelta != -120 {
+		t.Errorf("got %d, wanted %d", change.FocusDelta, -120)
+	}
+}
+
+func TestFilterChangerSameFilterIsNoop(t *testing.T) {
+	wheel := &fakeOffsetFilterWheel{names: []string{"Lum", "Red"}, offsets: []uint32{0, 30}, position: 1}
+
+	focuser := &simulatedFocuser{position: 10000, absolute: true}
+
+	if _, err := newTestFilterChanger(wheel, focuser).ChangeFilter(context.Background(), "Red"); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(focuser.moves) != 0 {
+		t.Errorf("got %v, wanted no focuser moves", focuser.moves)
+	}
+}
+
+func TestFilterChangerUnknownFilter(t *testing.T) {
+	wheel := &fakeOffsetFilterWheel{names: []string{"Lum", "Red"}, offsets: []uint32{0, 30}}
+
+	if _, err := NewFilterChanger(wheel, nil).ChangeFilter(context.Background(), "OIII"); err == nil {
+		t.Errorf("got nil, wanted an error for an unknown filter")
+	}
+}
@@ -0,0 +1,310 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type FlatsCamera interface {
+	ExposureCamera
+	GetMaxADU() (int32, error)
+}
+
+type FlatsCalibrator interface {
+	CloseCover() error
+	OpenCover() error
+	GetCoverStatus() (string, error)
+	GetStatus() (CalibratorState, error)
+	GetMaxBrightness() (int32, error)
+	SetCalibratorOn(brightness int32) error
+	SetCalibratorOff() error
+}
+
+type FlatsOptions struct {
+	// The target median, as a fraction of the camera's maximum ADU
+	TargetFraction float64
+	// The accepted deviation of the median from the target, as a fraction of the target
+	Tolerance float64
+	// The number of flats captured per filter
+	Count int
+	// The exposure duration (seconds) limits, and the duration of the first test exposure
+	MinExposure     float64
+	MaxExposure     float64
+	InitialExposure float64
+	// The calibrator brightness of the first test exposure, or 0 for the maximum brightness
+	Brightness int32
+	// The number of test exposures allowed to find the exposure for each filter
+	MaxIterations int
+	PollInterval  time.Duration
+}
+
+func NewFlatsOptions() FlatsOptions {
+	return FlatsOptions{
+		TargetFraction:  0.5,
+		Tolerance:       0.1,
+		Count:           20,
+		MinExposure:     0.1,
+		MaxExposure:     10,
+		InitialExposure: 1,
+		Brightness:      0,
+		MaxIterations:   10,
+		PollInterval:    250 * time.Millisecond,
+	}
+}
+
+type FlatsResult struct {
+	Filter     string
+	Brightness int32
+	// The exposure duration (seconds) the flats were captured with
+	Exposure float64
+	// The median ADU of the final test exposure
+	Median float64
+	Frames int
+}
+
+type Flats struct {
+	Camera     FlatsCamera
+	Calibrator FlatsCalibrator
+	// The filter changer used to step through filters (optional)
+	Filters *FilterChanger
+	Options FlatsOptions
+	// Called with every flat captured; an error stops the run
+	OnFrame func(filter string, index int, image [][]uint32) error
+}
+
+func NewFlats(camera FlatsCamera, calibrator FlatsCalibrator, options FlatsOptions) *Flats {
+	flats := Flats{
+		Camera:     camera,
+		Calibrator: calibrator,
+		Options:    options,
+	}
+
+	return &flats
+}
+
+/*
+setBrightness()
+
+Turns the calibrator on at the given brightness and waits for it to report ready.
+*/
+func (f *Flats) setBrightness(ctx context.Context, brightness int32) error {
+	if err := f.Calibrator.SetCalibratorOn(brightness); err != nil {
+		return err
+	}
+
+	return WaitUntil(ctx, f.Options.PollInterval, func() (bool, error) {
+		state, err := f.Calibrator.GetStatus()
+
+		if state == CalibratorError {
+			return false, errors.New("the calibrator reported an error turning on")
+		}
+
+		return state != CalibratorNotReady, err
+	})
+}
+
+/*
+closeCover()
+
+Closes the cover and waits for it to report closed; a device without a cover is left as is.
+
+@returns true if the device has a cover.
+*/
+func (f *Flats) closeCover(ctx context.Context) (bool, error) {
+	status, err := f.Calibrator.GetCoverStatus()
+
+	if err != nil || status == CoverNotPresent.String() {
+		return false, err
+	}
+
+	if err := f.Calibrator.CloseCover(); err != nil {
+		return true, err
+	}
+
+	return true, WaitUntil(ctx, f.Options.PollInterval, func() (bool, error) {
+		status, err := f.Calibrator.GetCoverStatus()
+
+		if status == CoverError.String() {
+			return false, errors.New("the cover reported an error closing")
+		}
+
+		return status == CoverClosed.String(), err
+	})
+}
+
+/*
+getNextExposure()
+
+Scales the exposure linearly towards the target median, trading exposure duration against
+calibrator brightness whenever the duration would fall outside its limits.
+
+@returns the next brightness and exposure duration to test.
+*/
+func (f *Flats) getNextExposure(brightness int32, maxBrightness int32, exposure float64, median float64, target float64, saturation float64) (int32, float64, error) {
+	scale := target / math.Max(median, 1)
+
+	// A saturated median says little about the required scale, so back off quickly:
+	if median >= 0.95*saturation {
+		scale = 0.25
+	}
+
+	level := float64(brightness) * exposure * scale
+
+	next := level / float64(brightness)
+
+	if next < f.Options.MinExposure {
+		dimmer := int32(math.Floor(level / f.Options.MinExposure))
+
+		if dimmer < 1 {
+			return brightness, exposure, errors.New("the calibrator is too bright for the minimum exposure at its lowest brightness")
+		}
+
+		return dimmer, level / float64(dimmer), nil
+	}
+
+	if next > f.Options.MaxExposure {
+		if brightness >= maxBrightness {
+			return brightness, exposure, errors.New("the calibrator is too dim for the maximum exposure at its highest brightness")
+		}
+
+		brighter := int32(math.Min(float64(maxBrightness), math.Ceil(level/f.Options.MaxExposure)))
+
+		return brighter, math.Min(f.Options.MaxExposure, level/float64(brighter)), nil
+	}
+
+	return brightness, next, nil
+}
+
+/*
+calibrate()
+
+Takes test exposures, starting from the given brightness and exposure duration and adjusting both,
+until the median ADU is within tolerance of the target.
+
+@returns the brightness, exposure duration and median found.
+*/
+func (f *Flats) calibrate(ctx context.Context, brightness int32, maxBrightness int32, exposure float64, target float64, saturation float64) (int32, float64, float64, error) {
+	lit := int32(-1)
+
+	for i := 0; i < f.Options.MaxIterations; i++ {
+		if brightness != lit {
+			if err := f.setBrightness(ctx, brightness); err != nil {
+				return brightness, exposure, 0, err
+			}
+
+			lit = brightness
+		}
+
+		image, err := Capture(ctx, f.Camera, exposure, true, f.Options.PollInterval)
+
+		if err != nil {
+			return brightness, exposure, 0, err
+		}
+
+		median, _ := GetImageBackground(image)
+
+		if math.Abs(median-target) <= f.Options.Tolerance*target {
+			return brightness, exposure, median, nil
+		}
+
+		if brightness, exposure, err = f.getNextExposure(brightness, maxBrightness, exposure, median, target, saturation); err != nil {
+			return brightness, exposure, median, err
+		}
+	}
+
+	return brightness, exposure, 0, fmt.Errorf("the flat exposure did not converge within %d test exposures", f.Options.MaxIterations)
+}
+
+/*
+Run()
+
+Closes the cover, then for each filter finds the calibrator brightness and exposure duration giving
+the target median ADU and captures the configured number of flats. The calibrator is always turned
+off and the cover reopened afterwards. Each filter starts from the brightness and exposure duration found
+for the previous one.
+
+@param filters []string (the filter names, or a single empty name to use the current filter)
+@returns the settings used for each filter.
+*/
+func (f *Flats) Run(ctx context.Context, filters []string) (results []FlatsResult, err error) {
+	results = []FlatsResult{}
+
+	maxADU, err := f.Camera.GetMaxADU()
+
+	if err != nil {
+		return results, err
+	}
+
+	maxBrightness, err := f.Calibrator.GetMaxBrightness()
+
+	if err != nil {
+		return results, err
+	}
+
+	if maxBrightness <= 0 {
+		return results, fmt.Errorf("the calibrator reports a maximum brightness of %d, so cannot be used for flats", maxBrightness)
+	}
+
+	target := f.Options.TargetFraction * float64(maxADU)
+
+	brightness, exposure := f.Options.Brightness, f.Options.InitialExposure
+
+	if brightness <= 0 || brightness > maxBrightness {
+		brightness = maxBrightness
+	}
+
+	covered, err := f.closeCover(ctx)
+
+	defer func() {
+		err = errors.Join(err, f.Calibrator.SetCalibratorOff())
+
+		if covered {
+			err = errors.Join(err, f.Calibrator.OpenCover())
+		}
+	}()
+
+	if err != nil {
+		return results, err
+	}
+
+	for _, filter := range filters {
+		if f.Filters != nil && filter != "" {
+			if _, err := f.Filters.ChangeFilter(ctx, filter); err != nil {
+				return results, err
+			}
+		}
+
+		result := FlatsResult{Filter: filter}
+
+		result.Brightness, result.Exposure, result.Median, err = f.calibrate(ctx, brightness, maxBrightness, exposure, target, float64(maxADU))
+
+		if err != nil {
+			return results, fmt.Errorf("filter %q: %w", filter, err)
+		}
+
+		brightness, exposure = result.Brightness, result.Exposure
+
+		for i := 0; i < f.Options.Count; i++ {
+			image, err := Capture(ctx, f.Camera, result.Exposure, true, f.Options.PollInterval)
+
+			if err != nil {
+				return results, err
+			}
+
+			if f.OnFrame != nil {
+				if err := f.OnFrame(filter, i, image); err != nil {
+					return results, err
+				}
+			}
+
+			result.Frames++
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
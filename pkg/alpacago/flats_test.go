@@ -0,0 +1,209 @@
+package alpacago
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+/*
+simulatedFlatPanel is a cover calibrator whose illumination, through the simulated camera,
+gives a signal proportional to brightness × exposure.
+*/
+type simulatedFlatPanel struct {
+	cover      CoverState
+	brightness int32
+	on         bool
+	opened     bool
+}
+
+func (p *simulatedFlatPanel) CloseCover() error {
+	p.cover = CoverClosed
+	return nil
+}
+
+func (p *simulatedFlatPanel) OpenCover() error {
+	p.cover = CoverOpen
+	p.opened = true
+	return nil
+}
+
+func (p *simulatedFlatPanel) GetCoverStatus() (string, error) { return p.cover.String(), nil }
+
+func (p *simulatedFlatPanel) GetStatus() (CalibratorState, error) {
+	if p.on {
+		return CalibratorReady, nil
+	}
+
+	return CalibratorOff, nil
+}
+
+func (p *simulatedFlatPanel) GetMaxBrightness() (int32, error) { return 255, nil }
+
+func (p *simulatedFlatPanel) SetCalibratorOn(brightness int32) error {
+	p.brightness, p.on = brightness, true
+	return nil
+}
+
+func (p *simulatedFlatPanel) SetCalibratorOff() error {
+	p.brightness, p.on = 0, false
+	return nil
+}
+
+type simulatedFlatCamera struct {
+	panel *simulatedFlatPanel
+	// The signal (ADU) per unit brightness per second
+	rate      float64
+	duration  float64
+	exposures []float64
+}
+
+func (c *simulatedFlatCamera) StartExposure(duration float64, light bool) error {
+	c.duration = duration
+	c.exposures = append(c.exposures, duration)
+	return nil
+}
+
+func (c *simulatedFlatCamera) IsImageReady() (bool, error) { return true, nil }
+
+func (c *simulatedFlatCamera) GetExposure() ([][]uint32, uint32, error) {
+	v := 100 + float64(c.panel.brightness)*c.duration*c.rate
+
+	image := make([][]uint32, 16)
+
+	for x := range image {
+		image[x] = make([]uint32, 16)
+
+		for y := range image[x] {
+			image[x][y] = uint32(math.Min(65535, v))
+		}
+	}
+
+	return image, 2, nil
+}
+
+func (c *simulatedFlatCamera) GetMaxADU() (int32, error) { return 65535, nil }
+
+func newTestFlatsOptions() FlatsOptions {
+	options := NewFlatsOptions()
+
+	options.Count = 3
+
+	options.PollInterval = time.Millisecond
+
+	return options
+}
+
+func TestFlatsRun(t *testing.T) {
+	panel := &simulatedFlatPanel{cover: CoverOpen}
+
+	camera := &simulatedFlatCamera{panel: panel, rate: 20}
+
+	frames := 0
+
+	flats := NewFlats(camera, panel, newTestFlatsOptions())
+
+	flats.OnFrame = func(filter string, index int, image [][]uint32) error {
+		frames++
+		return nil
+	}
+
+	results, err := flats.Run(context.Background(), []string{""})
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(results) != 1 || frames != 3 {
+		t.Fatalf("got %d results and %d frames, wanted 1 and 3", len(results), frames)
+	}
+
+	if math.Abs(results[0].Median-32767.5) > 0.1*32767.5 {
+		t.Errorf("got %f, wanted a median within 10%% of %f", results[0].Median, 32767.5)
+	}
+
+	if panel.on || !panel.opened {
+		t.Errorf("got the calibrator on %t and cover opened %t, wanted off and opened", panel.on, panel.opened)
+	}
+}
+
+func TestFlatsRunDimsCalibrator(t *testing.T) {
+	panel := &simulatedFlatPanel{cover: CoverOpen}
+
+	// At full brightness the panel saturates the sensor within the minimum exposure:
+	camera := &simulatedFlatCamera{panel: panel, rate: 20000}
+
+	results, err := NewFlats(camera, panel, newTestFlatsOptions()).Run(context.Background(), []string{""})
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if results[0].Brightness >= 255 || results[0].Exposure < 0.1 {
+		t.Errorf("got a brightness of %d at %fs, wanted the calibrator dimmed", results[0].Brightness, results[0].Exposure)
+	}
+}
+
+func TestFlatsRunTooDim(t *testing.T) {
+	panel := &simulatedFlatPanel{cover: CoverOpen}
+
+	camera := &simulatedFlatCamera{panel: panel, rate: 0.01}
+
+	if _, err := NewFlats(camera, panel, newTestFlatsOptions()).Run(context.Background(), []string{""}); err == nil {
+		t.Errorf("got nil, wanted an error for a calibrator too dim for the maximum exposure")
+	}
+
+	if panel.on || !panel.opened {
+		t.Errorf("got the calibrator on %t and cover opened %t, wanted off and opened", panel.on, panel.opened)
+	}
+}
+
+func TestFlatsRunCarriesSettingsOver(t *testing.T) {
+	panel := &simulatedFlatPanel{cover: CoverOpen}
+
+	camera := &simulatedFlatCamera{panel: panel, rate: 20}
+
+	if _, err := NewFlats(camera, panel, newTestFlatsOptions()).Run(context.Background(), []string{""}); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	single := len(camera.exposures)
+
+	camera.exposures = nil
+
+	results, err := NewFlats(camera, panel, newTestFlatsOptions()).Run(context.Background(), []string{"", ""})
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	// The second filter converges with a single test exposure, at the first filter's settings:
+	var got int = len(camera.exposures)
+
+	var want int = single + 1 + 3
+
+	if got != want || results[1].Exposure != results[0].Exposure {
+		t.Errorf("got %d exposures, wanted %d", got, want)
+	}
+}
+
+type unlitFlatPanel struct {
+	*simulatedFlatPanel
+}
+
+func (p unlitFlatPanel) GetMaxBrightness() (int32, error) { return 0, nil }
+
+func TestFlatsRunWithoutBrightness(t *testing.T) {
+	panel := unlitFlatPanel{&simulatedFlatPanel{cover: CoverOpen}}
+
+	camera := &simulatedFlatCamera{panel: panel.simulatedFlatPanel, rate: 20}
+
+	if _, err := NewFlats(camera, panel, newTestFlatsOptions()).Run(context.Background(), []string{""}); err == nil {
+		t.Errorf("got nil, wanted an error for a maximum brightness of 0")
+	}
+
+	if len(camera.exposures) != 0 {
+		t.Errorf("got %d exposures, wanted 0", len(camera.exposures))
+	}
+}
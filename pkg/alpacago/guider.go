@@ -0,0 +1,613 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+/*
+PulseGuider issues guide pulses, either through a camera's ST-4 port (Camera.SetPulseGuide) or directly
+to the mount (Telescope.SetPulseGuide).
+*/
+type PulseGuider interface {
+	SetPulseGuide(direction Direction, duration int32) error
+	IsPulseGuiding() (bool, error)
+}
+
+var ErrGuideStarLost = errors.New("the guide star was lost")
+
+/*
+GuideCalibration relates guide pulses to the motion of the guide star on the guide camera.
+*/
+type GuideCalibration struct {
+	// The direction the star moves on the guide frame (degrees, from the x axis towards the y axis) when
+	// pulsing West, and the rate (pixels per second of pulse) at which it moves
+	RAAngle float64
+	RARate  float64
+	// The direction the star moves on the guide frame when pulsing North, and the rate at which it moves
+	DecAngle float64
+	DecRate  float64
+}
+
+/*
+getAxisErrors()
+
+Resolves a displacement on the guide frame into the (not necessarily perpendicular) right ascension
+and declination axes.
+
+@returns the displacement (pixels) along the West and North pulse directions.
+*/
+func (c GuideCalibration) getAxisErrors(dx float64, dy float64) (float64, float64) {
+	ux, uy := math.Cos(c.RAAngle*degreesToRadians), math.Sin(c.RAAngle*degreesToRadians)
+
+	vx, vy := math.Cos(c.DecAngle*degreesToRadians), math.Sin(c.DecAngle*degreesToRadians)
+
+	det := ux*vy - uy*vx
+
+	return (dx*vy - dy*vx) / det, (ux*dy - uy*dx) / det
+}
+
+type GuideStep struct {
+	Time time.Time
+	Star Star
+	// The displacement (pixels) of the star from the lock position, on the guide frame
+	DX float64
+	DY float64
+	// The displacement (pixels) along the West and North pulse directions
+	RAError  float64
+	DecError float64
+	// The corrections issued (milliseconds, zero for none)
+	RADirection  Direction
+	RAPulse      int32
+	DecDirection Direction
+	DecPulse     int32
+	// Whether the guider is settling after a dither; such steps are excluded from the statistics
+	Settling bool
+}
+
+/*
+GuideStats are the guiding errors, in pixels or, where the Guider's PixelScale is set, arcseconds.
+*/
+type GuideStats struct {
+	Count    int
+	RARMS    float64
+	DecRMS   float64
+	TotalRMS float64
+	RAPeak   float64
+	DecPeak  float64
+}
+
+type Guider struct {
+	Camera ExposureCamera
+	Mount  PulseGuider
+	// The guide exposure duration (seconds)
+	Exposure    float64
+	Calibration *GuideCalibration
+	// The duration (milliseconds) and number of the pulses issued along each axis whilst calibrating
+	CalibrationPulse int32
+	CalibrationSteps int
+	// The minimum distance (pixels) the star must move along each axis for the calibration to succeed
+	MinCalibrationDistance float64
+	// The fraction of each error corrected
+	RAAggressiveness  float64
+	DecAggressiveness float64
+	// The weight given to the previous correction, smoothing out seeing
+	Hysteresis float64
+	// Errors (pixels) below which no correction is issued
+	MinMove float64
+	// The longest guide pulse (milliseconds) issued
+	MaxPulse int32
+	// The radius (pixels) about its last position within which the guide star is searched for
+	SearchRadius int
+	// The number of consecutive frames the star may be lost for before Run() fails
+	MaxLostFrames int
+	StarOptions   StarDetectionOptions
+	// The guide camera image scale (arcseconds per pixel), if statistics are wanted in arcseconds (optional)
+	PixelScale   float64
+	PollInterval time.Duration
+	// Called after each guide frame
+	OnStep func(step GuideStep)
+	mu     sync.Mutex
+	locked bool
+	// The lock position, and the last measured position of the guide star
+	lockX, lockY float64
+	starX, starY float64
+	// The previous corrections (pixels), for the hysteresis
+	lastRA, lastDec float64
+	steps           []GuideStep
+	// The settling criteria of a dither in progress
+	settling        bool
+	settleTolerance float64
+	settleFrames    int
+	settled         int
+}
+
+func NewGuider(camera ExposureCamera, mount PulseGuider) *Guider {
+	options := NewStarDetectionOptions()
+
+	options.Radius = 6
+
+	guider := Guider{
+		Camera:                 camera,
+		Mount:                  mount,
+		Exposure:               2,
+		CalibrationPulse:       1000,
+		CalibrationSteps:       8,
+		MinCalibrationDistance: 5,
+		RAAggressiveness:       0.7,
+		DecAggressiveness:      0.7,
+		Hysteresis:             0.1,
+		MinMove:                0.15,
+		MaxPulse:               2500,
+		SearchRadius:           15,
+		MaxLostFrames:          5,
+		StarOptions:            options,
+		PollInterval:           100 * time.Millisecond,
+	}
+
+	return &guider
+}
+
+/*
+SelectGuideStar()
+
+@returns the brightest unsaturated star clear of the edges of the frame, or an error if there is none.
+*/
+func (g *Guider) SelectGuideStar(image [][]uint32) (Star, error) {
+	margin := float64(g.SearchRadius + g.StarOptions.Radius)
+
+	for _, star := range DetectStars(image, g.StarOptions) {
+		if star.X < margin || star.Y < margin || star.X > float64(len(image))-1-margin || star.Y > float64(len(image[0]))-1-margin {
+			continue
+		}
+
+		return star, nil
+	}
+
+	return Star{}, errors.New("no suitable guide star was found")
+}
+
+/*
+findStar()
+
+Centroids the brightest star within the SearchRadius of the given position.
+
+@returns the star, or false if no star stands out of the background within the search box.
+*/
+func (g *Guider) findStar(image [][]uint32, x float64, y float64) (Star, bool) {
+	width := len(image)
+
+	if width == 0 {
+		return Star{}, false
+	}
+
+	height := len(image[0])
+
+	background, noise := GetImageBackground(image)
+
+	noise = math.Max(noise, 1)
+
+	cx, cy := int(math.Round(x)), int(math.Round(y))
+
+	mx, my, peak := -1, -1, background+g.StarOptions.Sigma*noise
+
+	for i := max(cx-g.SearchRadius, 0); i <= min(cx+g.SearchRadius, width-1); i++ {
+		for j := max(cy-g.SearchRadius, 0); j <= min(cy+g.SearchRadius, height-1); j++ {
+			if v := float64(image[i][j]); v > peak {
+				mx, my, peak = i, j, v
+			}
+		}
+	}
+
+	if mx < 0 {
+		return Star{}, false
+	}
+
+	return measureStar(image, mx, my, g.StarOptions.Radius, background)
+}
+
+/*
+pulse()
+
+Issues a guide pulse, and waits for it to complete (for drivers whose pulses are asynchronous).
+*/
+func (g *Guider) pulse(ctx context.Context, direction Direction, duration int32) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	if err := g.Mount.SetPulseGuide(direction, duration); err != nil {
+		return err
+	}
+
+	return WaitWhileMoving(ctx, g.PollInterval, g.Mount.IsPulseGuiding)
+}
+
+/*
+track()
+
+Pulses repeatedly in one direction, following the guide star from the given position.
+
+@returns the final position of the guide star.
+*/
+func (g *Guider) track(ctx context.Context, direction Direction, x float64, y float64) (float64, float64, error) {
+	for i := 0; i < g.CalibrationSteps; i++ {
+		if err := g.pulse(ctx, direction, g.CalibrationPulse); err != nil {
+			return x, y, err
+		}
+
+		image, err := Capture(ctx, g.Camera, g.Exposure, true, g.PollInterval)
+
+		if err != nil {
+			return x, y, err
+		}
+
+		star, ok := g.findStar(image, x, y)
+
+		if !ok {
+			return x, y, ErrGuideStarLost
+		}
+
+		x, y = star.X, star.Y
+	}
+
+	return x, y, nil
+}
+
+/*
+Calibrate()
+
+Selects a guide star, then pulses West and back East, and North and back South, measuring the direction
+and rate of the star's motion along each axis. The guide star is then locked at its final position.
+*/
+func (g *Guider) Calibrate(ctx context.Context) (GuideCalibration, error) {
+	calibration := GuideCalibration{}
+
+	if g.CalibrationSteps < 1 || g.CalibrationPulse <= 0 {
+		return calibration, errors.New("the calibration requires at least one pulse of a positive duration")
+	}
+
+	image, err := Capture(ctx, g.Camera, g.Exposure, true, g.PollInterval)
+
+	if err != nil {
+		return calibration, err
+	}
+
+	star, err := g.SelectGuideStar(image)
+
+	if err != nil {
+		return calibration, err
+	}
+
+	seconds := float64(g.CalibrationSteps) * float64(g.CalibrationPulse) / 1000
+
+	axes := []struct {
+		forward, back Direction
+		angle, rate   *float64
+	}{
+		{West, East, &calibration.RAAngle, &calibration.RARate},
+		{North, South, &calibration.DecAngle, &calibration.DecRate},
+	}
+
+	x, y := star.X, star.Y
+
+	for _, axis := range axes {
+		ex, ey, err := g.track(ctx, axis.forward, x, y)
+
+		if err != nil {
+			return calibration, err
+		}
+
+		distance := math.Hypot(ex-x, ey-y)
+
+		if distance < g.MinCalibrationDistance {
+			return calibration, fmt.Errorf("the guide star moved %.1f pixels during calibration, wanted at least %.1f", distance, g.MinCalibrationDistance)
+		}
+
+		*axis.angle = math.Atan2(ey-y, ex-x) / degreesToRadians
+		*axis.rate = distance / seconds
+
+		if x, y, err = g.track(ctx, axis.back, ex, ey); err != nil {
+			return calibration, err
+		}
+	}
+
+	// The axes needn't be perpendicular (e.g., with a poor polar alignment), but must not be near parallel:
+	if math.Abs(math.Sin((calibration.RAAngle-calibration.DecAngle)*degreesToRadians)) < 0.5 {
+		return calibration, fmt.Errorf("the calibrated axes at %.1f° and %.1f° are too far from perpendicular", calibration.RAAngle, calibration.DecAngle)
+	}
+
+	g.mu.Lock()
+
+	g.Calibration = &calibration
+	g.lock(x, y)
+
+	g.mu.Unlock()
+
+	return calibration, nil
+}
+
+/*
+lock()
+
+Locks the guider onto the star at the given position, the caller holding the mutex.
+*/
+func (g *Guider) lock(x float64, y float64) {
+	g.locked = true
+	g.lockX, g.lockY = x, y
+	g.starX, g.starY = x, y
+	g.lastRA, g.lastDec = 0, 0
+}
+
+/*
+getCorrection()
+
+@returns the direction and duration (milliseconds) of the pulse correcting the given error (pixels), and the
+correction (pixels) after hysteresis, which is fed back into the next correction.
+*/
+func (g *Guider) getCorrection(offset float64, last float64, aggressiveness float64, rate float64, forward Direction, back Direction) (Direction, int32, float64) {
+	correction := (1-g.Hysteresis)*offset + g.Hysteresis*last
+
+	if math.Abs(offset) < g.MinMove || rate <= 0 {
+		return forward, 0, correction
+	}
+
+	duration := math.Min(math.Abs(correction*aggressiveness)/rate*1000, float64(g.MaxPulse))
+
+	// A star displaced in the direction a forward pulse moves it is returned by a pulse the other way:
+	if correction > 0 {
+		return back, int32(math.Round(duration)), correction
+	}
+
+	return forward, int32(math.Round(duration)), correction
+}
+
+/*
+Step()
+
+Takes a guide frame and issues the corrections for the error in the guide star's position. The first frame
+after a Reset() only locks onto a guide star.
+
+@returns the guide step, or an error wrapping ErrGuideStarLost if the guide star cannot be found.
+*/
+func (g *Guider) Step(ctx context.Context) (GuideStep, error) {
+	step := GuideStep{}
+
+	image, err := Capture(ctx, g.Camera, g.Exposure, true, g.PollInterval)
+
+	if err != nil {
+		return step, err
+	}
+
+	step.Time = time.Now()
+
+	g.mu.Lock()
+
+	if g.Calibration == nil {
+		g.mu.Unlock()
+		return step, errors.New("the guider must be calibrated before guiding")
+	}
+
+	if !g.locked {
+		star, err := g.SelectGuideStar(image)
+
+		if err == nil {
+			g.lock(star.X, star.Y)
+			step.Star = star
+		}
+
+		g.mu.Unlock()
+
+		return step, err
+	}
+
+	star, ok := g.findStar(image, g.starX, g.starY)
+
+	if !ok {
+		g.mu.Unlock()
+		return step, fmt.Errorf("%w near (%.1f, %.1f)", ErrGuideStarLost, g.starX, g.starY)
+	}
+
+	calibration := *g.Calibration
+
+	g.starX, g.starY = star.X, star.Y
+
+	step.Star = star
+	step.DX, step.DY = star.X-g.lockX, star.Y-g.lockY
+	step.RAError, step.DecError = calibration.getAxisErrors(step.DX, step.DY)
+
+	step.RADirection, step.RAPulse, g.lastRA = g.getCorrection(step.RAError, g.lastRA, g.RAAggressiveness, calibration.RARate, West, East)
+	step.DecDirection, step.DecPulse, g.lastDec = g.getCorrection(step.DecError, g.lastDec, g.DecAggressiveness, calibration.DecRate, North, South)
+
+	if g.settling {
+		if math.Hypot(step.DX, step.DY) <= g.settleTolerance {
+			g.settled++
+		} else {
+			g.settled = 0
+		}
+
+		g.settling = g.settled < g.settleFrames
+		step.Settling = true
+	}
+
+	g.steps = append(g.steps, step)
+
+	g.mu.Unlock()
+
+	if g.OnStep != nil {
+		g.OnStep(step)
+	}
+
+	if err := g.pulse(ctx, step.RADirection, step.RAPulse); err != nil {
+		return step, err
+	}
+
+	return step, g.pulse(ctx, step.DecDirection, step.DecPulse)
+}
+
+/*
+Run()
+
+Calibrates the guider if needed, then guides until the context is done, or the guide star has been lost
+for MaxLostFrames consecutive frames.
+*/
+func (g *Guider) Run(ctx context.Context) error {
+	g.mu.Lock()
+
+	calibrated := g.Calibration != nil
+
+	g.mu.Unlock()
+
+	if !calibrated {
+		if _, err := g.Calibrate(ctx); err != nil {
+			return err
+		}
+	}
+
+	lost := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		_, err := g.Step(ctx)
+
+		switch {
+		case errors.Is(err, ErrGuideStarLost):
+			lost++
+
+			if lost >= g.MaxLostFrames {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			lost = 0
+		}
+	}
+}
+
+/*
+Reset()
+
+Releases the guide star, so that the next Step() selects and locks onto a new one.
+*/
+func (g *Guider) Reset() {
+	g.mu.Lock()
+
+	defer g.mu.Unlock()
+
+	g.locked = false
+	g.settling = false
+}
+
+/*
+Dither()
+
+Moves the lock position by a random offset of up to the given amount (pixels) along each axis, then waits
+until the guider (running in Run()) has held the star within the tolerance (pixels) for the given number
+of consecutive frames.
+*/
+func (g *Guider) Dither(ctx context.Context, amount float64, tolerance float64, frames int) error {
+	g.mu.Lock()
+
+	if !g.locked {
+		g.mu.Unlock()
+		return errors.New("the guider must be locked onto a guide star to dither")
+	}
+
+	g.lockX += (2*rand.Float64() - 1) * amount
+	g.lockY += (2*rand.Float64() - 1) * amount
+
+	g.settling = true
+	g.settleTolerance = tolerance
+	g.settleFrames = frames
+	g.settled = 0
+
+	g.mu.Unlock()
+
+	return WaitUntil(ctx, g.PollInterval, func() (bool, error) {
+		g.mu.Lock()
+
+		defer g.mu.Unlock()
+
+		return !g.settling, nil
+	})
+}
+
+/*
+GetLockPosition()
+
+@returns the lock position (pixels) on the guide frame, and whether the guider is locked onto a star.
+*/
+func (g *Guider) GetLockPosition() (float64, float64, bool) {
+	g.mu.Lock()
+
+	defer g.mu.Unlock()
+
+	return g.lockX, g.lockY, g.locked
+}
+
+/*
+GetStats()
+
+@returns the RMS and peak guiding errors since the last ResetStats(), excluding frames settling after a dither.
+*/
+func (g *Guider) GetStats() GuideStats {
+	g.mu.Lock()
+
+	defer g.mu.Unlock()
+
+	stats := GuideStats{}
+
+	scale := 1.0
+
+	if g.PixelScale > 0 {
+		scale = g.PixelScale
+	}
+
+	var ra2, dec2 float64
+
+	for _, step := range g.steps {
+		if step.Settling {
+			continue
+		}
+
+		ra, dec := step.RAError*scale, step.DecError*scale
+
+		stats.Count++
+
+		ra2 += ra * ra
+		dec2 += dec * dec
+
+		stats.RAPeak = math.Max(stats.RAPeak, math.Abs(ra))
+		stats.DecPeak = math.Max(stats.DecPeak, math.Abs(dec))
+	}
+
+	if stats.Count > 0 {
+		stats.RARMS = math.Sqrt(ra2 / float64(stats.Count))
+		stats.DecRMS = math.Sqrt(dec2 / float64(stats.Count))
+		stats.TotalRMS = math.Hypot(stats.RARMS, stats.DecRMS)
+	}
+
+	return stats
+}
+
+/*
+ResetStats()
+
+Discards the guide steps recorded for the statistics.
+*/
+func (g *Guider) ResetStats() {
+	g.mu.Lock()
+
+	defer g.mu.Unlock()
+
+	g.steps = nil
+}
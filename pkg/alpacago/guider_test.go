@@ -0,0 +1,201 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+/*
+simulatedGuideCamera is a guide camera on a mount, through whose ST-4 port guide pulses are issued. The
+star field drifts by a fixed amount each frame, and pulses move it along axes rotated on the frame.
+*/
+type simulatedGuideCamera struct {
+	// The offset (pixels) of the star field, and its drift per frame
+	x, y           float64
+	driftX, driftY float64
+	// The directions (degrees) the stars move when pulsing West and North, and the rate (pixels per second)
+	raAngle, decAngle float64
+	rate              float64
+	pulses            int
+}
+
+func (c *simulatedGuideCamera) StartExposure(duration float64, light bool) error {
+	c.x += c.driftX
+	c.y += c.driftY
+	return nil
+}
+
+func (c *simulatedGuideCamera) IsImageReady() (bool, error) { return true, nil }
+
+func (c *simulatedGuideCamera) GetExposure() ([][]uint32, uint32, error) {
+	stars := []syntheticStar{{x: 30 + c.x, y: 32 + c.y, flux: 20000}, {x: 50 + c.x, y: 12 + c.y, flux: 5000}}
+
+	return renderStarField(64, 64, 500, 1.5, stars), 2, nil
+}
+
+func (c *simulatedGuideCamera) SetPulseGuide(direction Direction, duration int32) error {
+	angle, sign := c.raAngle, 1.0
+
+	switch direction {
+	case East:
+		sign = -1
+	case North:
+		angle = c.decAngle
+	case South:
+		angle, sign = c.decAngle, -1
+	}
+
+	distance := sign * c.rate * float64(duration) / 1000
+
+	c.x += distance * math.Cos(angle*degreesToRadians)
+	c.y += distance * math.Sin(angle*degreesToRadians)
+
+	c.pulses++
+
+	return nil
+}
+
+func (c *simulatedGuideCamera) IsPulseGuiding() (bool, error) { return false, nil }
+
+func newTestGuider(camera *simulatedGuideCamera) *Guider {
+	guider := NewGuider(camera, camera)
+
+	guider.PollInterval = time.Millisecond
+
+	return guider
+}
+
+func TestGuiderPulseGuiders(t *testing.T) {
+	// Guide pulses may be issued through the camera's ST-4 port, or to the mount:
+	var _ PulseGuider = &Camera{}
+	var _ PulseGuider = &Telescope{}
+}
+
+func TestGuiderCalibrate(t *testing.T) {
+	camera := &simulatedGuideCamera{raAngle: 30, decAngle: -60, rate: 1}
+
+	guider := newTestGuider(camera)
+
+	calibration, err := guider.Calibrate(context.Background())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(calibration.RAAngle-30) > 1 || math.Abs(calibration.DecAngle+60) > 1 {
+		t.Errorf("got axes at %f° and %f°, wanted 30° and -60°", calibration.RAAngle, calibration.DecAngle)
+	}
+
+	if math.Abs(calibration.RARate-1) > 0.05 || math.Abs(calibration.DecRate-1) > 0.05 {
+		t.Errorf("got rates of %f and %f, wanted 1 pixel per second", calibration.RARate, calibration.DecRate)
+	}
+
+	// The star is returned to where it started, and locked there:
+	x, y, locked := guider.GetLockPosition()
+
+	if !locked || math.Hypot(x-30, y-32) > 0.1 {
+		t.Errorf("got a lock at (%f, %f), wanted (30, 32)", x, y)
+	}
+
+	if camera.pulses != 4*guider.CalibrationSteps {
+		t.Errorf("got %d pulses, wanted %d", camera.pulses, 4*guider.CalibrationSteps)
+	}
+}
+
+func TestGuiderCalibrateRequiresMotion(t *testing.T) {
+	camera := &simulatedGuideCamera{raAngle: 0, decAngle: 90, rate: 0.1}
+
+	if _, err := newTestGuider(camera).Calibrate(context.Background()); err == nil {
+		t.Errorf("got nil, wanted an error when the star barely moves")
+	}
+}
+
+func TestGuiderCorrectsDrift(t *testing.T) {
+	camera := &simulatedGuideCamera{raAngle: 120, decAngle: 30, rate: 2}
+
+	guider := newTestGuider(camera)
+
+	guider.PixelScale = 2
+
+	if _, err := guider.Calibrate(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	camera.driftX, camera.driftY = 0.3, -0.2
+
+	steps := []GuideStep{}
+
+	guider.OnStep = func(step GuideStep) { steps = append(steps, step) }
+
+	for i := 0; i < 40; i++ {
+		if _, err := guider.Step(context.Background()); err != nil {
+			t.Fatalf("got %q, wanted nil", err)
+		}
+	}
+
+	// Unguided, the star would have drifted 14 pixels; guided, it stays within a fraction of a pixel:
+	stats := guider.GetStats()
+
+	if stats.Count != 40 || stats.TotalRMS > 2*0.6 {
+		t.Errorf("got %+v, wanted a total RMS of under 1.2\"", stats)
+	}
+
+	if last := steps[len(steps)-1]; math.Hypot(last.DX, last.DY) > 0.6 {
+		t.Errorf("got a final error of (%f, %f), wanted the star held at the lock position", last.DX, last.DY)
+	}
+
+	guider.ResetStats()
+
+	if got := guider.GetStats(); got.Count != 0 {
+		t.Errorf("got %d steps, wanted none after a reset", got.Count)
+	}
+}
+
+func TestGuiderDither(t *testing.T) {
+	camera := &simulatedGuideCamera{raAngle: 0, decAngle: 90, rate: 2}
+
+	guider := newTestGuider(camera)
+
+	if _, err := guider.Calibrate(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	x, y, _ := guider.GetLockPosition()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+
+	go func() { done <- guider.Run(ctx) }()
+
+	timeout, stop := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer stop()
+
+	if err := guider.Dither(timeout, 3, 0.5, 2); err != nil {
+		t.Errorf("got %q, wanted the guider to settle", err)
+	}
+
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, wanted %q", err, context.Canceled)
+	}
+
+	dx, dy, _ := guider.GetLockPosition()
+
+	if math.Abs(dx-x) > 3 || math.Abs(dy-y) > 3 || (dx == x && dy == y) {
+		t.Errorf("got a lock at (%f, %f), wanted it moved by up to 3 pixels from (%f, %f)", dx, dy, x, y)
+	}
+
+	for _, step := range guider.steps {
+		if step.Settling {
+			return
+		}
+	}
+
+	t.Errorf("got no settling steps, wanted the dither to be settled")
+}
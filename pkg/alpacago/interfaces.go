@@ -0,0 +1,328 @@
+package alpacago
+
+import (
+	"context"
+	"time"
+)
+
+/*
+The device APIs are the methods of each device, as interfaces, so that code built on the devices accepts
+a mock (see the mocks package) in unit tests, or an alternate, non-Alpaca, backend.
+
+CameraAPI is satisfied by *Camera, and by mocks.Camera.
+*/
+type CameraAPI interface {
+	AbortExposure() error
+	CanAbortExposure() (bool, error)
+	CanAsymmetricBin() (bool, error)
+	CanFastReadout() (bool, error)
+	CanGetCoolerPower() (bool, error)
+	CanPulseGuide() (bool, error)
+	CanSetCCDTemperature() (bool, error)
+	CanStopExposure() (bool, error)
+	Diagnose(ctx context.Context) DiagnosticReport
+	DisableFastReadout() error
+	EnableFastReadout() error
+	GetBayerOffsetX() (int32, error)
+	GetBayerOffsetY() (int32, error)
+	GetBinX() (int32, error)
+	GetBinY() (int32, error)
+	GetCCDSizeX() (int32, error)
+	GetCCDSizeY() (int32, error)
+	GetCCDTemperature() (float64, error)
+	GetCCDTemperatureCoolerSetPoint() (float64, error)
+	GetCoolerPowerLevel() (float64, error)
+	GetCurrentOperationPercentageComplete() (int32, error)
+	GetExposure() ([][]uint32, uint32, error)
+	GetExposureMax() (float64, error)
+	GetExposureMin() (float64, error)
+	GetExposureResolution() (float64, error)
+	GetFullWellCapacity() (float64, error)
+	GetGain() (int32, error)
+	GetGainInElectronsPerADUnit() (float64, error)
+	GetGainMax() (int32, error)
+	GetGainMin() (int32, error)
+	GetGains() ([]string, error)
+	GetHeatSinkTemperature() (float64, error)
+	GetLastExposureDuration() (float64, error)
+	GetLastExposureStartTime() (*time.Time, error)
+	GetMaxADU() (int32, error)
+	GetMaxBinX() (int32, error)
+	GetMaxBinY() (int32, error)
+	GetOffset() (int32, error)
+	GetOperationalState() (string, error)
+	GetPixelSizeX() (float64, error)
+	GetPixelSizeY() (float64, error)
+	GetReadOutMode() (int32, error)
+	GetReadOutModes() ([]string, error)
+	GetSensorName() (string, error)
+	GetSensorType() (SensorType, error)
+	GetStartX() (int32, error)
+	GetStartY() (int32, error)
+	GetSubExposureDuration() (float64, error)
+	GetSubFrameHeight() (int32, error)
+	GetSubFrameWidth() (int32, error)
+	HasShutter() (bool, error)
+	IsConnected() (bool, error)
+	IsCoolerOn() (bool, error)
+	IsFastReadoutEnabled() (bool, error)
+	IsImageReady() (bool, error)
+	IsPulseGuiding() (bool, error)
+	SetBinX(binX int32) error
+	SetBinY(binY int32) error
+	SetCCDTemperatureCoolerSetPoint(temperature float64) error
+	SetConnected(connected bool) error
+	SetGain(gain int32) error
+	SetOffset(offset int32) error
+	SetPulseGuide(direction Direction, duration int32) error
+	SetReadOutMode(readOutMode int32) error
+	SetStartX(startX int32) error
+	SetStartY(startY int32) error
+	SetSubExposureDuration(subExposureDuration float64) error
+	SetSubFrameHeight(numY int32) error
+	SetSubFrameWidth(numX int32) error
+	StartExposure(duration float64, light bool) error
+	StopExposure() error
+	TurnCoolerOff() error
+	TurnCoolerOn() error
+}
+
+/*
+CoverCalibratorAPI is satisfied by *CoverCalibrator, and by mocks.CoverCalibrator.
+*/
+type CoverCalibratorAPI interface {
+	CloseCover() error
+	Diagnose(ctx context.Context) DiagnosticReport
+	GetBrightness() (float64, error)
+	GetCoverStatus() (string, error)
+	GetMaxBrightness() (int32, error)
+	GetStatus() (CalibratorState, error)
+	HaltCover() error
+	IsConnected() (bool, error)
+	OpenCover() error
+	SetCalibratorOff() error
+	SetCalibratorOn(brightness int32) error
+	SetConnected(connected bool) error
+}
+
+/*
+DomeAPI is satisfied by *Dome, and by mocks.Dome.
+*/
+type DomeAPI interface {
+	AbortSlew() error
+	CanFindHome() (bool, error)
+	CanPark() (bool, error)
+	CanSetAltitude() (bool, error)
+	CanSetAzimuth() (bool, error)
+	CanSetPark() (bool, error)
+	CanSetShutter() (bool, error)
+	CanSlave() (bool, error)
+	CanSyncAzimuth() (bool, error)
+	CloseShutter() error
+	Diagnose(ctx context.Context) DiagnosticReport
+	FindHome() error
+	GetAltitude() (float64, error)
+	GetAzimuth() (float64, error)
+	GetShutterStatus() (string, error)
+	IsAtHome() (bool, error)
+	IsAtPark() (bool, error)
+	IsConnected() (bool, error)
+	IsSlaved() (bool, error)
+	IsSlewing() (bool, error)
+	OpenShutter() error
+	Park() error
+	SetAsPark() error
+	SetConnected(connected bool) error
+	SetSlaved(slaved bool) error
+	SlewToAltitude(altitude float64) error
+	SlewToAzimuth(azimuth float64) error
+	SyncToAzimuth(azimuth float64) error
+}
+
+/*
+FilterWheelAPI is satisfied by *FilterWheel, and by mocks.FilterWheel.
+*/
+type FilterWheelAPI interface {
+	Diagnose(ctx context.Context) DiagnosticReport
+	GetDescription() (string, error)
+	GetFocusOffsets() ([]uint32, error)
+	GetNames() ([]string, error)
+	GetPosition() (int32, error)
+	IsConnected() (bool, error)
+	SetConnected(connected bool) error
+	SetPosition(position int32) error
+}
+
+/*
+FocuserAPI is satisfied by *Focuser, and by mocks.Focuser.
+*/
+type FocuserAPI interface {
+	Diagnose(ctx context.Context) DiagnosticReport
+	GetDescription() (string, error)
+	GetMaxIncrement() (int32, error)
+	GetMaxStep() (int32, error)
+	GetPosition() (int32, error)
+	GetStepSize() (float64, error)
+	GetTemperature() (float64, error)
+	GetTemperatureCompensation() (bool, error)
+	IsAbsolute() (bool, error)
+	IsConnected() (bool, error)
+	IsMoving() (bool, error)
+	IsTemperatureCompensationAvailable() (bool, error)
+	SetConnected(connected bool) error
+	SetHalt() error
+	SetMove(position int32) error
+	SetTemperatureCompensation(tempComp bool) error
+}
+
+/*
+ObservingConditionsAPI is satisfied by *ObservingConditions, and by mocks.ObservingConditions.
+*/
+type ObservingConditionsAPI interface {
+	Diagnose(ctx context.Context) DiagnosticReport
+	GetAveragePeriod() (float64, error)
+	GetCloudCover() (float64, error)
+	GetDewPoint() (float64, error)
+	GetHumidity() (float64, error)
+	GetPressure() (float64, error)
+	GetRainRate() (float64, error)
+	GetSeeingStarFWHM() (float64, error)
+	GetSensorDescription(sensorName string) (string, error)
+	GetSkyBrightness() (float64, error)
+	GetSkyQuality() (float64, error)
+	GetSkyTemperature() (float64, error)
+	GetTemperature() (float64, error)
+	GetTimeSinceLastUpdate(sensorName string) (float64, error)
+	GetWindDirection() (float64, error)
+	GetWindGust() (float64, error)
+	GetWindSpeed() (float64, error)
+	IsConnected() (bool, error)
+	SetConnected(connected bool) error
+	SetRefresh() error
+}
+
+/*
+RotatorAPI is satisfied by *Rotator, and by mocks.Rotator.
+*/
+type RotatorAPI interface {
+	CanReverse() (bool, error)
+	Diagnose(ctx context.Context) DiagnosticReport
+	GetDescription() (string, error)
+	GetMechanicalPosition() (float64, error)
+	GetPosition() (float64, error)
+	GetReverse() (bool, error)
+	GetStepSize() (float64, error)
+	GetTargetPosition() (float64, error)
+	IsConnected() (bool, error)
+	IsMoving() (bool, error)
+	SetConnected(connected bool) error
+	SetHalt() error
+	SetMove(position float64) error
+	SetMoveAbsolute(position float64) error
+	SetMoveMechanical(position float64) error
+	SetReverse(reverse bool) error
+	SetSync(position float64) error
+}
+
+/*
+SafetyMonitorAPI is satisfied by *SafetyMonitor, and by mocks.SafetyMonitor.
+*/
+type SafetyMonitorAPI interface {
+	Diagnose(ctx context.Context) DiagnosticReport
+	GetDescription() (string, error)
+	IsConnected() (bool, error)
+	IsSafe() (bool, error)
+	SetConnected(connected bool) error
+}
+
+/*
+TelescopeAPI is satisfied by *Telescope, and by mocks.Telescope.
+*/
+type TelescopeAPI interface {
+	CanFindHome() (bool, error)
+	CanMoveAxis(axis AxisType) (bool, error)
+	CanPark() (bool, error)
+	CanPulseGuide() (bool, error)
+	CanSetDeclinationRate() (bool, error)
+	CanSetGuideRates() (bool, error)
+	CanSetPark() (bool, error)
+	CanSetPierSide() (bool, error)
+	CanSetRightAscensionRate() (bool, error)
+	CanSetTracking() (bool, error)
+	CanSlew() (bool, error)
+	CanSlewAltAz() (bool, error)
+	CanSlewAltAzAsync() (bool, error)
+	CanSlewAsync() (bool, error)
+	CanSync() (bool, error)
+	CanSyncAltAz() (bool, error)
+	CanUnPark() (bool, error)
+	Diagnose(ctx context.Context) DiagnosticReport
+	DoesRefraction() (bool, error)
+	FindHome() error
+	GetAlignmentMode() (string, error)
+	GetAltitude() (float64, error)
+	GetApertureArea() (float64, error)
+	GetApertureDiameter() (float64, error)
+	GetAxisRates(axis AxisType) (map[string]float64, error)
+	GetAzimuth() (float64, error)
+	GetDeclination() (float64, error)
+	GetDeclinationRate() (float64, error)
+	GetDescription() (string, error)
+	GetDestinationSideOfPier(rightAscension float64, declination float64) (PierPointingMode, error)
+	GetEquatorialSystem() (string, error)
+	GetFocalLength() (float64, error)
+	GetRightAscension() (float64, error)
+	GetRightAscensionRate() (float64, error)
+	GetSideOfPier() (PierPointingMode, error)
+	GetSiderealTime() (float64, error)
+	GetSiteElevation() (float64, error)
+	GetSiteLatitude() (float64, error)
+	GetSiteLongitude() (float64, error)
+	GetSlewSettleTime() (int32, error)
+	GetTargetDeclination() (float64, error)
+	GetTargetRightAscension() (float64, error)
+	GetTrackingRate() (int32, error)
+	GetUTCDate() (time.Time, error)
+	IsAtHome() (bool, error)
+	IsAtPark() (bool, error)
+	IsConnected() (bool, error)
+	IsPulseGuiding() (bool, error)
+	IsSlewing() (bool, error)
+	IsTracking() (bool, error)
+	SetAbortSlew() error
+	SetConnected(connected bool) error
+	SetDeclinationRate(declinationRate float64) error
+	SetDoesRefraction(doesRefraction bool) error
+	SetPark() error
+	SetPulseGuide(direction Direction, duration int32) error
+	SetRightAscensionRate(rightAscensionRate float64) error
+	SetSideOfPier(sideOfPier PierPointingMode) error
+	SetSiteElevation(siteElevation float64) error
+	SetSiteLatitude(siteLatitude float64) error
+	SetSiteLongitude(siteLongitude float64) error
+	SetSlewSettleTime(slewSettleTime int32) error
+	SetSlewToAltAz(altitude float64, azimuth float64) error
+	SetSlewToAltAzAsync(altitude float64, azimuth float64) error
+	SetSlewToCoordinates(rightAscension float64, declination float64) error
+	SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error
+	SetSlewToTarget() error
+	SetSlewToTargetAsync() error
+	SetSyncToCoordinates(rightAscension float64, declination float64) error
+	SetTargetDeclination(targetDeclination float64) error
+	SetTargetRightAscension(targetRightAscension float64) error
+	SetTracking(tracking bool) error
+	SetUTCDate(UTCDate time.Time) error
+	SetUnPark() error
+}
+
+var (
+	_ CameraAPI              = (*Camera)(nil)
+	_ CoverCalibratorAPI     = (*CoverCalibrator)(nil)
+	_ DomeAPI                = (*Dome)(nil)
+	_ FilterWheelAPI         = (*FilterWheel)(nil)
+	_ FocuserAPI             = (*Focuser)(nil)
+	_ ObservingConditionsAPI = (*ObservingConditions)(nil)
+	_ RotatorAPI             = (*Rotator)(nil)
+	_ SafetyMonitorAPI       = (*SafetyMonitor)(nil)
+	_ TelescopeAPI           = (*Telescope)(nil)
+)
@@ -0,0 +1,400 @@
+package alpacago
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LimitsTelescope interface {
+	GetAltitude() (float64, error)
+	GetAzimuth() (float64, error)
+	GetRightAscension() (float64, error)
+	GetDeclination() (float64, error)
+	GetSiderealTime() (float64, error)
+	GetSiteLatitude() (float64, error)
+	SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error
+	SetSlewToAltAzAsync(altitude float64, azimuth float64) error
+	IsSlewing() (bool, error)
+	SetAbortSlew() error
+}
+
+var ErrLimitExceeded = errors.New("the position is outside of the mount limits")
+
+type HorizonPoint struct {
+	Azimuth  float64
+	Altitude float64
+}
+
+/*
+HorizonProfile is the local horizon (e.g., trees and buildings) as altitudes at a set of azimuths, which
+are interpolated linearly between, wrapping around through North.
+*/
+type HorizonProfile []HorizonPoint
+
+/*
+ParseHorizonProfile()
+
+Parses a horizon profile of one "azimuth altitude" pair (degrees) per line, separated by whitespace or a
+comma. Blank lines and lines starting with # are ignored.
+
+@returns the horizon profile, sorted by azimuth.
+*/
+func ParseHorizonProfile(data []byte) (HorizonProfile, error) {
+	horizon := HorizonProfile{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected an azimuth and an altitude, got %q", line, text)
+		}
+
+		azimuth, err := strconv.ParseFloat(fields[0], 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		altitude, err := strconv.ParseFloat(fields[1], 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if azimuth < 0 || azimuth > 360 || altitude < -90 || altitude > 90 {
+			return nil, fmt.Errorf("line %d: azimuth %f or altitude %f is out of range", line, azimuth, altitude)
+		}
+
+		horizon = append(horizon, HorizonPoint{Azimuth: azimuth, Altitude: altitude})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(horizon, func(i, j int) bool { return horizon[i].Azimuth < horizon[j].Azimuth })
+
+	return horizon, nil
+}
+
+/*
+LoadHorizonProfile()
+
+@returns the horizon profile read from the file at the given path.
+*/
+func LoadHorizonProfile(path string) (HorizonProfile, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseHorizonProfile(data)
+}
+
+/*
+GetAltitude()
+
+@param azimuth float64 (degrees, North through East)
+@returns the altitude (degrees) of the horizon at the given azimuth, or -90 for an empty profile.
+*/
+func (h HorizonProfile) GetAltitude(azimuth float64) float64 {
+	if len(h) == 0 {
+		return -90
+	}
+
+	azimuth = getNormalisedAngle(azimuth, 360)
+
+	// The points either side of the azimuth, wrapping around from the last point to the first:
+	i := sort.Search(len(h), func(i int) bool { return h[i].Azimuth >= azimuth })
+
+	previous, next := h[(i+len(h)-1)%len(h)], h[i%len(h)]
+
+	span := getNormalisedAngle(next.Azimuth-previous.Azimuth, 360)
+
+	if span == 0 {
+		return previous.Altitude
+	}
+
+	f := getNormalisedAngle(azimuth-previous.Azimuth, 360) / span
+
+	return previous.Altitude + f*(next.Altitude-previous.Altitude)
+}
+
+/*
+MountLimits are the positions a mount may point at, or slew through.
+*/
+type MountLimits struct {
+	// The local horizon, below which the mount may not point (optional)
+	Horizon HorizonProfile
+	// The minimum altitude (degrees), applied everywhere regardless of the horizon profile
+	MinAltitude float64
+	// The hour angle limits (hours, -12 to +12, positive past the meridian)
+	MinHourAngle float64
+	MaxHourAngle float64
+	// The declination limits (degrees)
+	MinDeclination float64
+	MaxDeclination float64
+}
+
+func NewMountLimits() MountLimits {
+	limits := MountLimits{
+		MinAltitude:    0,
+		MinHourAngle:   -12,
+		MaxHourAngle:   12,
+		MinDeclination: -90,
+		MaxDeclination: 90,
+	}
+
+	return limits
+}
+
+/*
+CheckHorizontal()
+
+@returns an error wrapping ErrLimitExceeded if the position is below the horizon or the minimum altitude.
+*/
+func (l MountLimits) CheckHorizontal(h HorizontalCoordinate) error {
+	minimum := math.Max(l.MinAltitude, l.Horizon.GetAltitude(h.Azimuth))
+
+	if h.Altitude < minimum {
+		return fmt.Errorf("%w: altitude %.2f° is below %.2f° at azimuth %.2f°", ErrLimitExceeded, h.Altitude, minimum, h.Azimuth)
+	}
+
+	return nil
+}
+
+/*
+CheckEquatorial()
+
+@param lst float64 (the local sidereal time, in hours)
+@param latitude float64 (the site latitude, in degrees)
+@returns an error wrapping ErrLimitExceeded if the position is outside of the declination or hour angle
+limits, or is below the horizon.
+*/
+func (l MountLimits) CheckEquatorial(c EquatorialCoordinate, lst float64, latitude float64) error {
+	if err := l.checkAxes(c, lst); err != nil {
+		return err
+	}
+
+	return l.CheckHorizontal(EquatorialToHorizontal(c, lst, latitude))
+}
+
+/*
+checkAxes()
+
+@returns an error wrapping ErrLimitExceeded if the position is outside of the declination or hour angle limits.
+*/
+func (l MountLimits) checkAxes(c EquatorialCoordinate, lst float64) error {
+	if c.Declination < l.MinDeclination || c.Declination > l.MaxDeclination {
+		return fmt.Errorf("%w: declination %.2f° is outside of %.2f° to %.2f°", ErrLimitExceeded, c.Declination, l.MinDeclination, l.MaxDeclination)
+	}
+
+	ha := GetHourAngle(c, lst)
+
+	if ha < l.MinHourAngle || ha > l.MaxHourAngle {
+		return fmt.Errorf("%w: hour angle %.2fh is outside of %.2fh to %.2fh", ErrLimitExceeded, ha, l.MinHourAngle, l.MaxHourAngle)
+	}
+
+	return nil
+}
+
+/*
+SlewGuard validates slew targets against the mount limits before slewing, and optionally watches the slew
+in progress, aborting it should the mount cross the limits on its way.
+*/
+type SlewGuard struct {
+	Telescope LimitsTelescope
+	Limits    MountLimits
+	// Whether to poll the position during a slew, and abort it if the limits are crossed
+	Watch        bool
+	PollInterval time.Duration
+	// Called after a slew has been aborted on crossing the limits
+	OnAbort func(err error)
+}
+
+func NewSlewGuard(telescope LimitsTelescope, limits MountLimits) *SlewGuard {
+	guard := SlewGuard{
+		Telescope:    telescope,
+		Limits:       limits,
+		Watch:        true,
+		PollInterval: 250 * time.Millisecond,
+	}
+
+	return &guard
+}
+
+/*
+getSite()
+
+@returns the local sidereal time (hours) and the site latitude (degrees) of the telescope.
+*/
+func (g *SlewGuard) getSite() (float64, float64, error) {
+	lst, err := g.Telescope.GetSiderealTime()
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	latitude, err := g.Telescope.GetSiteLatitude()
+
+	return lst, latitude, err
+}
+
+/*
+CheckPosition()
+
+@returns an error wrapping ErrLimitExceeded if the telescope's current position is outside of the limits.
+*/
+func (g *SlewGuard) CheckPosition() error {
+	ra, err := g.Telescope.GetRightAscension()
+
+	if err != nil {
+		return err
+	}
+
+	dec, err := g.Telescope.GetDeclination()
+
+	if err != nil {
+		return err
+	}
+
+	altitude, err := g.Telescope.GetAltitude()
+
+	if err != nil {
+		return err
+	}
+
+	azimuth, err := g.Telescope.GetAzimuth()
+
+	if err != nil {
+		return err
+	}
+
+	lst, err := g.Telescope.GetSiderealTime()
+
+	if err != nil {
+		return err
+	}
+
+	if err := g.Limits.checkAxes(EquatorialCoordinate{RightAscension: ra, Declination: dec}, lst); err != nil {
+		return err
+	}
+
+	return g.Limits.CheckHorizontal(HorizontalCoordinate{Altitude: altitude, Azimuth: azimuth})
+}
+
+/*
+SlewToCoordinates()
+
+Validates the target against the limits, then slews to it and waits for the slew to complete.
+
+@param c EquatorialCoordinate (in the telescope's equatorial system, see ToTelescopeCoordinates())
+*/
+func (g *SlewGuard) SlewToCoordinates(ctx context.Context, c EquatorialCoordinate) error {
+	lst, latitude, err := g.getSite()
+
+	if err != nil {
+		return err
+	}
+
+	if err := g.Limits.CheckEquatorial(c, lst, latitude); err != nil {
+		return err
+	}
+
+	// Telescope.SetSlewToCoordinatesAsync() takes the right ascension in degrees:
+	if err := g.Telescope.SetSlewToCoordinatesAsync(c.RightAscension*15, c.Declination); err != nil {
+		return err
+	}
+
+	return g.wait(ctx)
+}
+
+/*
+SlewToAltAz()
+
+Validates the target against the limits, then slews to it and waits for the slew to complete.
+*/
+func (g *SlewGuard) SlewToAltAz(ctx context.Context, h HorizontalCoordinate) error {
+	lst, latitude, err := g.getSite()
+
+	if err != nil {
+		return err
+	}
+
+	if err := g.Limits.CheckEquatorial(HorizontalToEquatorial(h, lst, latitude), lst, latitude); err != nil {
+		return err
+	}
+
+	if err := g.Telescope.SetSlewToAltAzAsync(h.Altitude, h.Azimuth); err != nil {
+		return err
+	}
+
+	return g.wait(ctx)
+}
+
+/*
+wait()
+
+Waits for the slew to complete. Whilst watching, a slew that crosses into the limits is aborted; a slew which
+starts outside of them (e.g., from a park position below the horizon) is only watched once it is within them.
+*/
+func (g *SlewGuard) wait(ctx context.Context) error {
+	if !g.Watch {
+		return WaitWhileMoving(ctx, g.PollInterval, g.Telescope.IsSlewing)
+	}
+
+	within := false
+
+	return WaitUntil(ctx, g.PollInterval, func() (bool, error) {
+		slewing, err := g.Telescope.IsSlewing()
+
+		if err != nil || !slewing {
+			return true, err
+		}
+
+		err = g.CheckPosition()
+
+		if err == nil {
+			within = true
+			return false, nil
+		}
+
+		if !errors.Is(err, ErrLimitExceeded) {
+			return true, err
+		}
+
+		if !within {
+			return false, nil
+		}
+
+		if abortErr := g.Telescope.SetAbortSlew(); abortErr != nil {
+			return true, abortErr
+		}
+
+		if g.OnAbort != nil {
+			g.OnAbort(err)
+		}
+
+		return true, fmt.Errorf("the slew was aborted: %w", err)
+	})
+}
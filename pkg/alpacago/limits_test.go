@@ -0,0 +1,188 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+/*
+fakeLimitsTelescope follows a path of horizontal positions once slewing, advancing a step each time
+IsSlewing is polled, whilst pointing on the meridian at the celestial equator.
+*/
+type fakeLimitsTelescope struct {
+	path    []HorizontalCoordinate
+	step    int
+	slewing bool
+	slews   [][2]float64
+	aborted bool
+}
+
+func (t *fakeLimitsTelescope) GetAltitude() (float64, error) { return t.path[t.step].Altitude, nil }
+
+func (t *fakeLimitsTelescope) GetAzimuth() (float64, error) { return t.path[t.step].Azimuth, nil }
+
+func (t *fakeLimitsTelescope) GetRightAscension() (float64, error) { return 6, nil }
+
+func (t *fakeLimitsTelescope) GetDeclination() (float64, error) { return 0, nil }
+
+func (t *fakeLimitsTelescope) GetSiderealTime() (float64, error) { return 6, nil }
+
+func (t *fakeLimitsTelescope) GetSiteLatitude() (float64, error) { return 51.5, nil }
+
+func (t *fakeLimitsTelescope) SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error {
+	t.slews = append(t.slews, [2]float64{rightAscension, declination})
+	t.slewing = true
+	return nil
+}
+
+func (t *fakeLimitsTelescope) SetSlewToAltAzAsync(altitude float64, azimuth float64) error {
+	t.slews = append(t.slews, [2]float64{altitude, azimuth})
+	t.slewing = true
+	return nil
+}
+
+func (t *fakeLimitsTelescope) IsSlewing() (bool, error) {
+	if t.slewing && t.step < len(t.path)-1 {
+		t.step++
+		return true, nil
+	}
+
+	t.slewing = false
+
+	return false, nil
+}
+
+func (t *fakeLimitsTelescope) SetAbortSlew() error {
+	t.aborted = true
+	t.slewing = false
+	return nil
+}
+
+const testHorizon = `# azimuth altitude
+0 10
+90, 30
+
+270 20
+`
+
+func TestHorizonProfileGetAltitude(t *testing.T) {
+	horizon, err := ParseHorizonProfile([]byte(testHorizon))
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	tests := map[float64]float64{0: 10, 45: 20, 90: 30, 180: 25, 315: 15, 360: 10, -45: 15}
+
+	for azimuth, want := range tests {
+		if got := horizon.GetAltitude(azimuth); math.Abs(got-want) > 1e-9 {
+			t.Errorf("got %f at azimuth %f, wanted %f", got, azimuth, want)
+		}
+	}
+
+	if _, err := ParseHorizonProfile([]byte("90 30 1")); err == nil {
+		t.Errorf("got nil, wanted an error for a malformed line")
+	}
+}
+
+func TestMountLimitsCheckEquatorial(t *testing.T) {
+	limits := NewMountLimits()
+
+	limits.MinAltitude = 15
+	limits.MaxHourAngle = 1
+	limits.MaxDeclination = 85
+
+	tests := map[EquatorialCoordinate]bool{
+		// On the meridian, at an altitude of 38.5°:
+		{RightAscension: 6, Declination: 0}: true,
+		// Past the hour angle limit:
+		{RightAscension: 4, Declination: 0}: false,
+		// Beyond the declination limit:
+		{RightAscension: 6, Declination: 88}: false,
+		// Below the minimum altitude, low in the South:
+		{RightAscension: 6, Declination: -30}: false,
+	}
+
+	for c, want := range tests {
+		err := limits.CheckEquatorial(c, 6, 51.5)
+
+		if got := err == nil; got != want {
+			t.Errorf("got %v for %+v, wanted within limits %t", err, c, want)
+		}
+
+		if err != nil && !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("got %q, wanted it to wrap %q", err, ErrLimitExceeded)
+		}
+	}
+}
+
+func TestSlewGuardRejectsTarget(t *testing.T) {
+	telescope := &fakeLimitsTelescope{path: []HorizontalCoordinate{{Altitude: 40, Azimuth: 180}}}
+
+	guard := NewSlewGuard(telescope, NewMountLimits())
+
+	err := guard.SlewToAltAz(context.Background(), HorizontalCoordinate{Altitude: -5, Azimuth: 90})
+
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("got %v, wanted %q", err, ErrLimitExceeded)
+	}
+
+	if len(telescope.slews) != 0 {
+		t.Errorf("got %v, wanted no slews", telescope.slews)
+	}
+}
+
+func TestSlewGuardAbortsSlew(t *testing.T) {
+	limits := NewMountLimits()
+
+	limits.Horizon, _ = ParseHorizonProfile([]byte(testHorizon))
+
+	// The slew dips below the trees in the East on its way round:
+	path := []HorizontalCoordinate{{Altitude: 50, Azimuth: 180}, {Altitude: 40, Azimuth: 120}, {Altitude: 25, Azimuth: 90}, {Altitude: 40, Azimuth: 60}}
+
+	telescope := &fakeLimitsTelescope{path: path}
+
+	guard := NewSlewGuard(telescope, limits)
+
+	guard.PollInterval = time.Millisecond
+
+	aborts := 0
+
+	guard.OnAbort = func(err error) { aborts++ }
+
+	err := guard.SlewToCoordinates(context.Background(), EquatorialCoordinate{RightAscension: 6, Declination: 0})
+
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("got %v, wanted %q", err, ErrLimitExceeded)
+	}
+
+	if !telescope.aborted || aborts != 1 || telescope.step != 2 {
+		t.Errorf("got aborted %t at step %d, wanted the slew aborted at step 2", telescope.aborted, telescope.step)
+	}
+
+	if telescope.slews[0] != [2]float64{90, 0} {
+		t.Errorf("got %v, wanted a slew to 90° right ascension", telescope.slews[0])
+	}
+}
+
+func TestSlewGuardWatchesFromBelowHorizon(t *testing.T) {
+	// A slew from a park position below the horizon is not aborted until it has risen within the limits:
+	path := []HorizontalCoordinate{{Altitude: -10, Azimuth: 0}, {Altitude: -5, Azimuth: 0}, {Altitude: 20, Azimuth: 90}, {Altitude: 38.5, Azimuth: 180}}
+
+	telescope := &fakeLimitsTelescope{path: path}
+
+	guard := NewSlewGuard(telescope, NewMountLimits())
+
+	guard.PollInterval = time.Millisecond
+
+	if err := guard.SlewToCoordinates(context.Background(), EquatorialCoordinate{RightAscension: 6, Declination: 0}); err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	if telescope.aborted {
+		t.Errorf("got an aborted slew, wanted it to complete")
+	}
+}
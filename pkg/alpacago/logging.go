@@ -0,0 +1,133 @@
+package alpacago
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+Logger receives a structured record of every request a client sends. It is satisfied by *slog.Logger, and
+by NewLogrusLogger() for logrus.
+*/
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+type logrusLogger struct {
+	logger *logrus.Logger
+}
+
+/*
+NewLogrusLogger()
+
+@returns a Logger writing to the logrus logger, with the record's key-value pairs as fields.
+*/
+func NewLogrusLogger(logger *logrus.Logger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+func (l *logrusLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	fields := logrus.Fields{}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		if key, ok := args[i].(string); ok {
+			fields[key] = args[i+1]
+		}
+	}
+
+	entry := l.logger.WithContext(ctx).WithFields(fields)
+
+	switch {
+	case level < slog.LevelInfo:
+		entry.Debug(msg)
+	case level < slog.LevelWarn:
+		entry.Info(msg)
+	case level < slog.LevelError:
+		entry.Warn(msg)
+	default:
+		entry.Error(msg)
+	}
+}
+
+/*
+RequestTrace is the outcome of a single request sent by a client, as passed to its OnResponse hook.
+*/
+type RequestTrace struct {
+	Request TransportRequest
+	// The ClientTransactionID sent with the request, and the ServerTransactionID of its response
+	ClientTransactionID uint32
+	ServerTransactionID uint32
+	// The HTTP status code of the response, 0 if the transport failed
+	StatusCode   int
+	ErrorNumber  int32
+	ErrorMessage string
+	Latency      time.Duration
+	// The transport failure e.g., a timeout or refused connection
+	Error error
+}
+
+/*
+getRequestTrace()
+
+@returns the trace of the request, with the client transaction ID sent, and the server transaction ID and ASCOM
+error read from the response envelope.
+*/
+func getRequestTrace(request TransportRequest, resp TransportResponse, err error, latency time.Duration) RequestTrace {
+	trace := RequestTrace{
+		Request:             request,
+		ClientTransactionID: request.getClientTransactionID(),
+		StatusCode:          resp.StatusCode,
+		Latency:             latency,
+		Error:               err,
+	}
+
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		return trace
+	}
+
+	trace.ServerTransactionID = resp.Envelope.ServerTransactionID
+	trace.ErrorNumber = resp.Envelope.ErrorNumber
+	trace.ErrorMessage = resp.Envelope.ErrorMessage
+
+	return trace
+}
+
+/*
+log()
+
+Logs the request trace: at debug level on success, warning level on an ASCOM error, and error level on a
+REST error or transport failure.
+*/
+func (t RequestTrace) log(ctx context.Context, logger Logger) {
+	level := slog.LevelDebug
+
+	switch {
+	case t.Error != nil || t.StatusCode >= http.StatusBadRequest:
+		level = slog.LevelError
+	case t.ErrorNumber != 0:
+		level = slog.LevelWarn
+	}
+
+	args := []any{
+		"verb", t.Request.Verb,
+		"device_type", t.Request.DeviceType,
+		"device_number", t.Request.DeviceNumber,
+		"method", t.Request.Method,
+		"client_transaction_id", t.ClientTransactionID,
+		"server_transaction_id", t.ServerTransactionID,
+		"status", t.StatusCode,
+		"latency", t.Latency,
+		"error_number", t.ErrorNumber,
+		"error_message", t.ErrorMessage,
+	}
+
+	if t.Error != nil {
+		args = append(args, "error", t.Error.Error())
+	}
+
+	logger.Log(ctx, level, "alpaca request", args...)
+}
@@ -0,0 +1,192 @@
+package alpacago
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestLoggedTelescope(transport Transport, logger Logger) *Telescope {
+	telescope := NewTelescope(65535, false, "", "0.0.0.0", 8000, 0, 1)
+
+	telescope.Alpaca.Transport = transport
+
+	telescope.Alpaca.Logger = logger
+
+	return telescope
+}
+
+func TestClientLoggerFields(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetValue("telescope", 0, "altitude", 45.5)
+
+	var buffer bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	telescope := newTestLoggedTelescope(transport, logger)
+
+	if _, err := telescope.GetAltitude(); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	record := map[string]interface{}{}
+
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	for key, want := range map[string]interface{}{
+		"level":         "DEBUG",
+		"msg":           "alpaca request",
+		"verb":          "GET",
+		"device_type":   "telescope",
+		"device_number": 0.,
+		"method":        "altitude",
+		"status":        200.,
+		"error_number":  0.,
+	} {
+		if got := record[key]; got != want {
+			t.Errorf("%s: got %v, wanted %v", key, got, want)
+		}
+	}
+
+	for _, key := range []string{"client_transaction_id", "server_transaction_id", "latency"} {
+		if _, ok := record[key]; !ok {
+			t.Errorf("got no %s, wanted a field", key)
+		}
+	}
+}
+
+func TestClientLoggerASCOMError(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetError(http.MethodPut, "telescope", 0, "park", 0x40B, "the telescope cannot be parked")
+
+	var buffer bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	telescope := newTestLoggedTelescope(transport, logger)
+
+	if err := telescope.SetPark(); err == nil {
+		t.Fatalf("got nil, wanted an error")
+	}
+
+	record := map[string]interface{}{}
+
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if record["level"] != "WARN" || record["error_number"] != float64(0x40B) || record["error_message"] != "the telescope cannot be parked" {
+		t.Errorf("got %v, wanted a warning with the ASCOM error", record)
+	}
+}
+
+func TestClientRequestHooks(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetValue("telescope", 0, "altitude", 45.5)
+
+	telescope := newTestLoggedTelescope(transport, nil)
+
+	var mu sync.Mutex
+
+	audit := []string{}
+
+	telescope.Alpaca.OnRequest = func(ctx context.Context, request TransportRequest) {
+		mu.Lock()
+		defer mu.Unlock()
+		audit = append(audit, "request "+request.Key())
+	}
+
+	telescope.Alpaca.OnResponse = func(ctx context.Context, trace RequestTrace) {
+		mu.Lock()
+		defer mu.Unlock()
+		audit = append(audit, "response "+trace.Request.Key())
+
+		if trace.StatusCode != http.StatusOK || trace.Latency < 0 {
+			t.Errorf("got status %d and latency %v, wanted 200 and a latency", trace.StatusCode, trace.Latency)
+		}
+	}
+
+	if _, err := telescope.GetAltitude(); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if err := telescope.SetTracking(true); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var got string = strings.Join(audit, ", ")
+
+	var want string = "request GET telescope/0/altitude, response GET telescope/0/altitude, request PUT telescope/0/tracking?Tracking=true, response PUT telescope/0/tracking?Tracking=true"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestClientRequestTraceTransactionID(t *testing.T) {
+	// The fake transport answers every envelope with a ClientTransactionID of 0:
+	transport := NewFakeTransport()
+
+	telescope := newTestLoggedTelescope(transport, nil)
+
+	telescope.Alpaca.TransactionId = 41
+
+	var got uint32
+
+	telescope.Alpaca.OnResponse = func(ctx context.Context, trace RequestTrace) {
+		got = trace.ClientTransactionID
+	}
+
+	if err := telescope.SetTracking(true); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var want uint32 = 42
+
+	if got != want {
+		t.Errorf("got %d, wanted the ClientTransactionID sent %d", got, want)
+	}
+}
+
+func TestNewAlpacaAPIWithoutLogger(t *testing.T) {
+	alpaca := NewAlpacaAPI(65535, false, "", "0.0.0.0", 8000)
+
+	if alpaca.Logger != nil {
+		t.Errorf("got %v, wanted no logger unless one is set", alpaca.Logger)
+	}
+}
+
+func TestLogrusLogger(t *testing.T) {
+	var buffer bytes.Buffer
+
+	logger := logrus.New()
+
+	logger.SetOutput(&buffer)
+
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	NewLogrusLogger(logger).Log(context.Background(), slog.LevelWarn, "alpaca request", "method", "park", "error_number", 0x40B)
+
+	record := map[string]interface{}{}
+
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if record["level"] != "warning" || record["method"] != "park" || record["error_number"] != float64(0x40B) {
+		t.Errorf("got %v, wanted a warning with the fields", record)
+	}
+}
@@ -0,0 +1,330 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type FlipTelescope interface {
+	GetAlignmentMode() (string, error)
+	GetRightAscension() (float64, error)
+	GetDeclination() (float64, error)
+	GetSiderealTime() (float64, error)
+	GetSideOfPier() (PierPointingMode, error)
+	SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error
+	IsSlewing() (bool, error)
+}
+
+type FlipRotator interface {
+	GetPosition() (float64, error)
+	SetMoveAbsolute(position float64) error
+	IsMoving() (bool, error)
+}
+
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+// The number of solar seconds in a sidereal second
+const siderealToSolar = 0.9972695663
+
+type MeridianFlip struct {
+	Telescope FlipTelescope
+	// The dome and rotator re-synchronised after a flip (optional)
+	Dome    *DomeSlaving
+	Rotator FlipRotator
+	// The imaging paused whilst Run() performs a flip (optional)
+	Imaging Pauser
+	// The flip is performed once the target is this many minutes past the meridian
+	MinutesPastMeridian float64
+	// The mount may track at most this many minutes past the meridian, so exposures that would run beyond
+	// it wait for the flip first
+	MaxMinutesPastMeridian float64
+	PollInterval           time.Duration
+	// Called before and after each flip
+	OnFlipStart    func()
+	OnFlipComplete func(err error)
+}
+
+func NewMeridianFlip(telescope FlipTelescope) *MeridianFlip {
+	flip := MeridianFlip{
+		Telescope:              telescope,
+		MinutesPastMeridian:    5,
+		MaxMinutesPastMeridian: 15,
+		PollInterval:           10 * time.Second,
+	}
+
+	return &flip
+}
+
+/*
+GetHourAngle()
+
+@returns the hour angle (hours, -12 to +12) of the telescope's current position; positive past the meridian.
+*/
+func (m *MeridianFlip) GetHourAngle() (float64, error) {
+	ra, err := m.Telescope.GetRightAscension()
+
+	if err != nil {
+		return 0, err
+	}
+
+	lst, err := m.Telescope.GetSiderealTime()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return GetHourAngle(EquatorialCoordinate{RightAscension: ra}, lst), nil
+}
+
+/*
+GetTimeToMeridian()
+
+@returns the time until the telescope's current position transits the meridian, negative once past it.
+*/
+func (m *MeridianFlip) GetTimeToMeridian() (time.Duration, error) {
+	ha, err := m.GetHourAngle()
+
+	return getSiderealDuration(-ha), err
+}
+
+/*
+getSiderealDuration()
+
+@returns the solar time taken for the sky to turn through the given number of sidereal hours.
+*/
+func getSiderealDuration(hours float64) time.Duration {
+	return time.Duration(hours * siderealToSolar * float64(time.Hour))
+}
+
+/*
+IsFlipRequired()
+
+A German equatorial mount tracking a target in the East points from the West of the pier (PierWest), and
+must flip to the East of the pier (PierEast) once the target has crossed the meridian into the West.
+
+@returns true if the mount is German equatorial, still on the western pointing state, and the target is
+at least MinutesPastMeridian past the meridian.
+*/
+func (m *MeridianFlip) IsFlipRequired() (bool, error) {
+	pending, err := m.isFlipPending()
+
+	if err != nil || !pending {
+		return false, err
+	}
+
+	ha, err := m.GetHourAngle()
+
+	return ha*60 >= m.MinutesPastMeridian, err
+}
+
+/*
+isFlipPending()
+
+@returns true if the mount is German equatorial and on the pointing state which will require a flip.
+*/
+func (m *MeridianFlip) isFlipPending() (bool, error) {
+	mode, err := m.Telescope.GetAlignmentMode()
+
+	if err != nil || mode != AlignmentGermanPolar.String() {
+		return false, err
+	}
+
+	side, err := m.Telescope.GetSideOfPier()
+
+	return side == PierWest, err
+}
+
+/*
+Flip()
+
+Performs a meridian flip, by re-slewing to the current position (checking with DestinationSideOfPier first,
+where the driver supports it), then verifies the pier side has changed, re-synchronises the dome and turns
+the rotator through 180° to keep the same sky position angle.
+*/
+func (m *MeridianFlip) Flip(ctx context.Context) (err error) {
+	if m.OnFlipStart != nil {
+		m.OnFlipStart()
+	}
+
+	if m.OnFlipComplete != nil {
+		defer func() { m.OnFlipComplete(err) }()
+	}
+
+	before, err := m.Telescope.GetSideOfPier()
+
+	if err != nil {
+		return err
+	}
+
+	ra, err := m.Telescope.GetRightAscension()
+
+	if err != nil {
+		return err
+	}
+
+	dec, err := m.Telescope.GetDeclination()
+
+	if err != nil {
+		return err
+	}
+
+	if predictor, ok := m.Telescope.(interface {
+		GetDestinationSideOfPier(float64, float64) (PierPointingMode, error)
+	}); ok {
+		// A driver that cannot predict the pointing state is flipped regardless, and checked afterwards:
+		if destination, err := predictor.GetDestinationSideOfPier(ra*15, dec); err == nil && destination == before {
+			return fmt.Errorf("the mount reports that a slew to the current position would remain on pier side %d", before)
+		}
+	}
+
+	// The telescope takes the right ascension in degrees, for predictions and slews alike:
+	if err := m.Telescope.SetSlewToCoordinatesAsync(ra*15, dec); err != nil {
+		return err
+	}
+
+	if err := WaitWhileMoving(ctx, m.PollInterval, m.Telescope.IsSlewing); err != nil {
+		return err
+	}
+
+	after, err := m.Telescope.GetSideOfPier()
+
+	if err != nil {
+		return err
+	}
+
+	if after == before {
+		return fmt.Errorf("the mount remained on pier side %d after the meridian flip", after)
+	}
+
+	if m.Dome != nil {
+		moved, err := m.Dome.Step()
+
+		if err != nil {
+			return err
+		}
+
+		if moved {
+			if err := WaitWhileMoving(ctx, m.PollInterval, m.Dome.Dome.IsSlewing); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.Rotator != nil {
+		position, err := m.Rotator.GetPosition()
+
+		if err != nil {
+			return err
+		}
+
+		if err := m.Rotator.SetMoveAbsolute(getNormalisedAngle(position+180, 360)); err != nil {
+			return err
+		}
+
+		if err := WaitWhileMoving(ctx, m.PollInterval, m.Rotator.IsMoving); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+waitForFlipPoint()
+
+Waits until the target is MinutesPastMeridian past the meridian.
+*/
+func (m *MeridianFlip) waitForFlipPoint(ctx context.Context) error {
+	return WaitUntil(ctx, m.PollInterval, func() (bool, error) {
+		ha, err := m.GetHourAngle()
+		return ha*60 >= m.MinutesPastMeridian, err
+	})
+}
+
+/*
+BeforeExposure()
+
+Called before each exposure: if a flip is due, or an exposure of the given duration would carry the mount
+beyond MaxMinutesPastMeridian, waits for the flip point and performs the flip.
+
+@param duration float64 (the exposure duration in seconds)
+@returns true if the mount was flipped.
+*/
+func (m *MeridianFlip) BeforeExposure(ctx context.Context, duration float64) (bool, error) {
+	if m.MaxMinutesPastMeridian < m.MinutesPastMeridian {
+		return false, errors.New("the maximum minutes past the meridian must not be less than the flip point")
+	}
+
+	pending, err := m.isFlipPending()
+
+	if err != nil || !pending {
+		return false, err
+	}
+
+	ha, err := m.GetHourAngle()
+
+	if err != nil {
+		return false, err
+	}
+
+	// The hour angle (minutes) the mount would reach by the end of the exposure:
+	end := ha*60 + duration/60/siderealToSolar
+
+	if ha*60 < m.MinutesPastMeridian && end <= m.MaxMinutesPastMeridian {
+		return false, nil
+	}
+
+	if err := m.waitForFlipPoint(ctx); err != nil {
+		return false, err
+	}
+
+	return true, m.Flip(ctx)
+}
+
+/*
+Run()
+
+Monitors the mount, and once a flip is required pauses imaging, flips, and resumes imaging. Pausing
+only takes effect between exposures, so a Sequencer should instead use its MeridianFlip field, which
+schedules the flip before each exposure.
+*/
+func (m *MeridianFlip) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.PollInterval)
+
+	defer ticker.Stop()
+
+	for {
+		required, err := m.IsFlipRequired()
+
+		if err != nil {
+			return err
+		}
+
+		if required {
+			if m.Imaging != nil {
+				m.Imaging.Pause()
+			}
+
+			err := m.Flip(ctx)
+
+			if m.Imaging != nil {
+				m.Imaging.Resume()
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package alpacago
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+/*
+simulatedGEM is a German equatorial mount tracking a target at RA 6h, whose sidereal time advances by
+a minute each time it is read. A slew once the target is past the meridian flips it to PierEast.
+*/
+type simulatedGEM struct {
+	lst   float64
+	side  PierPointingMode
+	slews int
+	// The pier side reported by DestinationSideOfPier, or PierUnknown if unsupported
+	destination PierPointingMode
+}
+
+func (m *simulatedGEM) GetAlignmentMode() (string, error) { return AlignmentGermanPolar.String(), nil }
+
+func (m *simulatedGEM) GetRightAscension() (float64, error) { return 6, nil }
+
+func (m *simulatedGEM) GetDeclination() (float64, error) { return 20, nil }
+
+func (m *simulatedGEM) GetSiderealTime() (float64, error) {
+	m.lst += 1. / 60
+
+	return m.lst, nil
+}
+
+func (m *simulatedGEM) GetSideOfPier() (PierPointingMode, error) { return m.side, nil }
+
+func (m *simulatedGEM) SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error {
+	m.slews++
+
+	if m.lst > rightAscension/15 {
+		m.side = PierEast
+	}
+
+	return nil
+}
+
+func (m *simulatedGEM) IsSlewing() (bool, error) { return false, nil }
+
+type simulatedDestinationGEM struct {
+	*simulatedGEM
+}
+
+func (m simulatedDestinationGEM) GetDestinationSideOfPier(rightAscension float64, declination float64) (PierPointingMode, error) {
+	return m.destination, nil
+}
+
+type fakeFlipRotator struct {
+	position float64
+}
+
+func (r *fakeFlipRotator) GetPosition() (float64, error) { return r.position, nil }
+
+func (r *fakeFlipRotator) SetMoveAbsolute(position float64) error {
+	r.position = position
+	return nil
+}
+
+func (r *fakeFlipRotator) IsMoving() (bool, error) { return false, nil }
+
+func newTestMeridianFlip(telescope FlipTelescope) *MeridianFlip {
+	flip := NewMeridianFlip(telescope)
+
+	flip.PollInterval = time.Millisecond
+
+	return flip
+}
+
+func TestMeridianFlipGetTimeToMeridian(t *testing.T) {
+	mount := &simulatedGEM{lst: 5.5 - 1./60, side: PierWest}
+
+	got, err := newTestMeridianFlip(mount).GetTimeToMeridian()
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	want := time.Duration(0.5 * siderealToSolar * float64(time.Hour))
+
+	if got-want > time.Second || want-got > time.Second {
+		t.Errorf("got %s, wanted %s", got, want)
+	}
+}
+
+func TestMeridianFlipBeforeExposureNotDue(t *testing.T) {
+	mount := &simulatedGEM{lst: 5, side: PierWest}
+
+	flipped, err := newTestMeridianFlip(mount).BeforeExposure(context.Background(), 300)
+
+	if err != nil || flipped {
+		t.Errorf("got %t, %v, wanted no flip an hour before the meridian", flipped, err)
+	}
+}
+
+func TestMeridianFlipBeforeExposureWaitsAndFlips(t *testing.T) {
+	// Ten minutes before the meridian, a twenty-six minute exposure would run past the limit:
+	mount := &simulatedGEM{lst: 6 - 10./60, side: PierWest}
+
+	rotator := &fakeFlipRotator{position: 270}
+
+	flip := newTestMeridianFlip(mount)
+
+	flip.Rotator = rotator
+
+	flipped, err := flip.BeforeExposure(context.Background(), 26*60)
+
+	if err != nil || !flipped {
+		t.Fatalf("got %t, %v, wanted a flip", flipped, err)
+	}
+
+	if mount.lst < 6+5./60 {
+		t.Errorf("got a flip at %f, wanted it no earlier than five minutes past the meridian", mount.lst)
+	}
+
+	if mount.side != PierEast || mount.slews != 1 {
+		t.Errorf("got pier side %d after %d slews, wanted %d after 1", mount.side, mount.slews, PierEast)
+	}
+
+	if rotator.position != 90 {
+		t.Errorf("got %f, wanted the rotator turned to %f", rotator.position, 90.0)
+	}
+}
+
+func TestMeridianFlipDestinationSideOfPierCheck(t *testing.T) {
+	mount := &simulatedGEM{lst: 6.2, side: PierWest, destination: PierWest}
+
+	if err := newTestMeridianFlip(simulatedDestinationGEM{mount}).Flip(context.Background()); err == nil {
+		t.Errorf("got nil, wanted an error when the destination pier side is unchanged")
+	}
+
+	if mount.slews != 0 {
+		t.Errorf("got %d slews, wanted none", mount.slews)
+	}
+}
+
+func TestMeridianFlipVerifiesPierSide(t *testing.T) {
+	// Before the meridian a re-slew leaves the mount where it is:
+	mount := &simulatedGEM{lst: 5, side: PierWest}
+
+	if err := newTestMeridianFlip(mount).Flip(context.Background()); err == nil {
+		t.Errorf("got nil, wanted an error when the pier side is unchanged after the flip")
+	}
+}
+
+func TestSequencerMeridianFlip(t *testing.T) {
+	camera := &fakeSequenceCamera{}
+
+	sequencer, _, _ := newTestSequencer(t, camera)
+
+	// The flip is already due when the first exposure starts:
+	mount := &simulatedGEM{lst: 6 + 8./60, side: PierWest}
+
+	sequencer.MeridianFlip = newTestMeridianFlip(mount)
+
+	if err := sequencer.Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if mount.side != PierEast || mount.slews != 1 || len(camera.exposures) != 5 {
+		t.Errorf("got pier side %d after %d flips with %d exposures, wanted %d after 1 with 5", mount.side, mount.slews, len(camera.exposures), PierEast)
+	}
+}
+
+func TestTelescopeGetDestinationSideOfPierDegrees(t *testing.T) {
+	telescope, server := newTestTelescopeServer(t)
+
+	server.setValue("destinationsideofpier", int32(PierWest))
+
+	if side, err := telescope.GetDestinationSideOfPier(56.85, 24.1); err != nil || side != PierWest {
+		t.Fatalf("got %d (%v), wanted %d", side, err, PierWest)
+	}
+
+	// The right ascension is taken in degrees, and sent to the driver in hours:
+	query, _ := url.ParseQuery(server.getQueries()[0])
+
+	if got := query.Get("RightAscension"); got != "3.790000" {
+		t.Errorf("got %q, wanted a right ascension of 3.79 hours", got)
+	}
+}
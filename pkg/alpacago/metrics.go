@@ -0,0 +1,221 @@
+package alpacago
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+Metrics records the outcome of every request a client sends.
+*/
+type Metrics interface {
+	ObserveRequest(trace RequestTrace)
+}
+
+/*
+DefaultLatencyBuckets are the upper bounds (seconds) of the request latency histogram buckets, from a fast
+local driver to the client's 60 second timeout.
+*/
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type metricsKey struct {
+	deviceType   string
+	deviceNumber uint
+	method       string
+}
+
+type requestSeries struct {
+	requests uint64
+	failures uint64
+	errors   map[int32]uint64
+	buckets  []uint64
+	sum      float64
+}
+
+/*
+RequestMetrics counts requests, ASCOM errors and transport failures, and histograms request latency, per
+device and method. It serves them in the Prometheus text exposition format.
+*/
+type RequestMetrics struct {
+	// The upper bounds (seconds) of the latency histogram buckets, in increasing order
+	buckets []float64
+	mu      sync.Mutex
+	series  map[metricsKey]*requestSeries
+}
+
+/*
+NewRequestMetrics()
+
+@param buckets ...float64 (the upper bounds, in seconds, of the latency histogram buckets, or none for the
+DefaultLatencyBuckets)
+@returns the request metrics, with their own sorted copy of the buckets.
+*/
+func NewRequestMetrics(buckets ...float64) *RequestMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	metrics := RequestMetrics{
+		buckets: append([]float64{}, buckets...),
+		series:  map[metricsKey]*requestSeries{},
+	}
+
+	sort.Float64s(metrics.buckets)
+
+	return &metrics
+}
+
+/*
+ObserveRequest()
+
+Records the request: a REST error or transport failure (e.g., a timeout) counts as a failure, an ASCOM
+error is counted by its error number.
+*/
+func (m *RequestMetrics) ObserveRequest(trace RequestTrace) {
+	m.mu.Lock()
+
+	defer m.mu.Unlock()
+
+	key := metricsKey{trace.Request.DeviceType, trace.Request.DeviceNumber, trace.Request.Method}
+
+	series, ok := m.series[key]
+
+	if !ok {
+		series = &requestSeries{errors: map[int32]uint64{}, buckets: make([]uint64, len(m.buckets))}
+		m.series[key] = series
+	}
+
+	series.requests++
+
+	if trace.Error != nil || trace.StatusCode >= http.StatusBadRequest {
+		series.failures++
+	} else if trace.ErrorNumber != 0 {
+		series.errors[trace.ErrorNumber]++
+	}
+
+	seconds := trace.Latency.Seconds()
+
+	series.sum += seconds
+
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			series.buckets[i]++
+		}
+	}
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+/*
+getLabels()
+
+@returns the Prometheus labels of the series e.g., `device_type="telescope",device_number="0",method="altitude"`.
+*/
+func (k metricsKey) getLabels() string {
+	return fmt.Sprintf(`device_type="%s",device_number="%d",method="%s"`, labelReplacer.Replace(k.deviceType), k.deviceNumber, labelReplacer.Replace(k.method))
+}
+
+/*
+Write()
+
+Writes the metrics in the Prometheus text exposition format, sorted by device and method.
+*/
+func (m *RequestMetrics) Write(w io.Writer) error {
+	m.mu.Lock()
+
+	defer m.mu.Unlock()
+
+	keys := []metricsKey{}
+
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+
+		if a.deviceType != b.deviceType {
+			return a.deviceType < b.deviceType
+		}
+
+		if a.deviceNumber != b.deviceNumber {
+			return a.deviceNumber < b.deviceNumber
+		}
+
+		return a.method < b.method
+	})
+
+	var b strings.Builder
+
+	b.WriteString("# HELP alpaca_requests_total The number of Alpaca requests sent.\n")
+	b.WriteString("# TYPE alpaca_requests_total counter\n")
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, "alpaca_requests_total{%s} %d\n", key.getLabels(), m.series[key].requests)
+	}
+
+	b.WriteString("# HELP alpaca_transport_failures_total The number of Alpaca requests failing with a REST error or transport failure.\n")
+	b.WriteString("# TYPE alpaca_transport_failures_total counter\n")
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, "alpaca_transport_failures_total{%s} %d\n", key.getLabels(), m.series[key].failures)
+	}
+
+	b.WriteString("# HELP alpaca_ascom_errors_total The number of Alpaca requests answered with an ASCOM error, by error number.\n")
+	b.WriteString("# TYPE alpaca_ascom_errors_total counter\n")
+
+	for _, key := range keys {
+		series := m.series[key]
+
+		numbers := []int32{}
+
+		for number := range series.errors {
+			numbers = append(numbers, number)
+		}
+
+		sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+		for _, number := range numbers {
+			fmt.Fprintf(&b, "alpaca_ascom_errors_total{%s,error_number=\"%d\"} %d\n", key.getLabels(), number, series.errors[number])
+		}
+	}
+
+	b.WriteString("# HELP alpaca_request_duration_seconds The latency of Alpaca requests.\n")
+	b.WriteString("# TYPE alpaca_request_duration_seconds histogram\n")
+
+	for _, key := range keys {
+		series := m.series[key]
+
+		labels := key.getLabels()
+
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "alpaca_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), series.buckets[i])
+		}
+
+		fmt.Fprintf(&b, "alpaca_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, series.requests)
+		fmt.Fprintf(&b, "alpaca_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(series.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "alpaca_request_duration_seconds_count{%s} %d\n", labels, series.requests)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+/*
+ServeHTTP()
+
+Serves the metrics for scraping by Prometheus e.g., http.Handle("/metrics", metrics).
+*/
+func (m *RequestMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := m.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,132 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) Get(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	return TransportResponse{}, errors.New("context deadline exceeded")
+}
+
+func (failingTransport) Put(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	return TransportResponse{}, errors.New("context deadline exceeded")
+}
+
+func TestRequestMetricsObserveRequest(t *testing.T) {
+	metrics := NewRequestMetrics(0.1, 1)
+
+	altitude := TransportRequest{Verb: http.MethodGet, DeviceType: "telescope", Method: "altitude"}
+
+	metrics.ObserveRequest(RequestTrace{Request: altitude, StatusCode: 200, Latency: 50 * time.Millisecond})
+
+	metrics.ObserveRequest(RequestTrace{Request: altitude, StatusCode: 200, Latency: 500 * time.Millisecond})
+
+	metrics.ObserveRequest(RequestTrace{Request: altitude, StatusCode: 200, ErrorNumber: 0x407, Latency: 2 * time.Second})
+
+	metrics.ObserveRequest(RequestTrace{Request: altitude, Error: errors.New("timeout"), Latency: 2 * time.Second})
+
+	var b strings.Builder
+
+	if err := metrics.Write(&b); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	labels := `device_type="telescope",device_number="0",method="altitude"`
+
+	for _, want := range []string{
+		"# TYPE alpaca_requests_total counter",
+		"alpaca_requests_total{" + labels + "} 4",
+		"alpaca_transport_failures_total{" + labels + "} 1",
+		"alpaca_ascom_errors_total{" + labels + `,error_number="1031"} 1`,
+		"# TYPE alpaca_request_duration_seconds histogram",
+		"alpaca_request_duration_seconds_bucket{" + labels + `,le="0.1"} 1`,
+		"alpaca_request_duration_seconds_bucket{" + labels + `,le="1"} 2`,
+		"alpaca_request_duration_seconds_bucket{" + labels + `,le="+Inf"} 4`,
+		"alpaca_request_duration_seconds_sum{" + labels + "} 4.55",
+		"alpaca_request_duration_seconds_count{" + labels + "} 4",
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("got %q, wanted a line %q", b.String(), want)
+		}
+	}
+}
+
+func TestRequestMetricsBucketsCopied(t *testing.T) {
+	buckets := []float64{1, 0.1}
+
+	metrics := NewRequestMetrics(buckets...)
+
+	altitude := TransportRequest{Verb: http.MethodGet, DeviceType: "telescope", Method: "altitude"}
+
+	metrics.ObserveRequest(RequestTrace{Request: altitude, StatusCode: 200, Latency: 50 * time.Millisecond})
+
+	// Changing the caller's buckets once series exist leaves the metrics as constructed:
+	buckets[0] = 10
+
+	metrics.ObserveRequest(RequestTrace{Request: altitude, StatusCode: 200, Latency: 5 * time.Second})
+
+	var b strings.Builder
+
+	if err := metrics.Write(&b); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var got int = strings.Count(b.String(), "alpaca_request_duration_seconds_bucket{")
+
+	var want int = 3
+
+	if got != want || !strings.Contains(b.String(), `le="0.1"} 1`+"\n") || !strings.Contains(b.String(), `le="1"} 1`+"\n") {
+		t.Errorf("got %q, wanted %d buckets, 0.1 and 1 each counting 1", b.String(), want)
+	}
+}
+
+func TestRequestMetricsClient(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetValue("telescope", 0, "altitude", 45.5)
+
+	transport.SetError(http.MethodPut, "telescope", 0, "park", 0x40B, "the telescope cannot be parked")
+
+	metrics := NewRequestMetrics()
+
+	telescope := newTestLoggedTelescope(transport, nil)
+
+	telescope.Alpaca.Metrics = metrics
+
+	telescope.GetAltitude()
+
+	telescope.GetAltitude()
+
+	telescope.SetPark()
+
+	telescope.Alpaca.Transport = failingTransport{}
+
+	telescope.GetAltitude()
+
+	recorder := httptest.NewRecorder()
+
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("got %q, wanted the Prometheus text format", got)
+	}
+
+	for _, want := range []string{
+		`alpaca_requests_total{device_type="telescope",device_number="0",method="altitude"} 3`,
+		`alpaca_transport_failures_total{device_type="telescope",device_number="0",method="altitude"} 1`,
+		`alpaca_requests_total{device_type="telescope",device_number="0",method="park"} 1`,
+		`alpaca_ascom_errors_total{device_type="telescope",device_number="0",method="park",error_number="1035"} 1`,
+	} {
+		if !strings.Contains(recorder.Body.String(), want+"\n") {
+			t.Errorf("got %q, wanted a line %q", recorder.Body.String(), want)
+		}
+	}
+}
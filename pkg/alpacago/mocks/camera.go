@@ -0,0 +1,821 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+Camera is a mock alpacago.CameraAPI.
+*/
+type Camera struct {
+	Recorder
+
+	AbortExposureFunc                         func() error
+	CanAbortExposureFunc                      func() (bool, error)
+	CanAsymmetricBinFunc                      func() (bool, error)
+	CanFastReadoutFunc                        func() (bool, error)
+	CanGetCoolerPowerFunc                     func() (bool, error)
+	CanPulseGuideFunc                         func() (bool, error)
+	CanSetCCDTemperatureFunc                  func() (bool, error)
+	CanStopExposureFunc                       func() (bool, error)
+	DiagnoseFunc                              func(context.Context) alpacago.DiagnosticReport
+	DisableFastReadoutFunc                    func() error
+	EnableFastReadoutFunc                     func() error
+	GetBayerOffsetXFunc                       func() (int32, error)
+	GetBayerOffsetYFunc                       func() (int32, error)
+	GetBinXFunc                               func() (int32, error)
+	GetBinYFunc                               func() (int32, error)
+	GetCCDSizeXFunc                           func() (int32, error)
+	GetCCDSizeYFunc                           func() (int32, error)
+	GetCCDTemperatureFunc                     func() (float64, error)
+	GetCCDTemperatureCoolerSetPointFunc       func() (float64, error)
+	GetCoolerPowerLevelFunc                   func() (float64, error)
+	GetCurrentOperationPercentageCompleteFunc func() (int32, error)
+	GetExposureFunc                           func() ([][]uint32, uint32, error)
+	GetExposureMaxFunc                        func() (float64, error)
+	GetExposureMinFunc                        func() (float64, error)
+	GetExposureResolutionFunc                 func() (float64, error)
+	GetFullWellCapacityFunc                   func() (float64, error)
+	GetGainFunc                               func() (int32, error)
+	GetGainInElectronsPerADUnitFunc           func() (float64, error)
+	GetGainMaxFunc                            func() (int32, error)
+	GetGainMinFunc                            func() (int32, error)
+	GetGainsFunc                              func() ([]string, error)
+	GetHeatSinkTemperatureFunc                func() (float64, error)
+	GetLastExposureDurationFunc               func() (float64, error)
+	GetLastExposureStartTimeFunc              func() (*time.Time, error)
+	GetMaxADUFunc                             func() (int32, error)
+	GetMaxBinXFunc                            func() (int32, error)
+	GetMaxBinYFunc                            func() (int32, error)
+	GetOffsetFunc                             func() (int32, error)
+	GetOperationalStateFunc                   func() (string, error)
+	GetPixelSizeXFunc                         func() (float64, error)
+	GetPixelSizeYFunc                         func() (float64, error)
+	GetReadOutModeFunc                        func() (int32, error)
+	GetReadOutModesFunc                       func() ([]string, error)
+	GetSensorNameFunc                         func() (string, error)
+	GetSensorTypeFunc                         func() (alpacago.SensorType, error)
+	GetStartXFunc                             func() (int32, error)
+	GetStartYFunc                             func() (int32, error)
+	GetSubExposureDurationFunc                func() (float64, error)
+	GetSubFrameHeightFunc                     func() (int32, error)
+	GetSubFrameWidthFunc                      func() (int32, error)
+	HasShutterFunc                            func() (bool, error)
+	IsConnectedFunc                           func() (bool, error)
+	IsCoolerOnFunc                            func() (bool, error)
+	IsFastReadoutEnabledFunc                  func() (bool, error)
+	IsImageReadyFunc                          func() (bool, error)
+	IsPulseGuidingFunc                        func() (bool, error)
+	SetBinXFunc                               func(int32) error
+	SetBinYFunc                               func(int32) error
+	SetCCDTemperatureCoolerSetPointFunc       func(float64) error
+	SetConnectedFunc                          func(bool) error
+	SetGainFunc                               func(int32) error
+	SetOffsetFunc                             func(int32) error
+	SetPulseGuideFunc                         func(alpacago.Direction, int32) error
+	SetReadOutModeFunc                        func(int32) error
+	SetStartXFunc                             func(int32) error
+	SetStartYFunc                             func(int32) error
+	SetSubExposureDurationFunc                func(float64) error
+	SetSubFrameHeightFunc                     func(int32) error
+	SetSubFrameWidthFunc                      func(int32) error
+	StartExposureFunc                         func(float64, bool) error
+	StopExposureFunc                          func() error
+	TurnCoolerOffFunc                         func() error
+	TurnCoolerOnFunc                          func() error
+}
+
+func (m *Camera) AbortExposure() (r0 error) {
+	m.record("AbortExposure")
+
+	if m.AbortExposureFunc != nil {
+		return m.AbortExposureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanAbortExposure() (r0 bool, r1 error) {
+	m.record("CanAbortExposure")
+
+	if m.CanAbortExposureFunc != nil {
+		return m.CanAbortExposureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanAsymmetricBin() (r0 bool, r1 error) {
+	m.record("CanAsymmetricBin")
+
+	if m.CanAsymmetricBinFunc != nil {
+		return m.CanAsymmetricBinFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanFastReadout() (r0 bool, r1 error) {
+	m.record("CanFastReadout")
+
+	if m.CanFastReadoutFunc != nil {
+		return m.CanFastReadoutFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanGetCoolerPower() (r0 bool, r1 error) {
+	m.record("CanGetCoolerPower")
+
+	if m.CanGetCoolerPowerFunc != nil {
+		return m.CanGetCoolerPowerFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanPulseGuide() (r0 bool, r1 error) {
+	m.record("CanPulseGuide")
+
+	if m.CanPulseGuideFunc != nil {
+		return m.CanPulseGuideFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanSetCCDTemperature() (r0 bool, r1 error) {
+	m.record("CanSetCCDTemperature")
+
+	if m.CanSetCCDTemperatureFunc != nil {
+		return m.CanSetCCDTemperatureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) CanStopExposure() (r0 bool, r1 error) {
+	m.record("CanStopExposure")
+
+	if m.CanStopExposureFunc != nil {
+		return m.CanStopExposureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *Camera) DisableFastReadout() (r0 error) {
+	m.record("DisableFastReadout")
+
+	if m.DisableFastReadoutFunc != nil {
+		return m.DisableFastReadoutFunc()
+	}
+
+	return
+}
+
+func (m *Camera) EnableFastReadout() (r0 error) {
+	m.record("EnableFastReadout")
+
+	if m.EnableFastReadoutFunc != nil {
+		return m.EnableFastReadoutFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetBayerOffsetX() (r0 int32, r1 error) {
+	m.record("GetBayerOffsetX")
+
+	if m.GetBayerOffsetXFunc != nil {
+		return m.GetBayerOffsetXFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetBayerOffsetY() (r0 int32, r1 error) {
+	m.record("GetBayerOffsetY")
+
+	if m.GetBayerOffsetYFunc != nil {
+		return m.GetBayerOffsetYFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetBinX() (r0 int32, r1 error) {
+	m.record("GetBinX")
+
+	if m.GetBinXFunc != nil {
+		return m.GetBinXFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetBinY() (r0 int32, r1 error) {
+	m.record("GetBinY")
+
+	if m.GetBinYFunc != nil {
+		return m.GetBinYFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetCCDSizeX() (r0 int32, r1 error) {
+	m.record("GetCCDSizeX")
+
+	if m.GetCCDSizeXFunc != nil {
+		return m.GetCCDSizeXFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetCCDSizeY() (r0 int32, r1 error) {
+	m.record("GetCCDSizeY")
+
+	if m.GetCCDSizeYFunc != nil {
+		return m.GetCCDSizeYFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetCCDTemperature() (r0 float64, r1 error) {
+	m.record("GetCCDTemperature")
+
+	if m.GetCCDTemperatureFunc != nil {
+		return m.GetCCDTemperatureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetCCDTemperatureCoolerSetPoint() (r0 float64, r1 error) {
+	m.record("GetCCDTemperatureCoolerSetPoint")
+
+	if m.GetCCDTemperatureCoolerSetPointFunc != nil {
+		return m.GetCCDTemperatureCoolerSetPointFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetCoolerPowerLevel() (r0 float64, r1 error) {
+	m.record("GetCoolerPowerLevel")
+
+	if m.GetCoolerPowerLevelFunc != nil {
+		return m.GetCoolerPowerLevelFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetCurrentOperationPercentageComplete() (r0 int32, r1 error) {
+	m.record("GetCurrentOperationPercentageComplete")
+
+	if m.GetCurrentOperationPercentageCompleteFunc != nil {
+		return m.GetCurrentOperationPercentageCompleteFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetExposure() (r0 [][]uint32, r1 uint32, r2 error) {
+	m.record("GetExposure")
+
+	if m.GetExposureFunc != nil {
+		return m.GetExposureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetExposureMax() (r0 float64, r1 error) {
+	m.record("GetExposureMax")
+
+	if m.GetExposureMaxFunc != nil {
+		return m.GetExposureMaxFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetExposureMin() (r0 float64, r1 error) {
+	m.record("GetExposureMin")
+
+	if m.GetExposureMinFunc != nil {
+		return m.GetExposureMinFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetExposureResolution() (r0 float64, r1 error) {
+	m.record("GetExposureResolution")
+
+	if m.GetExposureResolutionFunc != nil {
+		return m.GetExposureResolutionFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetFullWellCapacity() (r0 float64, r1 error) {
+	m.record("GetFullWellCapacity")
+
+	if m.GetFullWellCapacityFunc != nil {
+		return m.GetFullWellCapacityFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetGain() (r0 int32, r1 error) {
+	m.record("GetGain")
+
+	if m.GetGainFunc != nil {
+		return m.GetGainFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetGainInElectronsPerADUnit() (r0 float64, r1 error) {
+	m.record("GetGainInElectronsPerADUnit")
+
+	if m.GetGainInElectronsPerADUnitFunc != nil {
+		return m.GetGainInElectronsPerADUnitFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetGainMax() (r0 int32, r1 error) {
+	m.record("GetGainMax")
+
+	if m.GetGainMaxFunc != nil {
+		return m.GetGainMaxFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetGainMin() (r0 int32, r1 error) {
+	m.record("GetGainMin")
+
+	if m.GetGainMinFunc != nil {
+		return m.GetGainMinFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetGains() (r0 []string, r1 error) {
+	m.record("GetGains")
+
+	if m.GetGainsFunc != nil {
+		return m.GetGainsFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetHeatSinkTemperature() (r0 float64, r1 error) {
+	m.record("GetHeatSinkTemperature")
+
+	if m.GetHeatSinkTemperatureFunc != nil {
+		return m.GetHeatSinkTemperatureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetLastExposureDuration() (r0 float64, r1 error) {
+	m.record("GetLastExposureDuration")
+
+	if m.GetLastExposureDurationFunc != nil {
+		return m.GetLastExposureDurationFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetLastExposureStartTime() (r0 *time.Time, r1 error) {
+	m.record("GetLastExposureStartTime")
+
+	if m.GetLastExposureStartTimeFunc != nil {
+		return m.GetLastExposureStartTimeFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetMaxADU() (r0 int32, r1 error) {
+	m.record("GetMaxADU")
+
+	if m.GetMaxADUFunc != nil {
+		return m.GetMaxADUFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetMaxBinX() (r0 int32, r1 error) {
+	m.record("GetMaxBinX")
+
+	if m.GetMaxBinXFunc != nil {
+		return m.GetMaxBinXFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetMaxBinY() (r0 int32, r1 error) {
+	m.record("GetMaxBinY")
+
+	if m.GetMaxBinYFunc != nil {
+		return m.GetMaxBinYFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetOffset() (r0 int32, r1 error) {
+	m.record("GetOffset")
+
+	if m.GetOffsetFunc != nil {
+		return m.GetOffsetFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetOperationalState() (r0 string, r1 error) {
+	m.record("GetOperationalState")
+
+	if m.GetOperationalStateFunc != nil {
+		return m.GetOperationalStateFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetPixelSizeX() (r0 float64, r1 error) {
+	m.record("GetPixelSizeX")
+
+	if m.GetPixelSizeXFunc != nil {
+		return m.GetPixelSizeXFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetPixelSizeY() (r0 float64, r1 error) {
+	m.record("GetPixelSizeY")
+
+	if m.GetPixelSizeYFunc != nil {
+		return m.GetPixelSizeYFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetReadOutMode() (r0 int32, r1 error) {
+	m.record("GetReadOutMode")
+
+	if m.GetReadOutModeFunc != nil {
+		return m.GetReadOutModeFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetReadOutModes() (r0 []string, r1 error) {
+	m.record("GetReadOutModes")
+
+	if m.GetReadOutModesFunc != nil {
+		return m.GetReadOutModesFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetSensorName() (r0 string, r1 error) {
+	m.record("GetSensorName")
+
+	if m.GetSensorNameFunc != nil {
+		return m.GetSensorNameFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetSensorType() (r0 alpacago.SensorType, r1 error) {
+	m.record("GetSensorType")
+
+	if m.GetSensorTypeFunc != nil {
+		return m.GetSensorTypeFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetStartX() (r0 int32, r1 error) {
+	m.record("GetStartX")
+
+	if m.GetStartXFunc != nil {
+		return m.GetStartXFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetStartY() (r0 int32, r1 error) {
+	m.record("GetStartY")
+
+	if m.GetStartYFunc != nil {
+		return m.GetStartYFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetSubExposureDuration() (r0 float64, r1 error) {
+	m.record("GetSubExposureDuration")
+
+	if m.GetSubExposureDurationFunc != nil {
+		return m.GetSubExposureDurationFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetSubFrameHeight() (r0 int32, r1 error) {
+	m.record("GetSubFrameHeight")
+
+	if m.GetSubFrameHeightFunc != nil {
+		return m.GetSubFrameHeightFunc()
+	}
+
+	return
+}
+
+func (m *Camera) GetSubFrameWidth() (r0 int32, r1 error) {
+	m.record("GetSubFrameWidth")
+
+	if m.GetSubFrameWidthFunc != nil {
+		return m.GetSubFrameWidthFunc()
+	}
+
+	return
+}
+
+func (m *Camera) HasShutter() (r0 bool, r1 error) {
+	m.record("HasShutter")
+
+	if m.HasShutterFunc != nil {
+		return m.HasShutterFunc()
+	}
+
+	return
+}
+
+func (m *Camera) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *Camera) IsCoolerOn() (r0 bool, r1 error) {
+	m.record("IsCoolerOn")
+
+	if m.IsCoolerOnFunc != nil {
+		return m.IsCoolerOnFunc()
+	}
+
+	return
+}
+
+func (m *Camera) IsFastReadoutEnabled() (r0 bool, r1 error) {
+	m.record("IsFastReadoutEnabled")
+
+	if m.IsFastReadoutEnabledFunc != nil {
+		return m.IsFastReadoutEnabledFunc()
+	}
+
+	return
+}
+
+func (m *Camera) IsImageReady() (r0 bool, r1 error) {
+	m.record("IsImageReady")
+
+	if m.IsImageReadyFunc != nil {
+		return m.IsImageReadyFunc()
+	}
+
+	return
+}
+
+func (m *Camera) IsPulseGuiding() (r0 bool, r1 error) {
+	m.record("IsPulseGuiding")
+
+	if m.IsPulseGuidingFunc != nil {
+		return m.IsPulseGuidingFunc()
+	}
+
+	return
+}
+
+func (m *Camera) SetBinX(binX int32) (r0 error) {
+	m.record("SetBinX", binX)
+
+	if m.SetBinXFunc != nil {
+		return m.SetBinXFunc(binX)
+	}
+
+	return
+}
+
+func (m *Camera) SetBinY(binY int32) (r0 error) {
+	m.record("SetBinY", binY)
+
+	if m.SetBinYFunc != nil {
+		return m.SetBinYFunc(binY)
+	}
+
+	return
+}
+
+func (m *Camera) SetCCDTemperatureCoolerSetPoint(temperature float64) (r0 error) {
+	m.record("SetCCDTemperatureCoolerSetPoint", temperature)
+
+	if m.SetCCDTemperatureCoolerSetPointFunc != nil {
+		return m.SetCCDTemperatureCoolerSetPointFunc(temperature)
+	}
+
+	return
+}
+
+func (m *Camera) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *Camera) SetGain(gain int32) (r0 error) {
+	m.record("SetGain", gain)
+
+	if m.SetGainFunc != nil {
+		return m.SetGainFunc(gain)
+	}
+
+	return
+}
+
+func (m *Camera) SetOffset(offset int32) (r0 error) {
+	m.record("SetOffset", offset)
+
+	if m.SetOffsetFunc != nil {
+		return m.SetOffsetFunc(offset)
+	}
+
+	return
+}
+
+func (m *Camera) SetPulseGuide(direction alpacago.Direction, duration int32) (r0 error) {
+	m.record("SetPulseGuide", direction, duration)
+
+	if m.SetPulseGuideFunc != nil {
+		return m.SetPulseGuideFunc(direction, duration)
+	}
+
+	return
+}
+
+func (m *Camera) SetReadOutMode(readOutMode int32) (r0 error) {
+	m.record("SetReadOutMode", readOutMode)
+
+	if m.SetReadOutModeFunc != nil {
+		return m.SetReadOutModeFunc(readOutMode)
+	}
+
+	return
+}
+
+func (m *Camera) SetStartX(startX int32) (r0 error) {
+	m.record("SetStartX", startX)
+
+	if m.SetStartXFunc != nil {
+		return m.SetStartXFunc(startX)
+	}
+
+	return
+}
+
+func (m *Camera) SetStartY(startY int32) (r0 error) {
+	m.record("SetStartY", startY)
+
+	if m.SetStartYFunc != nil {
+		return m.SetStartYFunc(startY)
+	}
+
+	return
+}
+
+func (m *Camera) SetSubExposureDuration(subExposureDuration float64) (r0 error) {
+	m.record("SetSubExposureDuration", subExposureDuration)
+
+	if m.SetSubExposureDurationFunc != nil {
+		return m.SetSubExposureDurationFunc(subExposureDuration)
+	}
+
+	return
+}
+
+func (m *Camera) SetSubFrameHeight(numY int32) (r0 error) {
+	m.record("SetSubFrameHeight", numY)
+
+	if m.SetSubFrameHeightFunc != nil {
+		return m.SetSubFrameHeightFunc(numY)
+	}
+
+	return
+}
+
+func (m *Camera) SetSubFrameWidth(numX int32) (r0 error) {
+	m.record("SetSubFrameWidth", numX)
+
+	if m.SetSubFrameWidthFunc != nil {
+		return m.SetSubFrameWidthFunc(numX)
+	}
+
+	return
+}
+
+func (m *Camera) StartExposure(duration float64, light bool) (r0 error) {
+	m.record("StartExposure", duration, light)
+
+	if m.StartExposureFunc != nil {
+		return m.StartExposureFunc(duration, light)
+	}
+
+	return
+}
+
+func (m *Camera) StopExposure() (r0 error) {
+	m.record("StopExposure")
+
+	if m.StopExposureFunc != nil {
+		return m.StopExposureFunc()
+	}
+
+	return
+}
+
+func (m *Camera) TurnCoolerOff() (r0 error) {
+	m.record("TurnCoolerOff")
+
+	if m.TurnCoolerOffFunc != nil {
+		return m.TurnCoolerOffFunc()
+	}
+
+	return
+}
+
+func (m *Camera) TurnCoolerOn() (r0 error) {
+	m.record("TurnCoolerOn")
+
+	if m.TurnCoolerOnFunc != nil {
+		return m.TurnCoolerOnFunc()
+	}
+
+	return
+}
+
+var _ alpacago.CameraAPI = (*Camera)(nil)
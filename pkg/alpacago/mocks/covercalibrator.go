@@ -0,0 +1,149 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+CoverCalibrator is a mock alpacago.CoverCalibratorAPI.
+*/
+type CoverCalibrator struct {
+	Recorder
+
+	CloseCoverFunc       func() error
+	DiagnoseFunc         func(context.Context) alpacago.DiagnosticReport
+	GetBrightnessFunc    func() (float64, error)
+	GetCoverStatusFunc   func() (string, error)
+	GetMaxBrightnessFunc func() (int32, error)
+	GetStatusFunc        func() (alpacago.CalibratorState, error)
+	HaltCoverFunc        func() error
+	IsConnectedFunc      func() (bool, error)
+	OpenCoverFunc        func() error
+	SetCalibratorOffFunc func() error
+	SetCalibratorOnFunc  func(int32) error
+	SetConnectedFunc     func(bool) error
+}
+
+func (m *CoverCalibrator) CloseCover() (r0 error) {
+	m.record("CloseCover")
+
+	if m.CloseCoverFunc != nil {
+		return m.CloseCoverFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) GetBrightness() (r0 float64, r1 error) {
+	m.record("GetBrightness")
+
+	if m.GetBrightnessFunc != nil {
+		return m.GetBrightnessFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) GetCoverStatus() (r0 string, r1 error) {
+	m.record("GetCoverStatus")
+
+	if m.GetCoverStatusFunc != nil {
+		return m.GetCoverStatusFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) GetMaxBrightness() (r0 int32, r1 error) {
+	m.record("GetMaxBrightness")
+
+	if m.GetMaxBrightnessFunc != nil {
+		return m.GetMaxBrightnessFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) GetStatus() (r0 alpacago.CalibratorState, r1 error) {
+	m.record("GetStatus")
+
+	if m.GetStatusFunc != nil {
+		return m.GetStatusFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) HaltCover() (r0 error) {
+	m.record("HaltCover")
+
+	if m.HaltCoverFunc != nil {
+		return m.HaltCoverFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) OpenCover() (r0 error) {
+	m.record("OpenCover")
+
+	if m.OpenCoverFunc != nil {
+		return m.OpenCoverFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) SetCalibratorOff() (r0 error) {
+	m.record("SetCalibratorOff")
+
+	if m.SetCalibratorOffFunc != nil {
+		return m.SetCalibratorOffFunc()
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) SetCalibratorOn(brightness int32) (r0 error) {
+	m.record("SetCalibratorOn", brightness)
+
+	if m.SetCalibratorOnFunc != nil {
+		return m.SetCalibratorOnFunc(brightness)
+	}
+
+	return
+}
+
+func (m *CoverCalibrator) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+var _ alpacago.CoverCalibratorAPI = (*CoverCalibrator)(nil)
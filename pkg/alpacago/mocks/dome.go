@@ -0,0 +1,325 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+Dome is a mock alpacago.DomeAPI.
+*/
+type Dome struct {
+	Recorder
+
+	AbortSlewFunc        func() error
+	CanFindHomeFunc      func() (bool, error)
+	CanParkFunc          func() (bool, error)
+	CanSetAltitudeFunc   func() (bool, error)
+	CanSetAzimuthFunc    func() (bool, error)
+	CanSetParkFunc       func() (bool, error)
+	CanSetShutterFunc    func() (bool, error)
+	CanSlaveFunc         func() (bool, error)
+	CanSyncAzimuthFunc   func() (bool, error)
+	CloseShutterFunc     func() error
+	DiagnoseFunc         func(context.Context) alpacago.DiagnosticReport
+	FindHomeFunc         func() error
+	GetAltitudeFunc      func() (float64, error)
+	GetAzimuthFunc       func() (float64, error)
+	GetShutterStatusFunc func() (string, error)
+	IsAtHomeFunc         func() (bool, error)
+	IsAtParkFunc         func() (bool, error)
+	IsConnectedFunc      func() (bool, error)
+	IsSlavedFunc         func() (bool, error)
+	IsSlewingFunc        func() (bool, error)
+	OpenShutterFunc      func() error
+	ParkFunc             func() error
+	SetAsParkFunc        func() error
+	SetConnectedFunc     func(bool) error
+	SetSlavedFunc        func(bool) error
+	SlewToAltitudeFunc   func(float64) error
+	SlewToAzimuthFunc    func(float64) error
+	SyncToAzimuthFunc    func(float64) error
+}
+
+func (m *Dome) AbortSlew() (r0 error) {
+	m.record("AbortSlew")
+
+	if m.AbortSlewFunc != nil {
+		return m.AbortSlewFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanFindHome() (r0 bool, r1 error) {
+	m.record("CanFindHome")
+
+	if m.CanFindHomeFunc != nil {
+		return m.CanFindHomeFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanPark() (r0 bool, r1 error) {
+	m.record("CanPark")
+
+	if m.CanParkFunc != nil {
+		return m.CanParkFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanSetAltitude() (r0 bool, r1 error) {
+	m.record("CanSetAltitude")
+
+	if m.CanSetAltitudeFunc != nil {
+		return m.CanSetAltitudeFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanSetAzimuth() (r0 bool, r1 error) {
+	m.record("CanSetAzimuth")
+
+	if m.CanSetAzimuthFunc != nil {
+		return m.CanSetAzimuthFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanSetPark() (r0 bool, r1 error) {
+	m.record("CanSetPark")
+
+	if m.CanSetParkFunc != nil {
+		return m.CanSetParkFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanSetShutter() (r0 bool, r1 error) {
+	m.record("CanSetShutter")
+
+	if m.CanSetShutterFunc != nil {
+		return m.CanSetShutterFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanSlave() (r0 bool, r1 error) {
+	m.record("CanSlave")
+
+	if m.CanSlaveFunc != nil {
+		return m.CanSlaveFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CanSyncAzimuth() (r0 bool, r1 error) {
+	m.record("CanSyncAzimuth")
+
+	if m.CanSyncAzimuthFunc != nil {
+		return m.CanSyncAzimuthFunc()
+	}
+
+	return
+}
+
+func (m *Dome) CloseShutter() (r0 error) {
+	m.record("CloseShutter")
+
+	if m.CloseShutterFunc != nil {
+		return m.CloseShutterFunc()
+	}
+
+	return
+}
+
+func (m *Dome) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *Dome) FindHome() (r0 error) {
+	m.record("FindHome")
+
+	if m.FindHomeFunc != nil {
+		return m.FindHomeFunc()
+	}
+
+	return
+}
+
+func (m *Dome) GetAltitude() (r0 float64, r1 error) {
+	m.record("GetAltitude")
+
+	if m.GetAltitudeFunc != nil {
+		return m.GetAltitudeFunc()
+	}
+
+	return
+}
+
+func (m *Dome) GetAzimuth() (r0 float64, r1 error) {
+	m.record("GetAzimuth")
+
+	if m.GetAzimuthFunc != nil {
+		return m.GetAzimuthFunc()
+	}
+
+	return
+}
+
+func (m *Dome) GetShutterStatus() (r0 string, r1 error) {
+	m.record("GetShutterStatus")
+
+	if m.GetShutterStatusFunc != nil {
+		return m.GetShutterStatusFunc()
+	}
+
+	return
+}
+
+func (m *Dome) IsAtHome() (r0 bool, r1 error) {
+	m.record("IsAtHome")
+
+	if m.IsAtHomeFunc != nil {
+		return m.IsAtHomeFunc()
+	}
+
+	return
+}
+
+func (m *Dome) IsAtPark() (r0 bool, r1 error) {
+	m.record("IsAtPark")
+
+	if m.IsAtParkFunc != nil {
+		return m.IsAtParkFunc()
+	}
+
+	return
+}
+
+func (m *Dome) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *Dome) IsSlaved() (r0 bool, r1 error) {
+	m.record("IsSlaved")
+
+	if m.IsSlavedFunc != nil {
+		return m.IsSlavedFunc()
+	}
+
+	return
+}
+
+func (m *Dome) IsSlewing() (r0 bool, r1 error) {
+	m.record("IsSlewing")
+
+	if m.IsSlewingFunc != nil {
+		return m.IsSlewingFunc()
+	}
+
+	return
+}
+
+func (m *Dome) OpenShutter() (r0 error) {
+	m.record("OpenShutter")
+
+	if m.OpenShutterFunc != nil {
+		return m.OpenShutterFunc()
+	}
+
+	return
+}
+
+func (m *Dome) Park() (r0 error) {
+	m.record("Park")
+
+	if m.ParkFunc != nil {
+		return m.ParkFunc()
+	}
+
+	return
+}
+
+func (m *Dome) SetAsPark() (r0 error) {
+	m.record("SetAsPark")
+
+	if m.SetAsParkFunc != nil {
+		return m.SetAsParkFunc()
+	}
+
+	return
+}
+
+func (m *Dome) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *Dome) SetSlaved(slaved bool) (r0 error) {
+	m.record("SetSlaved", slaved)
+
+	if m.SetSlavedFunc != nil {
+		return m.SetSlavedFunc(slaved)
+	}
+
+	return
+}
+
+func (m *Dome) SlewToAltitude(altitude float64) (r0 error) {
+	m.record("SlewToAltitude", altitude)
+
+	if m.SlewToAltitudeFunc != nil {
+		return m.SlewToAltitudeFunc(altitude)
+	}
+
+	return
+}
+
+func (m *Dome) SlewToAzimuth(azimuth float64) (r0 error) {
+	m.record("SlewToAzimuth", azimuth)
+
+	if m.SlewToAzimuthFunc != nil {
+		return m.SlewToAzimuthFunc(azimuth)
+	}
+
+	return
+}
+
+func (m *Dome) SyncToAzimuth(azimuth float64) (r0 error) {
+	m.record("SyncToAzimuth", azimuth)
+
+	if m.SyncToAzimuthFunc != nil {
+		return m.SyncToAzimuthFunc(azimuth)
+	}
+
+	return
+}
+
+var _ alpacago.DomeAPI = (*Dome)(nil)
@@ -0,0 +1,105 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+FilterWheel is a mock alpacago.FilterWheelAPI.
+*/
+type FilterWheel struct {
+	Recorder
+
+	DiagnoseFunc        func(context.Context) alpacago.DiagnosticReport
+	GetDescriptionFunc  func() (string, error)
+	GetFocusOffsetsFunc func() ([]uint32, error)
+	GetNamesFunc        func() ([]string, error)
+	GetPositionFunc     func() (int32, error)
+	IsConnectedFunc     func() (bool, error)
+	SetConnectedFunc    func(bool) error
+	SetPositionFunc     func(int32) error
+}
+
+func (m *FilterWheel) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *FilterWheel) GetDescription() (r0 string, r1 error) {
+	m.record("GetDescription")
+
+	if m.GetDescriptionFunc != nil {
+		return m.GetDescriptionFunc()
+	}
+
+	return
+}
+
+func (m *FilterWheel) GetFocusOffsets() (r0 []uint32, r1 error) {
+	m.record("GetFocusOffsets")
+
+	if m.GetFocusOffsetsFunc != nil {
+		return m.GetFocusOffsetsFunc()
+	}
+
+	return
+}
+
+func (m *FilterWheel) GetNames() (r0 []string, r1 error) {
+	m.record("GetNames")
+
+	if m.GetNamesFunc != nil {
+		return m.GetNamesFunc()
+	}
+
+	return
+}
+
+func (m *FilterWheel) GetPosition() (r0 int32, r1 error) {
+	m.record("GetPosition")
+
+	if m.GetPositionFunc != nil {
+		return m.GetPositionFunc()
+	}
+
+	return
+}
+
+func (m *FilterWheel) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *FilterWheel) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *FilterWheel) SetPosition(position int32) (r0 error) {
+	m.record("SetPosition", position)
+
+	if m.SetPositionFunc != nil {
+		return m.SetPositionFunc(position)
+	}
+
+	return
+}
+
+var _ alpacago.FilterWheelAPI = (*FilterWheel)(nil)
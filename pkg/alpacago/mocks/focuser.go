@@ -0,0 +1,193 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+Focuser is a mock alpacago.FocuserAPI.
+*/
+type Focuser struct {
+	Recorder
+
+	DiagnoseFunc                           func(context.Context) alpacago.DiagnosticReport
+	GetDescriptionFunc                     func() (string, error)
+	GetMaxIncrementFunc                    func() (int32, error)
+	GetMaxStepFunc                         func() (int32, error)
+	GetPositionFunc                        func() (int32, error)
+	GetStepSizeFunc                        func() (float64, error)
+	GetTemperatureFunc                     func() (float64, error)
+	GetTemperatureCompensationFunc         func() (bool, error)
+	IsAbsoluteFunc                         func() (bool, error)
+	IsConnectedFunc                        func() (bool, error)
+	IsMovingFunc                           func() (bool, error)
+	IsTemperatureCompensationAvailableFunc func() (bool, error)
+	SetConnectedFunc                       func(bool) error
+	SetHaltFunc                            func() error
+	SetMoveFunc                            func(int32) error
+	SetTemperatureCompensationFunc         func(bool) error
+}
+
+func (m *Focuser) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *Focuser) GetDescription() (r0 string, r1 error) {
+	m.record("GetDescription")
+
+	if m.GetDescriptionFunc != nil {
+		return m.GetDescriptionFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) GetMaxIncrement() (r0 int32, r1 error) {
+	m.record("GetMaxIncrement")
+
+	if m.GetMaxIncrementFunc != nil {
+		return m.GetMaxIncrementFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) GetMaxStep() (r0 int32, r1 error) {
+	m.record("GetMaxStep")
+
+	if m.GetMaxStepFunc != nil {
+		return m.GetMaxStepFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) GetPosition() (r0 int32, r1 error) {
+	m.record("GetPosition")
+
+	if m.GetPositionFunc != nil {
+		return m.GetPositionFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) GetStepSize() (r0 float64, r1 error) {
+	m.record("GetStepSize")
+
+	if m.GetStepSizeFunc != nil {
+		return m.GetStepSizeFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) GetTemperature() (r0 float64, r1 error) {
+	m.record("GetTemperature")
+
+	if m.GetTemperatureFunc != nil {
+		return m.GetTemperatureFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) GetTemperatureCompensation() (r0 bool, r1 error) {
+	m.record("GetTemperatureCompensation")
+
+	if m.GetTemperatureCompensationFunc != nil {
+		return m.GetTemperatureCompensationFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) IsAbsolute() (r0 bool, r1 error) {
+	m.record("IsAbsolute")
+
+	if m.IsAbsoluteFunc != nil {
+		return m.IsAbsoluteFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) IsMoving() (r0 bool, r1 error) {
+	m.record("IsMoving")
+
+	if m.IsMovingFunc != nil {
+		return m.IsMovingFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) IsTemperatureCompensationAvailable() (r0 bool, r1 error) {
+	m.record("IsTemperatureCompensationAvailable")
+
+	if m.IsTemperatureCompensationAvailableFunc != nil {
+		return m.IsTemperatureCompensationAvailableFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *Focuser) SetHalt() (r0 error) {
+	m.record("SetHalt")
+
+	if m.SetHaltFunc != nil {
+		return m.SetHaltFunc()
+	}
+
+	return
+}
+
+func (m *Focuser) SetMove(position int32) (r0 error) {
+	m.record("SetMove", position)
+
+	if m.SetMoveFunc != nil {
+		return m.SetMoveFunc(position)
+	}
+
+	return
+}
+
+func (m *Focuser) SetTemperatureCompensation(tempComp bool) (r0 error) {
+	m.record("SetTemperatureCompensation", tempComp)
+
+	if m.SetTemperatureCompensationFunc != nil {
+		return m.SetTemperatureCompensationFunc(tempComp)
+	}
+
+	return
+}
+
+var _ alpacago.FocuserAPI = (*Focuser)(nil)
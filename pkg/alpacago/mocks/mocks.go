@@ -0,0 +1,77 @@
+// Package mocks provides mock implementations of the alpacago device APIs (e.g., alpacago.TelescopeAPI)
+// for unit tests. Each mock method calls its Func field, if set, or else returns zero values, and records
+// the call and its arguments.
+package mocks
+
+import "sync"
+
+/*
+Call is a single call of a mock method, with its arguments.
+*/
+type Call struct {
+	Method    string
+	Arguments []interface{}
+}
+
+/*
+Recorder records the calls of a mock, and is safe for concurrent use.
+*/
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) record(method string, arguments ...interface{}) {
+	r.mu.Lock()
+
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, Call{Method: method, Arguments: arguments})
+}
+
+/*
+GetCalls()
+
+@returns the calls of the mock, in order.
+*/
+func (r *Recorder) GetCalls() []Call {
+	r.mu.Lock()
+
+	defer r.mu.Unlock()
+
+	return append([]Call{}, r.calls...)
+}
+
+/*
+GetCallCount()
+
+@returns the number of calls of the mock's method.
+*/
+func (r *Recorder) GetCallCount(method string) int {
+	r.mu.Lock()
+
+	defer r.mu.Unlock()
+
+	count := 0
+
+	for _, call := range r.calls {
+		if call.Method == method {
+			count++
+		}
+	}
+
+	return count
+}
+
+/*
+Reset()
+
+Forgets the calls of the mock.
+*/
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
@@ -0,0 +1,97 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+func TestTelescopeFunc(t *testing.T) {
+	telescope := &Telescope{
+		GetAltitudeFunc: func() (float64, error) { return 45.5, nil },
+	}
+
+	var api alpacago.TelescopeAPI = telescope
+
+	got, err := api.GetAltitude()
+
+	if err != nil || got != 45.5 {
+		t.Errorf("got %f (%v), wanted 45.5", got, err)
+	}
+}
+
+func TestTelescopeZeroValues(t *testing.T) {
+	telescope := &Telescope{}
+
+	side, err := telescope.GetSideOfPier()
+
+	if err != nil || side != alpacago.PierPointingMode(0) {
+		t.Errorf("got %v (%v), wanted the zero value", side, err)
+	}
+
+	date, err := telescope.GetUTCDate()
+
+	if err != nil || !date.IsZero() {
+		t.Errorf("got %v (%v), wanted the zero time", date, err)
+	}
+}
+
+func TestRecorderCalls(t *testing.T) {
+	failure := errors.New("the telescope cannot be parked")
+
+	telescope := &Telescope{
+		SetParkFunc: func() error { return failure },
+	}
+
+	telescope.SetTracking(true)
+
+	telescope.SetSlewToCoordinatesAsync(56.85, 24.1)
+
+	if err := telescope.SetPark(); !errors.Is(err, failure) {
+		t.Errorf("got %v, wanted %v", err, failure)
+	}
+
+	calls := telescope.GetCalls()
+
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, wanted 3", len(calls))
+	}
+
+	if calls[1].Method != "SetSlewToCoordinatesAsync" || calls[1].Arguments[0] != 56.85 || calls[1].Arguments[1] != 24.1 {
+		t.Errorf("got %+v, wanted SetSlewToCoordinatesAsync(56.85, 24.1)", calls[1])
+	}
+
+	var got int = telescope.GetCallCount("SetPark")
+
+	var want int = 1
+
+	if got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+
+	telescope.Reset()
+
+	if len(telescope.GetCalls()) != 0 {
+		t.Errorf("got %d calls, wanted 0", len(telescope.GetCalls()))
+	}
+}
+
+func TestCameraCapture(t *testing.T) {
+	camera := &Camera{
+		IsImageReadyFunc: func() (bool, error) { return true, nil },
+		GetExposureFunc:  func() ([][]uint32, uint32, error) { return [][]uint32{{1, 2}, {3, 4}}, 2, nil },
+	}
+
+	image, err := alpacago.Capture(context.Background(), camera, 0.1, false, time.Millisecond)
+
+	if err != nil || len(image) != 2 {
+		t.Fatalf("got %v (%v), wanted a 2x2 image", image, err)
+	}
+
+	if camera.GetCallCount("StartExposure") != 1 {
+		t.Errorf("got %d exposures, wanted 1", camera.GetCallCount("StartExposure"))
+	}
+}
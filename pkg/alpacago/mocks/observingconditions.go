@@ -0,0 +1,237 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+ObservingConditions is a mock alpacago.ObservingConditionsAPI.
+*/
+type ObservingConditions struct {
+	Recorder
+
+	DiagnoseFunc               func(context.Context) alpacago.DiagnosticReport
+	GetAveragePeriodFunc       func() (float64, error)
+	GetCloudCoverFunc          func() (float64, error)
+	GetDewPointFunc            func() (float64, error)
+	GetHumidityFunc            func() (float64, error)
+	GetPressureFunc            func() (float64, error)
+	GetRainRateFunc            func() (float64, error)
+	GetSeeingStarFWHMFunc      func() (float64, error)
+	GetSensorDescriptionFunc   func(string) (string, error)
+	GetSkyBrightnessFunc       func() (float64, error)
+	GetSkyQualityFunc          func() (float64, error)
+	GetSkyTemperatureFunc      func() (float64, error)
+	GetTemperatureFunc         func() (float64, error)
+	GetTimeSinceLastUpdateFunc func(string) (float64, error)
+	GetWindDirectionFunc       func() (float64, error)
+	GetWindGustFunc            func() (float64, error)
+	GetWindSpeedFunc           func() (float64, error)
+	IsConnectedFunc            func() (bool, error)
+	SetConnectedFunc           func(bool) error
+	SetRefreshFunc             func() error
+}
+
+func (m *ObservingConditions) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetAveragePeriod() (r0 float64, r1 error) {
+	m.record("GetAveragePeriod")
+
+	if m.GetAveragePeriodFunc != nil {
+		return m.GetAveragePeriodFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetCloudCover() (r0 float64, r1 error) {
+	m.record("GetCloudCover")
+
+	if m.GetCloudCoverFunc != nil {
+		return m.GetCloudCoverFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetDewPoint() (r0 float64, r1 error) {
+	m.record("GetDewPoint")
+
+	if m.GetDewPointFunc != nil {
+		return m.GetDewPointFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetHumidity() (r0 float64, r1 error) {
+	m.record("GetHumidity")
+
+	if m.GetHumidityFunc != nil {
+		return m.GetHumidityFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetPressure() (r0 float64, r1 error) {
+	m.record("GetPressure")
+
+	if m.GetPressureFunc != nil {
+		return m.GetPressureFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetRainRate() (r0 float64, r1 error) {
+	m.record("GetRainRate")
+
+	if m.GetRainRateFunc != nil {
+		return m.GetRainRateFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetSeeingStarFWHM() (r0 float64, r1 error) {
+	m.record("GetSeeingStarFWHM")
+
+	if m.GetSeeingStarFWHMFunc != nil {
+		return m.GetSeeingStarFWHMFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetSensorDescription(sensorName string) (r0 string, r1 error) {
+	m.record("GetSensorDescription", sensorName)
+
+	if m.GetSensorDescriptionFunc != nil {
+		return m.GetSensorDescriptionFunc(sensorName)
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetSkyBrightness() (r0 float64, r1 error) {
+	m.record("GetSkyBrightness")
+
+	if m.GetSkyBrightnessFunc != nil {
+		return m.GetSkyBrightnessFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetSkyQuality() (r0 float64, r1 error) {
+	m.record("GetSkyQuality")
+
+	if m.GetSkyQualityFunc != nil {
+		return m.GetSkyQualityFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetSkyTemperature() (r0 float64, r1 error) {
+	m.record("GetSkyTemperature")
+
+	if m.GetSkyTemperatureFunc != nil {
+		return m.GetSkyTemperatureFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetTemperature() (r0 float64, r1 error) {
+	m.record("GetTemperature")
+
+	if m.GetTemperatureFunc != nil {
+		return m.GetTemperatureFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetTimeSinceLastUpdate(sensorName string) (r0 float64, r1 error) {
+	m.record("GetTimeSinceLastUpdate", sensorName)
+
+	if m.GetTimeSinceLastUpdateFunc != nil {
+		return m.GetTimeSinceLastUpdateFunc(sensorName)
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetWindDirection() (r0 float64, r1 error) {
+	m.record("GetWindDirection")
+
+	if m.GetWindDirectionFunc != nil {
+		return m.GetWindDirectionFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetWindGust() (r0 float64, r1 error) {
+	m.record("GetWindGust")
+
+	if m.GetWindGustFunc != nil {
+		return m.GetWindGustFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) GetWindSpeed() (r0 float64, r1 error) {
+	m.record("GetWindSpeed")
+
+	if m.GetWindSpeedFunc != nil {
+		return m.GetWindSpeedFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *ObservingConditions) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *ObservingConditions) SetRefresh() (r0 error) {
+	m.record("SetRefresh")
+
+	if m.SetRefreshFunc != nil {
+		return m.SetRefreshFunc()
+	}
+
+	return
+}
+
+var _ alpacago.ObservingConditionsAPI = (*ObservingConditions)(nil)
@@ -0,0 +1,204 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+Rotator is a mock alpacago.RotatorAPI.
+*/
+type Rotator struct {
+	Recorder
+
+	CanReverseFunc            func() (bool, error)
+	DiagnoseFunc              func(context.Context) alpacago.DiagnosticReport
+	GetDescriptionFunc        func() (string, error)
+	GetMechanicalPositionFunc func() (float64, error)
+	GetPositionFunc           func() (float64, error)
+	GetReverseFunc            func() (bool, error)
+	GetStepSizeFunc           func() (float64, error)
+	GetTargetPositionFunc     func() (float64, error)
+	IsConnectedFunc           func() (bool, error)
+	IsMovingFunc              func() (bool, error)
+	SetConnectedFunc          func(bool) error
+	SetHaltFunc               func() error
+	SetMoveFunc               func(float64) error
+	SetMoveAbsoluteFunc       func(float64) error
+	SetMoveMechanicalFunc     func(float64) error
+	SetReverseFunc            func(bool) error
+	SetSyncFunc               func(float64) error
+}
+
+func (m *Rotator) CanReverse() (r0 bool, r1 error) {
+	m.record("CanReverse")
+
+	if m.CanReverseFunc != nil {
+		return m.CanReverseFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *Rotator) GetDescription() (r0 string, r1 error) {
+	m.record("GetDescription")
+
+	if m.GetDescriptionFunc != nil {
+		return m.GetDescriptionFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) GetMechanicalPosition() (r0 float64, r1 error) {
+	m.record("GetMechanicalPosition")
+
+	if m.GetMechanicalPositionFunc != nil {
+		return m.GetMechanicalPositionFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) GetPosition() (r0 float64, r1 error) {
+	m.record("GetPosition")
+
+	if m.GetPositionFunc != nil {
+		return m.GetPositionFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) GetReverse() (r0 bool, r1 error) {
+	m.record("GetReverse")
+
+	if m.GetReverseFunc != nil {
+		return m.GetReverseFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) GetStepSize() (r0 float64, r1 error) {
+	m.record("GetStepSize")
+
+	if m.GetStepSizeFunc != nil {
+		return m.GetStepSizeFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) GetTargetPosition() (r0 float64, r1 error) {
+	m.record("GetTargetPosition")
+
+	if m.GetTargetPositionFunc != nil {
+		return m.GetTargetPositionFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) IsMoving() (r0 bool, r1 error) {
+	m.record("IsMoving")
+
+	if m.IsMovingFunc != nil {
+		return m.IsMovingFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *Rotator) SetHalt() (r0 error) {
+	m.record("SetHalt")
+
+	if m.SetHaltFunc != nil {
+		return m.SetHaltFunc()
+	}
+
+	return
+}
+
+func (m *Rotator) SetMove(position float64) (r0 error) {
+	m.record("SetMove", position)
+
+	if m.SetMoveFunc != nil {
+		return m.SetMoveFunc(position)
+	}
+
+	return
+}
+
+func (m *Rotator) SetMoveAbsolute(position float64) (r0 error) {
+	m.record("SetMoveAbsolute", position)
+
+	if m.SetMoveAbsoluteFunc != nil {
+		return m.SetMoveAbsoluteFunc(position)
+	}
+
+	return
+}
+
+func (m *Rotator) SetMoveMechanical(position float64) (r0 error) {
+	m.record("SetMoveMechanical", position)
+
+	if m.SetMoveMechanicalFunc != nil {
+		return m.SetMoveMechanicalFunc(position)
+	}
+
+	return
+}
+
+func (m *Rotator) SetReverse(reverse bool) (r0 error) {
+	m.record("SetReverse", reverse)
+
+	if m.SetReverseFunc != nil {
+		return m.SetReverseFunc(reverse)
+	}
+
+	return
+}
+
+func (m *Rotator) SetSync(position float64) (r0 error) {
+	m.record("SetSync", position)
+
+	if m.SetSyncFunc != nil {
+		return m.SetSyncFunc(position)
+	}
+
+	return
+}
+
+var _ alpacago.RotatorAPI = (*Rotator)(nil)
@@ -0,0 +1,72 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+SafetyMonitor is a mock alpacago.SafetyMonitorAPI.
+*/
+type SafetyMonitor struct {
+	Recorder
+
+	DiagnoseFunc       func(context.Context) alpacago.DiagnosticReport
+	GetDescriptionFunc func() (string, error)
+	IsConnectedFunc    func() (bool, error)
+	IsSafeFunc         func() (bool, error)
+	SetConnectedFunc   func(bool) error
+}
+
+func (m *SafetyMonitor) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *SafetyMonitor) GetDescription() (r0 string, r1 error) {
+	m.record("GetDescription")
+
+	if m.GetDescriptionFunc != nil {
+		return m.GetDescriptionFunc()
+	}
+
+	return
+}
+
+func (m *SafetyMonitor) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *SafetyMonitor) IsSafe() (r0 bool, r1 error) {
+	m.record("IsSafe")
+
+	if m.IsSafeFunc != nil {
+		return m.IsSafeFunc()
+	}
+
+	return
+}
+
+func (m *SafetyMonitor) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+var _ alpacago.SafetyMonitorAPI = (*SafetyMonitor)(nil)
@@ -0,0 +1,832 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+Telescope is a mock alpacago.TelescopeAPI.
+*/
+type Telescope struct {
+	Recorder
+
+	CanFindHomeFunc               func() (bool, error)
+	CanMoveAxisFunc               func(alpacago.AxisType) (bool, error)
+	CanParkFunc                   func() (bool, error)
+	CanPulseGuideFunc             func() (bool, error)
+	CanSetDeclinationRateFunc     func() (bool, error)
+	CanSetGuideRatesFunc          func() (bool, error)
+	CanSetParkFunc                func() (bool, error)
+	CanSetPierSideFunc            func() (bool, error)
+	CanSetRightAscensionRateFunc  func() (bool, error)
+	CanSetTrackingFunc            func() (bool, error)
+	CanSlewFunc                   func() (bool, error)
+	CanSlewAltAzFunc              func() (bool, error)
+	CanSlewAltAzAsyncFunc         func() (bool, error)
+	CanSlewAsyncFunc              func() (bool, error)
+	CanSyncFunc                   func() (bool, error)
+	CanSyncAltAzFunc              func() (bool, error)
+	CanUnParkFunc                 func() (bool, error)
+	DiagnoseFunc                  func(context.Context) alpacago.DiagnosticReport
+	DoesRefractionFunc            func() (bool, error)
+	FindHomeFunc                  func() error
+	GetAlignmentModeFunc          func() (string, error)
+	GetAltitudeFunc               func() (float64, error)
+	GetApertureAreaFunc           func() (float64, error)
+	GetApertureDiameterFunc       func() (float64, error)
+	GetAxisRatesFunc              func(alpacago.AxisType) (map[string]float64, error)
+	GetAzimuthFunc                func() (float64, error)
+	GetDeclinationFunc            func() (float64, error)
+	GetDeclinationRateFunc        func() (float64, error)
+	GetDescriptionFunc            func() (string, error)
+	GetDestinationSideOfPierFunc  func(float64, float64) (alpacago.PierPointingMode, error)
+	GetEquatorialSystemFunc       func() (string, error)
+	GetFocalLengthFunc            func() (float64, error)
+	GetRightAscensionFunc         func() (float64, error)
+	GetRightAscensionRateFunc     func() (float64, error)
+	GetSideOfPierFunc             func() (alpacago.PierPointingMode, error)
+	GetSiderealTimeFunc           func() (float64, error)
+	GetSiteElevationFunc          func() (float64, error)
+	GetSiteLatitudeFunc           func() (float64, error)
+	GetSiteLongitudeFunc          func() (float64, error)
+	GetSlewSettleTimeFunc         func() (int32, error)
+	GetTargetDeclinationFunc      func() (float64, error)
+	GetTargetRightAscensionFunc   func() (float64, error)
+	GetTrackingRateFunc           func() (int32, error)
+	GetUTCDateFunc                func() (time.Time, error)
+	IsAtHomeFunc                  func() (bool, error)
+	IsAtParkFunc                  func() (bool, error)
+	IsConnectedFunc               func() (bool, error)
+	IsPulseGuidingFunc            func() (bool, error)
+	IsSlewingFunc                 func() (bool, error)
+	IsTrackingFunc                func() (bool, error)
+	SetAbortSlewFunc              func() error
+	SetConnectedFunc              func(bool) error
+	SetDeclinationRateFunc        func(float64) error
+	SetDoesRefractionFunc         func(bool) error
+	SetParkFunc                   func() error
+	SetPulseGuideFunc             func(alpacago.Direction, int32) error
+	SetRightAscensionRateFunc     func(float64) error
+	SetSideOfPierFunc             func(alpacago.PierPointingMode) error
+	SetSiteElevationFunc          func(float64) error
+	SetSiteLatitudeFunc           func(float64) error
+	SetSiteLongitudeFunc          func(float64) error
+	SetSlewSettleTimeFunc         func(int32) error
+	SetSlewToAltAzFunc            func(float64, float64) error
+	SetSlewToAltAzAsyncFunc       func(float64, float64) error
+	SetSlewToCoordinatesFunc      func(float64, float64) error
+	SetSlewToCoordinatesAsyncFunc func(float64, float64) error
+	SetSlewToTargetFunc           func() error
+	SetSlewToTargetAsyncFunc      func() error
+	SetSyncToCoordinatesFunc      func(float64, float64) error
+	SetTargetDeclinationFunc      func(float64) error
+	SetTargetRightAscensionFunc   func(float64) error
+	SetTrackingFunc               func(bool) error
+	SetUTCDateFunc                func(time.Time) error
+	SetUnParkFunc                 func() error
+}
+
+func (m *Telescope) CanFindHome() (r0 bool, r1 error) {
+	m.record("CanFindHome")
+
+	if m.CanFindHomeFunc != nil {
+		return m.CanFindHomeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanMoveAxis(axis alpacago.AxisType) (r0 bool, r1 error) {
+	m.record("CanMoveAxis", axis)
+
+	if m.CanMoveAxisFunc != nil {
+		return m.CanMoveAxisFunc(axis)
+	}
+
+	return
+}
+
+func (m *Telescope) CanPark() (r0 bool, r1 error) {
+	m.record("CanPark")
+
+	if m.CanParkFunc != nil {
+		return m.CanParkFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanPulseGuide() (r0 bool, r1 error) {
+	m.record("CanPulseGuide")
+
+	if m.CanPulseGuideFunc != nil {
+		return m.CanPulseGuideFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSetDeclinationRate() (r0 bool, r1 error) {
+	m.record("CanSetDeclinationRate")
+
+	if m.CanSetDeclinationRateFunc != nil {
+		return m.CanSetDeclinationRateFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSetGuideRates() (r0 bool, r1 error) {
+	m.record("CanSetGuideRates")
+
+	if m.CanSetGuideRatesFunc != nil {
+		return m.CanSetGuideRatesFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSetPark() (r0 bool, r1 error) {
+	m.record("CanSetPark")
+
+	if m.CanSetParkFunc != nil {
+		return m.CanSetParkFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSetPierSide() (r0 bool, r1 error) {
+	m.record("CanSetPierSide")
+
+	if m.CanSetPierSideFunc != nil {
+		return m.CanSetPierSideFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSetRightAscensionRate() (r0 bool, r1 error) {
+	m.record("CanSetRightAscensionRate")
+
+	if m.CanSetRightAscensionRateFunc != nil {
+		return m.CanSetRightAscensionRateFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSetTracking() (r0 bool, r1 error) {
+	m.record("CanSetTracking")
+
+	if m.CanSetTrackingFunc != nil {
+		return m.CanSetTrackingFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSlew() (r0 bool, r1 error) {
+	m.record("CanSlew")
+
+	if m.CanSlewFunc != nil {
+		return m.CanSlewFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSlewAltAz() (r0 bool, r1 error) {
+	m.record("CanSlewAltAz")
+
+	if m.CanSlewAltAzFunc != nil {
+		return m.CanSlewAltAzFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSlewAltAzAsync() (r0 bool, r1 error) {
+	m.record("CanSlewAltAzAsync")
+
+	if m.CanSlewAltAzAsyncFunc != nil {
+		return m.CanSlewAltAzAsyncFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSlewAsync() (r0 bool, r1 error) {
+	m.record("CanSlewAsync")
+
+	if m.CanSlewAsyncFunc != nil {
+		return m.CanSlewAsyncFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSync() (r0 bool, r1 error) {
+	m.record("CanSync")
+
+	if m.CanSyncFunc != nil {
+		return m.CanSyncFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanSyncAltAz() (r0 bool, r1 error) {
+	m.record("CanSyncAltAz")
+
+	if m.CanSyncAltAzFunc != nil {
+		return m.CanSyncAltAzFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) CanUnPark() (r0 bool, r1 error) {
+	m.record("CanUnPark")
+
+	if m.CanUnParkFunc != nil {
+		return m.CanUnParkFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) Diagnose(ctx context.Context) (r0 alpacago.DiagnosticReport) {
+	m.record("Diagnose", ctx)
+
+	if m.DiagnoseFunc != nil {
+		return m.DiagnoseFunc(ctx)
+	}
+
+	return
+}
+
+func (m *Telescope) DoesRefraction() (r0 bool, r1 error) {
+	m.record("DoesRefraction")
+
+	if m.DoesRefractionFunc != nil {
+		return m.DoesRefractionFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) FindHome() (r0 error) {
+	m.record("FindHome")
+
+	if m.FindHomeFunc != nil {
+		return m.FindHomeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetAlignmentMode() (r0 string, r1 error) {
+	m.record("GetAlignmentMode")
+
+	if m.GetAlignmentModeFunc != nil {
+		return m.GetAlignmentModeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetAltitude() (r0 float64, r1 error) {
+	m.record("GetAltitude")
+
+	if m.GetAltitudeFunc != nil {
+		return m.GetAltitudeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetApertureArea() (r0 float64, r1 error) {
+	m.record("GetApertureArea")
+
+	if m.GetApertureAreaFunc != nil {
+		return m.GetApertureAreaFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetApertureDiameter() (r0 float64, r1 error) {
+	m.record("GetApertureDiameter")
+
+	if m.GetApertureDiameterFunc != nil {
+		return m.GetApertureDiameterFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetAxisRates(axis alpacago.AxisType) (r0 map[string]float64, r1 error) {
+	m.record("GetAxisRates", axis)
+
+	if m.GetAxisRatesFunc != nil {
+		return m.GetAxisRatesFunc(axis)
+	}
+
+	return
+}
+
+func (m *Telescope) GetAzimuth() (r0 float64, r1 error) {
+	m.record("GetAzimuth")
+
+	if m.GetAzimuthFunc != nil {
+		return m.GetAzimuthFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetDeclination() (r0 float64, r1 error) {
+	m.record("GetDeclination")
+
+	if m.GetDeclinationFunc != nil {
+		return m.GetDeclinationFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetDeclinationRate() (r0 float64, r1 error) {
+	m.record("GetDeclinationRate")
+
+	if m.GetDeclinationRateFunc != nil {
+		return m.GetDeclinationRateFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetDescription() (r0 string, r1 error) {
+	m.record("GetDescription")
+
+	if m.GetDescriptionFunc != nil {
+		return m.GetDescriptionFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetDestinationSideOfPier(rightAscension float64, declination float64) (r0 alpacago.PierPointingMode, r1 error) {
+	m.record("GetDestinationSideOfPier", rightAscension, declination)
+
+	if m.GetDestinationSideOfPierFunc != nil {
+		return m.GetDestinationSideOfPierFunc(rightAscension, declination)
+	}
+
+	return
+}
+
+func (m *Telescope) GetEquatorialSystem() (r0 string, r1 error) {
+	m.record("GetEquatorialSystem")
+
+	if m.GetEquatorialSystemFunc != nil {
+		return m.GetEquatorialSystemFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetFocalLength() (r0 float64, r1 error) {
+	m.record("GetFocalLength")
+
+	if m.GetFocalLengthFunc != nil {
+		return m.GetFocalLengthFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetRightAscension() (r0 float64, r1 error) {
+	m.record("GetRightAscension")
+
+	if m.GetRightAscensionFunc != nil {
+		return m.GetRightAscensionFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetRightAscensionRate() (r0 float64, r1 error) {
+	m.record("GetRightAscensionRate")
+
+	if m.GetRightAscensionRateFunc != nil {
+		return m.GetRightAscensionRateFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetSideOfPier() (r0 alpacago.PierPointingMode, r1 error) {
+	m.record("GetSideOfPier")
+
+	if m.GetSideOfPierFunc != nil {
+		return m.GetSideOfPierFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetSiderealTime() (r0 float64, r1 error) {
+	m.record("GetSiderealTime")
+
+	if m.GetSiderealTimeFunc != nil {
+		return m.GetSiderealTimeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetSiteElevation() (r0 float64, r1 error) {
+	m.record("GetSiteElevation")
+
+	if m.GetSiteElevationFunc != nil {
+		return m.GetSiteElevationFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetSiteLatitude() (r0 float64, r1 error) {
+	m.record("GetSiteLatitude")
+
+	if m.GetSiteLatitudeFunc != nil {
+		return m.GetSiteLatitudeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetSiteLongitude() (r0 float64, r1 error) {
+	m.record("GetSiteLongitude")
+
+	if m.GetSiteLongitudeFunc != nil {
+		return m.GetSiteLongitudeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetSlewSettleTime() (r0 int32, r1 error) {
+	m.record("GetSlewSettleTime")
+
+	if m.GetSlewSettleTimeFunc != nil {
+		return m.GetSlewSettleTimeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetTargetDeclination() (r0 float64, r1 error) {
+	m.record("GetTargetDeclination")
+
+	if m.GetTargetDeclinationFunc != nil {
+		return m.GetTargetDeclinationFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetTargetRightAscension() (r0 float64, r1 error) {
+	m.record("GetTargetRightAscension")
+
+	if m.GetTargetRightAscensionFunc != nil {
+		return m.GetTargetRightAscensionFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetTrackingRate() (r0 int32, r1 error) {
+	m.record("GetTrackingRate")
+
+	if m.GetTrackingRateFunc != nil {
+		return m.GetTrackingRateFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) GetUTCDate() (r0 time.Time, r1 error) {
+	m.record("GetUTCDate")
+
+	if m.GetUTCDateFunc != nil {
+		return m.GetUTCDateFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) IsAtHome() (r0 bool, r1 error) {
+	m.record("IsAtHome")
+
+	if m.IsAtHomeFunc != nil {
+		return m.IsAtHomeFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) IsAtPark() (r0 bool, r1 error) {
+	m.record("IsAtPark")
+
+	if m.IsAtParkFunc != nil {
+		return m.IsAtParkFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) IsConnected() (r0 bool, r1 error) {
+	m.record("IsConnected")
+
+	if m.IsConnectedFunc != nil {
+		return m.IsConnectedFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) IsPulseGuiding() (r0 bool, r1 error) {
+	m.record("IsPulseGuiding")
+
+	if m.IsPulseGuidingFunc != nil {
+		return m.IsPulseGuidingFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) IsSlewing() (r0 bool, r1 error) {
+	m.record("IsSlewing")
+
+	if m.IsSlewingFunc != nil {
+		return m.IsSlewingFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) IsTracking() (r0 bool, r1 error) {
+	m.record("IsTracking")
+
+	if m.IsTrackingFunc != nil {
+		return m.IsTrackingFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) SetAbortSlew() (r0 error) {
+	m.record("SetAbortSlew")
+
+	if m.SetAbortSlewFunc != nil {
+		return m.SetAbortSlewFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) SetConnected(connected bool) (r0 error) {
+	m.record("SetConnected", connected)
+
+	if m.SetConnectedFunc != nil {
+		return m.SetConnectedFunc(connected)
+	}
+
+	return
+}
+
+func (m *Telescope) SetDeclinationRate(declinationRate float64) (r0 error) {
+	m.record("SetDeclinationRate", declinationRate)
+
+	if m.SetDeclinationRateFunc != nil {
+		return m.SetDeclinationRateFunc(declinationRate)
+	}
+
+	return
+}
+
+func (m *Telescope) SetDoesRefraction(doesRefraction bool) (r0 error) {
+	m.record("SetDoesRefraction", doesRefraction)
+
+	if m.SetDoesRefractionFunc != nil {
+		return m.SetDoesRefractionFunc(doesRefraction)
+	}
+
+	return
+}
+
+func (m *Telescope) SetPark() (r0 error) {
+	m.record("SetPark")
+
+	if m.SetParkFunc != nil {
+		return m.SetParkFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) SetPulseGuide(direction alpacago.Direction, duration int32) (r0 error) {
+	m.record("SetPulseGuide", direction, duration)
+
+	if m.SetPulseGuideFunc != nil {
+		return m.SetPulseGuideFunc(direction, duration)
+	}
+
+	return
+}
+
+func (m *Telescope) SetRightAscensionRate(rightAscensionRate float64) (r0 error) {
+	m.record("SetRightAscensionRate", rightAscensionRate)
+
+	if m.SetRightAscensionRateFunc != nil {
+		return m.SetRightAscensionRateFunc(rightAscensionRate)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSideOfPier(sideOfPier alpacago.PierPointingMode) (r0 error) {
+	m.record("SetSideOfPier", sideOfPier)
+
+	if m.SetSideOfPierFunc != nil {
+		return m.SetSideOfPierFunc(sideOfPier)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSiteElevation(siteElevation float64) (r0 error) {
+	m.record("SetSiteElevation", siteElevation)
+
+	if m.SetSiteElevationFunc != nil {
+		return m.SetSiteElevationFunc(siteElevation)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSiteLatitude(siteLatitude float64) (r0 error) {
+	m.record("SetSiteLatitude", siteLatitude)
+
+	if m.SetSiteLatitudeFunc != nil {
+		return m.SetSiteLatitudeFunc(siteLatitude)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSiteLongitude(siteLongitude float64) (r0 error) {
+	m.record("SetSiteLongitude", siteLongitude)
+
+	if m.SetSiteLongitudeFunc != nil {
+		return m.SetSiteLongitudeFunc(siteLongitude)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewSettleTime(slewSettleTime int32) (r0 error) {
+	m.record("SetSlewSettleTime", slewSettleTime)
+
+	if m.SetSlewSettleTimeFunc != nil {
+		return m.SetSlewSettleTimeFunc(slewSettleTime)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewToAltAz(altitude float64, azimuth float64) (r0 error) {
+	m.record("SetSlewToAltAz", altitude, azimuth)
+
+	if m.SetSlewToAltAzFunc != nil {
+		return m.SetSlewToAltAzFunc(altitude, azimuth)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewToAltAzAsync(altitude float64, azimuth float64) (r0 error) {
+	m.record("SetSlewToAltAzAsync", altitude, azimuth)
+
+	if m.SetSlewToAltAzAsyncFunc != nil {
+		return m.SetSlewToAltAzAsyncFunc(altitude, azimuth)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewToCoordinates(rightAscension float64, declination float64) (r0 error) {
+	m.record("SetSlewToCoordinates", rightAscension, declination)
+
+	if m.SetSlewToCoordinatesFunc != nil {
+		return m.SetSlewToCoordinatesFunc(rightAscension, declination)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewToCoordinatesAsync(rightAscension float64, declination float64) (r0 error) {
+	m.record("SetSlewToCoordinatesAsync", rightAscension, declination)
+
+	if m.SetSlewToCoordinatesAsyncFunc != nil {
+		return m.SetSlewToCoordinatesAsyncFunc(rightAscension, declination)
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewToTarget() (r0 error) {
+	m.record("SetSlewToTarget")
+
+	if m.SetSlewToTargetFunc != nil {
+		return m.SetSlewToTargetFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) SetSlewToTargetAsync() (r0 error) {
+	m.record("SetSlewToTargetAsync")
+
+	if m.SetSlewToTargetAsyncFunc != nil {
+		return m.SetSlewToTargetAsyncFunc()
+	}
+
+	return
+}
+
+func (m *Telescope) SetSyncToCoordinates(rightAscension float64, declination float64) (r0 error) {
+	m.record("SetSyncToCoordinates", rightAscension, declination)
+
+	if m.SetSyncToCoordinatesFunc != nil {
+		return m.SetSyncToCoordinatesFunc(rightAscension, declination)
+	}
+
+	return
+}
+
+func (m *Telescope) SetTargetDeclination(targetDeclination float64) (r0 error) {
+	m.record("SetTargetDeclination", targetDeclination)
+
+	if m.SetTargetDeclinationFunc != nil {
+		return m.SetTargetDeclinationFunc(targetDeclination)
+	}
+
+	return
+}
+
+func (m *Telescope) SetTargetRightAscension(targetRightAscension float64) (r0 error) {
+	m.record("SetTargetRightAscension", targetRightAscension)
+
+	if m.SetTargetRightAscensionFunc != nil {
+		return m.SetTargetRightAscensionFunc(targetRightAscension)
+	}
+
+	return
+}
+
+func (m *Telescope) SetTracking(tracking bool) (r0 error) {
+	m.record("SetTracking", tracking)
+
+	if m.SetTrackingFunc != nil {
+		return m.SetTrackingFunc(tracking)
+	}
+
+	return
+}
+
+func (m *Telescope) SetUTCDate(date time.Time) (r0 error) {
+	m.record("SetUTCDate", date)
+
+	if m.SetUTCDateFunc != nil {
+		return m.SetUTCDateFunc(date)
+	}
+
+	return
+}
+
+func (m *Telescope) SetUnPark() (r0 error) {
+	m.record("SetUnPark")
+
+	if m.SetUnParkFunc != nil {
+		return m.SetUnParkFunc()
+	}
+
+	return
+}
+
+var _ alpacago.TelescopeAPI = (*Telescope)(nil)
@@ -95,7 +95,7 @@ GetPosition()
 @see https://ascom-standards.org/api/#/Rotator%20Specific%20Methods/get_rotator__device_number__position
 */
 func (r *Rotator) GetPosition() (float64, error) {
-	return r.Alpaca.GetFloat64Response("rotator", r.DeviceNumber, "mechanicalposition")
+	return r.Alpaca.GetFloat64Response("rotator", r.DeviceNumber, "position")
 }
 
 /*
@@ -0,0 +1,654 @@
+package alpacago
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+type ruleTokenKind int
+
+const (
+	ruleTokenEOF ruleTokenKind = iota
+	ruleTokenNumber
+	ruleTokenDuration
+	ruleTokenIdentifier
+	ruleTokenOperator
+)
+
+type ruleToken struct {
+	kind     ruleTokenKind
+	text     string
+	number   float64
+	duration time.Duration
+	position int
+}
+
+/*
+tokeniseRule()
+
+Splits a rule expression into numbers, durations (e.g., 5m or 1h30m), identifiers and operators.
+*/
+func tokeniseRule(expression string) ([]ruleToken, error) {
+	tokens := []ruleToken{}
+
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			text := string(runes[start:i])
+
+			if n, err := strconv.ParseFloat(text, 64); err == nil {
+				tokens = append(tokens, ruleToken{kind: ruleTokenNumber, text: text, number: n, position: start})
+			} else if d, err := time.ParseDuration(text); err == nil {
+				tokens = append(tokens, ruleToken{kind: ruleTokenDuration, text: text, duration: d, position: start})
+			} else {
+				return nil, fmt.Errorf("invalid number or duration %q at position %d", text, start)
+			}
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			tokens = append(tokens, ruleToken{kind: ruleTokenIdentifier, text: string(runes[start:i]), position: start})
+
+		default:
+			start := i
+
+			operator := ""
+
+			if i+1 < len(runes) {
+				switch string(runes[i : i+2]) {
+				case "||", "&&", "==", "!=", ">=", "<=":
+					operator = string(runes[i : i+2])
+				}
+			}
+
+			if operator == "" {
+				switch r {
+				case '!', '>', '<', '+', '-', '*', '/', '(', ')':
+					operator = string(r)
+				default:
+					return nil, fmt.Errorf("unexpected character %q at position %d", r, start)
+				}
+			}
+
+			i += len([]rune(operator))
+
+			tokens = append(tokens, ruleToken{kind: ruleTokenOperator, text: operator, position: start})
+		}
+	}
+
+	return append(tokens, ruleToken{kind: ruleTokenEOF, position: len(runes)}), nil
+}
+
+type ruleNode interface {
+	// isBoolean reports whether the node yields a boolean, rather than a number
+	isBoolean() bool
+	evaluate(values map[string]float64, now time.Time) (float64, bool)
+}
+
+type ruleNumber struct {
+	value float64
+}
+
+func (n *ruleNumber) isBoolean() bool { return false }
+
+func (n *ruleNumber) evaluate(values map[string]float64, now time.Time) (float64, bool) {
+	return n.value, false
+}
+
+type ruleSensor struct {
+	name string
+}
+
+func (n *ruleSensor) isBoolean() bool { return false }
+
+func (n *ruleSensor) evaluate(values map[string]float64, now time.Time) (float64, bool) {
+	return values[n.name], false
+}
+
+type ruleUnary struct {
+	operator string
+	operand  ruleNode
+}
+
+func (n *ruleUnary) isBoolean() bool { return n.operator == "!" }
+
+func (n *ruleUnary) evaluate(values map[string]float64, now time.Time) (float64, bool) {
+	v, b := n.operand.evaluate(values, now)
+
+	if n.operator == "!" {
+		return 0, !b
+	}
+
+	return -v, false
+}
+
+type ruleBinary struct {
+	operator string
+	left     ruleNode
+	right    ruleNode
+}
+
+func (n *ruleBinary) isBoolean() bool {
+	switch n.operator {
+	case "+", "-", "*", "/":
+		return false
+	default:
+		return true
+	}
+}
+
+func (n *ruleBinary) evaluate(values map[string]float64, now time.Time) (float64, bool) {
+	// Both sides are always evaluated, so that any "for" state remains up to date:
+	l, lb := n.left.evaluate(values, now)
+
+	r, rb := n.right.evaluate(values, now)
+
+	switch n.operator {
+	case "||":
+		return 0, lb || rb
+	case "&&":
+		return 0, lb && rb
+	case "==":
+		return 0, l == r
+	case "!=":
+		return 0, l != r
+	case ">":
+		return 0, l > r
+	case ">=":
+		return 0, l >= r
+	case "<":
+		return 0, l < r
+	case "<=":
+		return 0, l <= r
+	case "+":
+		return l + r, false
+	case "-":
+		return l - r, false
+	case "*":
+		return l * r, false
+	default:
+		return l / r, false
+	}
+}
+
+type ruleFor struct {
+	condition ruleNode
+	duration  time.Duration
+	since     time.Time
+}
+
+func (n *ruleFor) isBoolean() bool { return true }
+
+func (n *ruleFor) evaluate(values map[string]float64, now time.Time) (float64, bool) {
+	_, b := n.condition.evaluate(values, now)
+
+	if !b {
+		n.since = time.Time{}
+		return 0, false
+	}
+
+	if n.since.IsZero() {
+		n.since = now
+	}
+
+	return 0, now.Sub(n.since) >= n.duration
+}
+
+type ruleParser struct {
+	tokens  []ruleToken
+	index   int
+	sensors map[string]bool
+}
+
+func (p *ruleParser) peek() ruleToken {
+	return p.tokens[p.index]
+}
+
+func (p *ruleParser) next() ruleToken {
+	t := p.tokens[p.index]
+
+	if t.kind != ruleTokenEOF {
+		p.index++
+	}
+
+	return t
+}
+
+func (p *ruleParser) accept(operators ...string) (string, bool) {
+	t := p.peek()
+
+	if t.kind != ruleTokenOperator {
+		return "", false
+	}
+
+	for _, o := range operators {
+		if t.text == o {
+			p.index++
+			return o, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *ruleParser) expect(node ruleNode, boolean bool, position int) error {
+	if node.isBoolean() != boolean {
+		if boolean {
+			return fmt.Errorf("expected a condition at position %d, but found a value", position)
+		}
+
+		return fmt.Errorf("expected a value at position %d, but found a condition", position)
+	}
+
+	return nil
+}
+
+func (p *ruleParser) parseOr() (ruleNode, error) {
+	return p.parseBinary([]string{"||"}, true, p.parseAnd)
+}
+
+func (p *ruleParser) parseAnd() (ruleNode, error) {
+	return p.parseBinary([]string{"&&"}, true, p.parseCondition)
+}
+
+func (p *ruleParser) parseBinary(operators []string, boolean bool, operand func() (ruleNode, error)) (ruleNode, error) {
+	position := p.peek().position
+
+	left, err := operand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept(operators...)
+
+		if !ok {
+			return left, nil
+		}
+
+		if err := p.expect(left, boolean, position); err != nil {
+			return nil, err
+		}
+
+		position = p.peek().position
+
+		right, err := operand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(right, boolean, position); err != nil {
+			return nil, err
+		}
+
+		left = &ruleBinary{operator: operator, left: left, right: right}
+	}
+}
+
+/*
+parseCondition()
+
+A condition is a negation, a comparison or a parenthesised expression, optionally followed by
+"for <duration>", which requires it to have held continuously for that long.
+*/
+func (p *ruleParser) parseCondition() (ruleNode, error) {
+	position := p.peek().position
+
+	if _, ok := p.accept("!"); ok {
+		operand, err := p.parseCondition()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(operand, true, position+1); err != nil {
+			return nil, err
+		}
+
+		return &ruleUnary{operator: "!", operand: operand}, nil
+	}
+
+	node, err := p.parseSum()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if operator, ok := p.accept("==", "!=", ">=", "<=", ">", "<"); ok {
+		if err := p.expect(node, false, position); err != nil {
+			return nil, err
+		}
+
+		position := p.peek().position
+
+		right, err := p.parseSum()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(right, false, position); err != nil {
+			return nil, err
+		}
+
+		node = &ruleBinary{operator: operator, left: node, right: right}
+	}
+
+	if t := p.peek(); t.kind == ruleTokenIdentifier && strings.EqualFold(t.text, "for") {
+		p.next()
+
+		if err := p.expect(node, true, position); err != nil {
+			return nil, err
+		}
+
+		d := p.next()
+
+		if d.kind != ruleTokenDuration {
+			return nil, fmt.Errorf("expected a duration (e.g., 5m) at position %d", d.position)
+		}
+
+		node = &ruleFor{condition: node, duration: d.duration}
+	}
+
+	return node, nil
+}
+
+func (p *ruleParser) parseSum() (ruleNode, error) {
+	return p.parseBinary([]string{"+", "-"}, false, p.parseTerm)
+}
+
+func (p *ruleParser) parseTerm() (ruleNode, error) {
+	return p.parseBinary([]string{"*", "/"}, false, p.parseFactor)
+}
+
+func (p *ruleParser) parseFactor() (ruleNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case ruleTokenNumber:
+		return &ruleNumber{value: t.number}, nil
+
+	case ruleTokenIdentifier:
+		if _, ok := ruleSensorNames[t.text]; !ok {
+			return nil, fmt.Errorf("unknown sensor %q at position %d", t.text, t.position)
+		}
+
+		p.sensors[t.text] = true
+
+		return &ruleSensor{name: t.text}, nil
+
+	case ruleTokenOperator:
+		switch t.text {
+		case "-":
+			operand, err := p.parseFactor()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect(operand, false, t.position+1); err != nil {
+				return nil, err
+			}
+
+			return &ruleUnary{operator: "-", operand: operand}, nil
+
+		case "(":
+			node, err := p.parseOr()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("expected \")\" at position %d", p.peek().position)
+			}
+
+			return node, nil
+		}
+	}
+
+	if t.kind == ruleTokenEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.position)
+}
+
+var ruleSensorNames = map[string][]string{
+	SensorCloudCover:      {SensorCloudCover},
+	SensorDewPoint:        {SensorDewPoint},
+	SensorHumidity:        {SensorHumidity},
+	SensorPressure:        {SensorPressure},
+	SensorRainRate:        {SensorRainRate},
+	SensorSkyBrightness:   {SensorSkyBrightness},
+	SensorSkyQuality:      {SensorSkyQuality},
+	SensorSkyTemperature:  {SensorSkyTemperature},
+	SensorStarFWHM:        {SensorStarFWHM},
+	SensorTemperature:     {SensorTemperature},
+	SensorWindDirection:   {SensorWindDirection},
+	SensorWindGust:        {SensorWindGust},
+	SensorWindSpeed:       {SensorWindSpeed},
+	SensorSkyAmbientDelta: {SensorSkyTemperature, SensorTemperature},
+}
+
+type SafetyRule struct {
+	Expression string
+
+	root    ruleNode
+	sensors []string
+	mu      sync.Mutex
+}
+
+/*
+ParseSafetyRule()
+
+Parses an expression over observing conditions sensors, e.g.,
+
+	RainRate > 0 || WindGust > 15 || Humidity > 90 for 5m
+
+Conditions support ||, &&, !, comparisons and arithmetic (+, -, *, /) over sensor readings.
+A "for <duration>" suffix applies to the preceding comparison or parenthesised expression.
+
+@returns the parsed rule, or an error describing where the expression is invalid.
+*/
+func ParseSafetyRule(expression string) (*SafetyRule, error) {
+	tokens, err := tokeniseRule(expression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parser := ruleParser{tokens: tokens, sensors: map[string]bool{}}
+
+	root, err := parser.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t := parser.peek(); t.kind != ruleTokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.position)
+	}
+
+	if err := parser.expect(root, true, 0); err != nil {
+		return nil, err
+	}
+
+	sensors := []string{}
+
+	for name := range parser.sensors {
+		sensors = append(sensors, name)
+	}
+
+	sort.Strings(sensors)
+
+	rule := SafetyRule{
+		Expression: expression,
+		root:       root,
+		sensors:    sensors,
+	}
+
+	return &rule, nil
+}
+
+/*
+GetSensors()
+
+@returns the (sorted) sensor names referenced by the rule.
+*/
+func (r *SafetyRule) GetSensors() []string {
+	return r.sensors
+}
+
+/*
+Evaluate()
+
+@param values map[string]float64 (the sensor readings, keyed by sensor name)
+@param now time.Time (the time of the readings, used by "for" durations)
+@returns true if the rule holds for the readings.
+*/
+func (r *SafetyRule) Evaluate(values map[string]float64, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, b := r.root.evaluate(values, now)
+
+	return b
+}
+
+type SensorUpdateSource interface {
+	GetTimeSinceLastUpdate(sensorName string) (float64, error)
+}
+
+type ConditionsSafetyMonitor struct {
+	Conditions WeatherSource
+	// Conditions are unsafe whilst this rule holds
+	UnsafeWhen *SafetyRule
+	// Readings older than this are treated as unsafe (zero disables staleness checks)
+	MaxAge time.Duration
+
+	now func() time.Time
+}
+
+/*
+NewConditionsSafetyMonitor()
+
+@param conditions WeatherSource (e.g., an *ObservingConditions)
+@param unsafeWhen string (a rule expression, see ParseSafetyRule, that holds when conditions are unsafe)
+@returns a synthetic safety monitor, or an error if the rule is invalid.
+*/
+func NewConditionsSafetyMonitor(conditions WeatherSource, unsafeWhen string, maxAge time.Duration) (*ConditionsSafetyMonitor, error) {
+	rule, err := ParseSafetyRule(unsafeWhen)
+
+	if err != nil {
+		return nil, err
+	}
+
+	monitor := ConditionsSafetyMonitor{
+		Conditions: conditions,
+		UnsafeWhen: rule,
+		MaxAge:     maxAge,
+		now:        time.Now,
+	}
+
+	return &monitor, nil
+}
+
+/*
+getTime()
+
+@returns the current time, against which the "for <duration>" hold periods of the rule are measured.
+*/
+func (m *ConditionsSafetyMonitor) getTime() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+
+	return m.now()
+}
+
+/*
+Check()
+
+Reads every sensor referenced by the rule, checking staleness where the source supports
+GetTimeSinceLastUpdate, then evaluates the rule.
+
+@returns true if conditions are safe, otherwise false with the reasons why not.
+*/
+func (m *ConditionsSafetyMonitor) Check() (bool, []string, error) {
+	values := map[string]float64{}
+
+	reasons := []string{}
+
+	updates, canCheckAge := m.Conditions.(SensorUpdateSource)
+
+	for _, name := range m.UnsafeWhen.GetSensors() {
+		if m.MaxAge > 0 && canCheckAge {
+			for _, sensor := range ruleSensorNames[name] {
+				age, err := updates.GetTimeSinceLastUpdate(sensor)
+
+				if err != nil {
+					return false, nil, err
+				}
+
+				// A negative age indicates that the sensor has never been updated:
+				if age < 0 || time.Duration(age*float64(time.Second)) > m.MaxAge {
+					reasons = append(reasons, fmt.Sprintf("%s reading is stale (%.0fs old)", sensor, age))
+				}
+			}
+		}
+
+		value, err := GetWeatherReading(m.Conditions, name)
+
+		if err != nil {
+			return false, nil, err
+		}
+
+		values[name] = value
+	}
+
+	if m.UnsafeWhen.Evaluate(values, m.getTime()) {
+		reasons = append(reasons, fmt.Sprintf("rule holds: %s", m.UnsafeWhen.Expression))
+	}
+
+	return len(reasons) == 0, reasons, nil
+}
+
+/*
+IsSafe()
+
+@returns true if the state is safe, false if it is unsafe, compatible with SafetyMonitor.IsSafe()
+*/
+func (m *ConditionsSafetyMonitor) IsSafe() (bool, error) {
+	safe, _, err := m.Check()
+
+	if err != nil {
+		return false, err
+	}
+
+	return safe, nil
+}
@@ -0,0 +1,191 @@
+package alpacago
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeUpdatedWeatherSource struct {
+	fakeWeatherSource
+	ages map[string]float64
+}
+
+func (w *fakeUpdatedWeatherSource) GetTimeSinceLastUpdate(sensorName string) (float64, error) {
+	if age, ok := w.ages[sensorName]; ok {
+		return age, nil
+	}
+
+	return 0, errors.New("not implemented")
+}
+
+func TestParseSafetyRuleSensors(t *testing.T) {
+	rule, err := ParseSafetyRule("RainRate > 0 || WindGust > 15 || Humidity > 90 for 5m")
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var got string = strings.Join(rule.GetSensors(), ",")
+	var want string = "Humidity,RainRate,WindGust"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestParseSafetyRuleInvalid(t *testing.T) {
+	var expressions []string = []string{
+		"",
+		"RainRate",
+		"RainRate >",
+		"RainRate > 0 ||",
+		"Rainfall > 0",
+		"(RainRate > 0",
+		"RainRate > 0 for",
+		"RainRate > 0 for 5",
+		"RainRate + (WindGust > 1) > 0",
+		"RainRate > 0 && 1",
+		"RainRate > 0 $",
+	}
+
+	for _, expression := range expressions {
+		if _, err := ParseSafetyRule(expression); err == nil {
+			t.Errorf("got nil, wanted an error for %q", expression)
+		}
+	}
+}
+
+func TestSafetyRuleEvaluatePrecedence(t *testing.T) {
+	rule, _ := ParseSafetyRule("RainRate > 0 || WindGust > 15 && !(Humidity < 50)")
+
+	now := time.Now()
+
+	if rule.Evaluate(map[string]float64{SensorRainRate: 0, SensorWindGust: 20, SensorHumidity: 40}, now) {
+		t.Errorf("got true, wanted false")
+	}
+
+	if !rule.Evaluate(map[string]float64{SensorRainRate: 0, SensorWindGust: 20, SensorHumidity: 60}, now) {
+		t.Errorf("got false, wanted true")
+	}
+
+	if !rule.Evaluate(map[string]float64{SensorRainRate: 1, SensorWindGust: 0, SensorHumidity: 0}, now) {
+		t.Errorf("got false, wanted true")
+	}
+}
+
+func TestSafetyRuleEvaluateArithmetic(t *testing.T) {
+	rule, err := ParseSafetyRule("SkyTemperature - Temperature > -15")
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if rule.Evaluate(map[string]float64{SensorSkyTemperature: -25, SensorTemperature: 5}, time.Now()) {
+		t.Errorf("got true, wanted false for a clear sky")
+	}
+
+	if !rule.Evaluate(map[string]float64{SensorSkyTemperature: -5, SensorTemperature: 5}, time.Now()) {
+		t.Errorf("got false, wanted true for a cloudy sky")
+	}
+}
+
+func TestSafetyRuleEvaluateForDuration(t *testing.T) {
+	rule, _ := ParseSafetyRule("Humidity > 90 for 5m")
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	humid := map[string]float64{SensorHumidity: 95}
+
+	if rule.Evaluate(humid, now) {
+		t.Errorf("got true, wanted false before the duration has elapsed")
+	}
+
+	if rule.Evaluate(humid, now.Add(4*time.Minute)) {
+		t.Errorf("got true, wanted false before the duration has elapsed")
+	}
+
+	if !rule.Evaluate(humid, now.Add(5*time.Minute)) {
+		t.Errorf("got false, wanted true once the duration has elapsed")
+	}
+
+	// A single dry reading restarts the duration:
+	rule.Evaluate(map[string]float64{SensorHumidity: 80}, now.Add(6*time.Minute))
+
+	if rule.Evaluate(humid, now.Add(7*time.Minute)) {
+		t.Errorf("got true, wanted false after the condition was interrupted")
+	}
+}
+
+func TestConditionsSafetyMonitorIsSafe(t *testing.T) {
+	weather := &fakeWeatherSource{readings: map[string]float64{SensorRainRate: 0, SensorWindGust: 5}}
+
+	monitor, err := NewConditionsSafetyMonitor(weather, "RainRate > 0 || WindGust > 15", 0)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	safe, err := monitor.IsSafe()
+
+	if err != nil || !safe {
+		t.Errorf("got %t (%v), wanted true", safe, err)
+	}
+
+	weather.readings[SensorWindGust] = 20
+
+	safe, _ = monitor.IsSafe()
+
+	if safe {
+		t.Errorf("got true, wanted false")
+	}
+}
+
+func TestConditionsSafetyMonitorWithoutConstructor(t *testing.T) {
+	rule, _ := ParseSafetyRule("RainRate > 0")
+
+	monitor := &ConditionsSafetyMonitor{
+		Conditions: &fakeWeatherSource{readings: map[string]float64{SensorRainRate: 0}},
+		UnsafeWhen: rule,
+	}
+
+	safe, _, err := monitor.Check()
+
+	if err != nil || !safe {
+		t.Errorf("got %t (%v), wanted true", safe, err)
+	}
+}
+
+func TestConditionsSafetyMonitorStaleReadings(t *testing.T) {
+	weather := &fakeUpdatedWeatherSource{
+		fakeWeatherSource: fakeWeatherSource{readings: map[string]float64{SensorSkyTemperature: -25, SensorTemperature: 5}},
+		ages:              map[string]float64{SensorSkyTemperature: 30, SensorTemperature: 900},
+	}
+
+	monitor, _ := NewConditionsSafetyMonitor(weather, "SkyAmbientDelta > -15", 5*time.Minute)
+
+	safe, reasons, err := monitor.Check()
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if safe {
+		t.Errorf("got safe, wanted unsafe for a stale reading")
+	}
+
+	if len(reasons) != 1 || !strings.HasPrefix(reasons[0], SensorTemperature) {
+		t.Errorf("got %q, wanted a single stale Temperature reason", reasons)
+	}
+}
+
+func TestConditionsSafetyMonitorReadError(t *testing.T) {
+	monitor, _ := NewConditionsSafetyMonitor(&fakeWeatherSource{}, "RainRate > 0", 0)
+
+	safe, err := monitor.IsSafe()
+
+	if err == nil || safe {
+		t.Errorf("got %t (%v), wanted false with an error", safe, err)
+	}
+}
@@ -0,0 +1,728 @@
+package alpacago
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type SequenceTelescope interface {
+	SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error
+	IsSlewing() (bool, error)
+}
+
+type SequenceRotator interface {
+	SetMoveAbsolute(position float64) error
+	IsMoving() (bool, error)
+}
+
+type SequenceExposure struct {
+	// The filter name, as returned by FilterWheel.GetNames(), or empty to leave the filter unchanged
+	Filter string `json:"filter"`
+	// The exposure duration (seconds)
+	Duration float64 `json:"duration"`
+	Count    int     `json:"count"`
+	// Take dark frames, with the shutter closed
+	Dark bool `json:"dark,omitempty"`
+}
+
+type SequenceTarget struct {
+	Name string `json:"name"`
+	// The target right ascension (hours) and declination (degrees)
+	RightAscension float64 `json:"ra"`
+	Declination    float64 `json:"dec"`
+	// The rotator position (degrees), or the sky position angle where the Sequencer has a SkyRotator, or
+	// nil to leave the rotator unchanged
+	Rotation *float64 `json:"rotation,omitempty"`
+	// Run autofocus once the target has been acquired, through the first filter
+	Autofocus bool               `json:"autofocus,omitempty"`
+	Exposures []SequenceExposure `json:"exposures"`
+}
+
+type Sequence struct {
+	Name    string           `json:"name"`
+	Targets []SequenceTarget `json:"targets"`
+}
+
+/*
+ParseSequence()
+
+Parses and validates a JSON imaging plan e.g.,
+
+	{
+	  "name": "M42",
+	  "targets": [{
+	    "name": "M42", "ra": 5.588, "dec": -5.39, "rotation": 90, "autofocus": true,
+	    "exposures": [{ "filter": "Red", "duration": 120, "count": 10 }]
+	  }]
+	}
+
+@returns the sequence, or an error describing the first invalid entry.
+*/
+func ParseSequence(data []byte) (*Sequence, error) {
+	sequence := Sequence{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&sequence); err != nil {
+		return nil, err
+	}
+
+	if len(sequence.Targets) == 0 {
+		return nil, errors.New("the sequence has no targets")
+	}
+
+	for i, target := range sequence.Targets {
+		if target.RightAscension < 0 || target.RightAscension >= 24 {
+			return nil, fmt.Errorf("target %d: please provide a right ascension between 0h and 24h", i)
+		}
+
+		if target.Declination < -90 || target.Declination > 90 {
+			return nil, fmt.Errorf("target %d: please provide a declination between -90° and +90°", i)
+		}
+
+		if len(target.Exposures) == 0 {
+			return nil, fmt.Errorf("target %d: the target has no exposures", i)
+		}
+
+		for j, exposure := range target.Exposures {
+			if exposure.Count <= 0 {
+				return nil, fmt.Errorf("target %d, exposure %d: please provide a count of at least 1", i, j)
+			}
+
+			if exposure.Duration < 0 {
+				return nil, fmt.Errorf("target %d, exposure %d: please provide a non-negative duration", i, j)
+			}
+		}
+	}
+
+	return &sequence, nil
+}
+
+/*
+LoadSequence()
+
+@returns the sequence parsed from the JSON file at the given path.
+*/
+func LoadSequence(path string) (*Sequence, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSequence(data)
+}
+
+type SequenceCheckpoint struct {
+	Sequence string `json:"sequence"`
+	// The number of completed frames, per target, per exposure
+	Completed [][]int   `json:"completed"`
+	Updated   time.Time `json:"updated"`
+}
+
+/*
+NewSequenceCheckpoint()
+
+@returns an empty checkpoint shaped to the given sequence.
+*/
+func NewSequenceCheckpoint(sequence *Sequence) SequenceCheckpoint {
+	checkpoint := SequenceCheckpoint{
+		Sequence:  sequence.Name,
+		Completed: make([][]int, len(sequence.Targets)),
+	}
+
+	for i, target := range sequence.Targets {
+		checkpoint.Completed[i] = make([]int, len(target.Exposures))
+	}
+
+	return checkpoint
+}
+
+/*
+LoadSequenceCheckpoint()
+
+@returns the checkpoint saved at the given path, or an empty checkpoint if there is none.
+*/
+func LoadSequenceCheckpoint(path string, sequence *Sequence) (SequenceCheckpoint, error) {
+	checkpoint := NewSequenceCheckpoint(sequence)
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+
+	if err != nil {
+		return checkpoint, err
+	}
+
+	saved := SequenceCheckpoint{}
+
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return checkpoint, err
+	}
+
+	if saved.Sequence != sequence.Name || len(saved.Completed) != len(sequence.Targets) {
+		return checkpoint, fmt.Errorf("the checkpoint at %s does not belong to the sequence %q", path, sequence.Name)
+	}
+
+	for i, target := range sequence.Targets {
+		if len(saved.Completed[i]) != len(target.Exposures) {
+			return checkpoint, fmt.Errorf("the checkpoint at %s does not belong to the sequence %q", path, sequence.Name)
+		}
+	}
+
+	return saved, nil
+}
+
+/*
+Save()
+
+Writes the checkpoint to the given path, via a temporary file so an interruption never leaves it truncated.
+*/
+func (c SequenceCheckpoint) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+type SequenceState int32
+
+const (
+	SequenceIdle SequenceState = iota
+	SequenceRunning
+	SequencePaused
+	// Waiting for the safety source to report safe
+	SequenceInterrupted
+	SequenceAborted
+	SequenceComplete
+	SequenceFailed
+)
+
+func (s SequenceState) String() string {
+	name := []string{"idle", "running", "paused", "interrupted", "aborted", "complete", "failed"}
+
+	i := uint8(s)
+
+	switch {
+	case i <= uint8(SequenceFailed):
+		return name[i]
+	default:
+		return strconv.Itoa(int(i))
+	}
+}
+
+var ErrSequenceAborted = errors.New("the sequence was aborted")
+
+// Ends the wait for a frame once conditions become unsafe, so that it is retaken:
+var errSequenceUnsafe = errors.New("conditions became unsafe during the exposure")
+
+type SequenceFrame struct {
+	Target   string
+	Filter   string
+	Duration float64
+	Light    bool
+	// The 1-based index of the frame within its exposure, of Count
+	Index int
+	Count int
+	Time  time.Time
+}
+
+type Sequencer struct {
+	Sequence *Sequence
+	Camera   ExposureCamera
+	// The devices used to acquire targets (any may be left nil)
+	Telescope SequenceTelescope
+	Dome      *DomeSlaving
+	Filters   *FilterChanger
+	Rotator   SequenceRotator
+	// Frames targets by their sky position angle, taking precedence over the Rotator (optional)
+	SkyRotator  *SkyRotator
+	Autofocus   *Autofocus
+	Compensator *TemperatureCompensator
+	// Flips a German equatorial mount between exposures, as they approach the meridian (optional)
+	MeridianFlip *MeridianFlip
+	// Exposures are held whilst the safety source reports unsafe, and the target re-acquired after
+	Safety SafetySource
+	// The file progress is saved to after every frame, so an interrupted run resumes (optional)
+	CheckpointPath string
+	PollInterval   time.Duration
+	SafetyInterval time.Duration
+	// Called with every frame captured; an error stops the sequence
+	OnFrame func(frame SequenceFrame, image [][]uint32) error
+	// Called as the sequence changes state
+	OnStateChange func(state SequenceState)
+
+	mu         sync.Mutex
+	state      SequenceState
+	resumed    chan struct{}
+	cancel     context.CancelFunc
+	aborted    bool
+	checkpoint SequenceCheckpoint
+	filter     string
+}
+
+func NewSequencer(sequence *Sequence, camera ExposureCamera) *Sequencer {
+	sequencer := Sequencer{
+		Sequence:       sequence,
+		Camera:         camera,
+		PollInterval:   time.Second,
+		SafetyInterval: 30 * time.Second,
+		state:          SequenceIdle,
+	}
+
+	return &sequencer
+}
+
+/*
+GetState()
+
+@returns the current state of the sequencer.
+*/
+func (s *Sequencer) GetState() SequenceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+/*
+GetCheckpoint()
+
+@returns a copy of the progress through the sequence.
+*/
+func (s *Sequencer) GetCheckpoint() SequenceCheckpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint := s.checkpoint
+
+	checkpoint.Completed = make([][]int, len(s.checkpoint.Completed))
+
+	for i, completed := range s.checkpoint.Completed {
+		checkpoint.Completed[i] = append([]int{}, completed...)
+	}
+
+	return checkpoint
+}
+
+func (s *Sequencer) setState(state SequenceState) {
+	s.mu.Lock()
+
+	changed := s.state != state
+
+	s.state = state
+
+	s.mu.Unlock()
+
+	if changed && s.OnStateChange != nil {
+		s.OnStateChange(state)
+	}
+}
+
+/*
+Pause()
+
+Pauses the sequence before its next step; an exposure in progress is allowed to complete.
+*/
+func (s *Sequencer) Pause() {
+	s.mu.Lock()
+
+	if (s.state != SequenceRunning && s.state != SequenceInterrupted) || s.resumed != nil {
+		s.mu.Unlock()
+		return
+	}
+
+	s.resumed = make(chan struct{})
+
+	s.mu.Unlock()
+}
+
+/*
+Resume()
+
+Resumes a paused sequence.
+*/
+func (s *Sequencer) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.resumed != nil {
+		close(s.resumed)
+		s.resumed = nil
+	}
+}
+
+/*
+Abort()
+
+Aborts the sequence, cancelling any exposure or move that is being waited on.
+*/
+func (s *Sequencer) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.aborted = true
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+/*
+waitIfPaused()
+
+Blocks whilst the sequence is paused.
+*/
+func (s *Sequencer) waitIfPaused(ctx context.Context) error {
+	s.mu.Lock()
+
+	resumed := s.resumed
+
+	s.mu.Unlock()
+
+	if resumed == nil {
+		return ctx.Err()
+	}
+
+	s.setState(SequencePaused)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+	}
+
+	s.setState(SequenceRunning)
+
+	return nil
+}
+
+/*
+isSafe()
+
+@returns true if there is no safety source, or it reports safe; a source that cannot be read is unsafe.
+*/
+func (s *Sequencer) isSafe() bool {
+	if s.Safety == nil {
+		return true
+	}
+
+	safe, err := s.Safety.IsSafe()
+
+	return err == nil && safe
+}
+
+/*
+waitUntilSafe()
+
+@returns true if the sequence had to wait for conditions to become safe.
+*/
+func (s *Sequencer) waitUntilSafe(ctx context.Context) (bool, error) {
+	if s.isSafe() {
+		return false, nil
+	}
+
+	s.setState(SequenceInterrupted)
+
+	if err := WaitUntil(ctx, s.SafetyInterval, func() (bool, error) { return s.isSafe(), nil }); err != nil {
+		return true, err
+	}
+
+	s.setState(SequenceRunning)
+
+	return true, nil
+}
+
+/*
+acquire()
+
+Slews the telescope to the target, brings the dome round to the telescope and moves the rotator.
+*/
+func (s *Sequencer) acquire(ctx context.Context, target SequenceTarget) error {
+	if s.Telescope != nil {
+		// Telescope.SetSlewToCoordinatesAsync() takes the right ascension in degrees:
+		if err := s.Telescope.SetSlewToCoordinatesAsync(target.RightAscension*15, target.Declination); err != nil {
+			return err
+		}
+
+		if err := WaitWhileMoving(ctx, s.PollInterval, s.Telescope.IsSlewing); err != nil {
+			return err
+		}
+	}
+
+	if err := s.syncDome(ctx); err != nil {
+		return err
+	}
+
+	if s.SkyRotator != nil && target.Rotation != nil {
+		return s.SkyRotator.MoveToPositionAngle(ctx, *target.Rotation)
+	}
+
+	if s.Rotator != nil && target.Rotation != nil {
+		if err := s.Rotator.SetMoveAbsolute(*target.Rotation); err != nil {
+			return err
+		}
+
+		if err := WaitWhileMoving(ctx, s.PollInterval, s.Rotator.IsMoving); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+syncDome()
+
+Moves the dome to the telescope's required azimuth, if outside the slaving hysteresis, and waits for it.
+*/
+func (s *Sequencer) syncDome(ctx context.Context) error {
+	if s.Dome == nil {
+		return nil
+	}
+
+	moved, err := s.Dome.Step()
+
+	if err != nil || !moved {
+		return err
+	}
+
+	return WaitWhileMoving(ctx, s.PollInterval, s.Dome.Dome.IsSlewing)
+}
+
+/*
+selectFilter()
+
+Changes to the named filter, unless it is already selected or no filter is named.
+*/
+func (s *Sequencer) selectFilter(ctx context.Context, name string) error {
+	if s.Filters == nil || name == "" || name == s.filter {
+		return nil
+	}
+
+	if _, err := s.Filters.ChangeFilter(ctx, name); err != nil {
+		return err
+	}
+
+	s.filter = name
+
+	return nil
+}
+
+/*
+focus()
+
+Runs autofocus and, if temperature compensation is enabled, takes the new reference temperature.
+*/
+func (s *Sequencer) focus(ctx context.Context) error {
+	if s.Autofocus == nil {
+		return nil
+	}
+
+	report, err := s.Autofocus.Run(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if s.Compensator != nil {
+		s.Compensator.SetReference(report.Temperature)
+	}
+
+	return nil
+}
+
+/*
+complete()
+
+Records a completed frame, and saves the checkpoint if a path is configured.
+*/
+func (s *Sequencer) complete(target int, exposure int) error {
+	s.mu.Lock()
+
+	s.checkpoint.Completed[target][exposure]++
+
+	s.checkpoint.Updated = time.Now()
+
+	checkpoint := s.checkpoint
+
+	s.mu.Unlock()
+
+	if s.CheckpointPath == "" {
+		return nil
+	}
+
+	return checkpoint.Save(s.CheckpointPath)
+}
+
+/*
+Run()
+
+Executes the sequence: for each target, acquires it, optionally focuses, and takes each exposure
+the requested number of times. Progress resumes from the checkpoint, if one exists. Whilst the
+safety source reports unsafe the sequence waits, and re-acquires the target once safe. A frame is
+retaken if conditions become unsafe during it, or if it fails whilst unsafe (e.g., the camera returns
+to idle, aborted by a SafetySupervisor).
+
+@returns nil once the sequence is complete, ErrSequenceAborted if aborted, or the first error.
+*/
+func (s *Sequencer) Run(ctx context.Context) (err error) {
+	checkpoint := NewSequenceCheckpoint(s.Sequence)
+
+	if s.CheckpointPath != "" {
+		if checkpoint, err = LoadSequenceCheckpoint(s.CheckpointPath, s.Sequence); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	s.mu.Lock()
+
+	s.checkpoint, s.cancel, s.aborted, s.filter = checkpoint, cancel, false, ""
+
+	s.mu.Unlock()
+
+	s.setState(SequenceRunning)
+
+	defer func() {
+		s.mu.Lock()
+
+		aborted := s.aborted
+
+		s.cancel = nil
+
+		s.mu.Unlock()
+
+		switch {
+		case aborted:
+			err = ErrSequenceAborted
+			s.setState(SequenceAborted)
+		case err != nil:
+			s.setState(SequenceFailed)
+		default:
+			s.setState(SequenceComplete)
+		}
+	}()
+
+	for t, target := range s.Sequence.Targets {
+		acquired, focused := false, !target.Autofocus
+
+		for e, exposure := range target.Exposures {
+			for checkpoint.Completed[t][e] < exposure.Count {
+				if err := s.waitIfPaused(ctx); err != nil {
+					return err
+				}
+
+				interrupted, err := s.waitUntilSafe(ctx)
+
+				if err != nil {
+					return err
+				}
+
+				if interrupted || !acquired {
+					if err := s.acquire(ctx, target); err != nil {
+						return err
+					}
+
+					acquired = true
+				} else if err := s.syncDome(ctx); err != nil {
+					return err
+				}
+
+				if err := s.selectFilter(ctx, exposure.Filter); err != nil {
+					return err
+				}
+
+				if !focused {
+					if err := s.focus(ctx); err != nil {
+						return err
+					}
+
+					focused = true
+				}
+
+				if s.MeridianFlip != nil {
+					flipped, err := s.MeridianFlip.BeforeExposure(ctx, exposure.Duration)
+
+					if err != nil {
+						return err
+					}
+
+					if flipped {
+						if err := s.syncDome(ctx); err != nil {
+							return err
+						}
+					}
+				}
+
+				if s.Compensator != nil {
+					if _, err := s.Compensator.Step(ctx); err != nil {
+						return err
+					}
+				}
+
+				frame := SequenceFrame{
+					Target:   target.Name,
+					Filter:   exposure.Filter,
+					Duration: exposure.Duration,
+					Light:    !exposure.Dark,
+					Index:    checkpoint.Completed[t][e] + 1,
+					Count:    exposure.Count,
+					Time:     time.Now(),
+				}
+
+				image, err := capture(ctx, s.Camera, exposure.Duration, !exposure.Dark, s.PollInterval, func() error {
+					if !s.isSafe() {
+						return errSequenceUnsafe
+					}
+
+					return nil
+				})
+
+				if errors.Is(err, errSequenceUnsafe) || (err != nil && ctx.Err() == nil && !s.isSafe()) {
+					// The exposure was lost to a safety interruption, so retake it once safe:
+					continue
+				}
+
+				if err != nil {
+					return err
+				}
+
+				if s.OnFrame != nil {
+					if err := s.OnFrame(frame, image); err != nil {
+						return err
+					}
+				}
+
+				if err := s.complete(t, e); err != nil {
+					return err
+				}
+
+				checkpoint = s.GetCheckpoint()
+			}
+		}
+	}
+
+	return nil
+}
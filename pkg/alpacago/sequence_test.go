@@ -0,0 +1,360 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeSequenceTelescope struct {
+	slews [][2]float64
+}
+
+func (t *fakeSequenceTelescope) SetSlewToCoordinatesAsync(rightAscension float64, declination float64) error {
+	t.slews = append(t.slews, [2]float64{rightAscension, declination})
+	return nil
+}
+
+func (t *fakeSequenceTelescope) IsSlewing() (bool, error) { return false, nil }
+
+type fakeSequenceCamera struct {
+	exposures []float64
+	// Exposures fail whilst set
+	err error
+}
+
+func (c *fakeSequenceCamera) StartExposure(duration float64, light bool) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	c.exposures = append(c.exposures, duration)
+
+	return nil
+}
+
+func (c *fakeSequenceCamera) IsImageReady() (bool, error) { return true, nil }
+
+func (c *fakeSequenceCamera) GetExposure() ([][]uint32, uint32, error) {
+	return [][]uint32{{0}}, 2, nil
+}
+
+const testSequence = `{
+	"name": "M42",
+	"targets": [
+		{
+			"name": "M42", "ra": 5.588, "dec": -5.39,
+			"exposures": [{ "filter": "Red", "duration": 60, "count": 2 }, { "filter": "Blue", "duration": 90, "count": 2 }]
+		},
+		{
+			"name": "M45", "ra": 3.79, "dec": 24.1,
+			"exposures": [{ "filter": "Red", "duration": 30, "count": 1 }]
+		}
+	]
+}`
+
+func newTestSequencer(t *testing.T, camera *fakeSequenceCamera) (*Sequencer, *fakeSequenceTelescope, *fakeOffsetFilterWheel) {
+	sequence, err := ParseSequence([]byte(testSequence))
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	telescope := &fakeSequenceTelescope{}
+
+	wheel := &fakeOffsetFilterWheel{names: []string{"Lum", "Red", "Blue"}, offsets: []uint32{0, 0, 0}}
+
+	sequencer := NewSequencer(sequence, camera)
+
+	sequencer.Telescope = telescope
+	sequencer.Filters = NewFilterChanger(wheel, nil)
+	sequencer.Filters.PollInterval = time.Millisecond
+	sequencer.PollInterval = time.Millisecond
+	sequencer.SafetyInterval = time.Millisecond
+
+	return sequencer, telescope, wheel
+}
+
+func TestParseSequenceValidates(t *testing.T) {
+	invalid := []string{
+		`{"name": "empty", "targets": []}`,
+		`{"name": "ra", "targets": [{"ra": 25, "dec": 0, "exposures": [{"duration": 1, "count": 1}]}]}`,
+		`{"name": "count", "targets": [{"ra": 1, "dec": 0, "exposures": [{"duration": 1, "count": 0}]}]}`,
+		`{"name": "unknown", "targets": [{"ra": 1, "dec": 0, "exposure": [{"duration": 1, "count": 1}]}]}`,
+	}
+
+	for _, data := range invalid {
+		if _, err := ParseSequence([]byte(data)); err == nil {
+			t.Errorf("got nil, wanted an error for %s", data)
+		}
+	}
+}
+
+func TestSequencerRun(t *testing.T) {
+	camera := &fakeSequenceCamera{}
+
+	sequencer, telescope, wheel := newTestSequencer(t, camera)
+
+	frames := []SequenceFrame{}
+
+	sequencer.OnFrame = func(frame SequenceFrame, image [][]uint32) error {
+		frames = append(frames, frame)
+		return nil
+	}
+
+	if err := sequencer.Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(frames) != 5 || len(camera.exposures) != 5 {
+		t.Errorf("got %d frames, wanted %d", len(frames), 5)
+	}
+
+	if len(telescope.slews) != 2 || telescope.slews[1] != [2]float64{3.79 * 15, 24.1} {
+		t.Errorf("got %v, wanted a slew to each target", telescope.slews)
+	}
+
+	if frames[2].Filter != "Blue" || frames[2].Index != 1 || frames[2].Count != 2 {
+		t.Errorf("got %+v, wanted the first of two Blue frames", frames[2])
+	}
+
+	if wheel.position != 1 {
+		t.Errorf("got %d, wanted the wheel left on Red", wheel.position)
+	}
+
+	if got := sequencer.GetState(); got != SequenceComplete {
+		t.Errorf("got %q, wanted %q", got, SequenceComplete)
+	}
+}
+
+func TestSequencerResumesFromCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	camera := &fakeSequenceCamera{}
+
+	sequencer, _, _ := newTestSequencer(t, camera)
+
+	sequencer.CheckpointPath = path
+
+	sequencer.OnFrame = func(frame SequenceFrame, image [][]uint32) error {
+		if frame.Filter == "Blue" {
+			return errors.New("disk full")
+		}
+
+		return nil
+	}
+
+	if err := sequencer.Run(context.Background()); err == nil {
+		t.Fatalf("got nil, wanted the sequence to stop on the first Blue frame")
+	}
+
+	// A new run continues from the first Blue frame:
+	camera = &fakeSequenceCamera{}
+
+	sequencer, _, _ = newTestSequencer(t, camera)
+
+	sequencer.CheckpointPath = path
+
+	if err := sequencer.Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(camera.exposures) != 3 || camera.exposures[0] != 90 {
+		t.Errorf("got %v, wanted [90 90 30]", camera.exposures)
+	}
+}
+
+func TestSequencerPauseAndAbort(t *testing.T) {
+	camera := &fakeSequenceCamera{}
+
+	sequencer, _, _ := newTestSequencer(t, camera)
+
+	states := make(chan SequenceState, 16)
+
+	sequencer.OnStateChange = func(state SequenceState) { states <- state }
+
+	sequencer.OnFrame = func(frame SequenceFrame, image [][]uint32) error {
+		sequencer.Pause()
+		return nil
+	}
+
+	done := make(chan error)
+
+	go func() { done <- sequencer.Run(context.Background()) }()
+
+	for state := range states {
+		if state == SequencePaused {
+			break
+		}
+	}
+
+	sequencer.Abort()
+
+	if err := <-done; !errors.Is(err, ErrSequenceAborted) {
+		t.Errorf("got %v, wanted %q", err, ErrSequenceAborted)
+	}
+
+	if len(camera.exposures) != 1 {
+		t.Errorf("got %d exposures, wanted %d", len(camera.exposures), 1)
+	}
+}
+
+func TestSequencerSafetyInterruption(t *testing.T) {
+	camera := &fakeSequenceCamera{}
+
+	sequencer, telescope, _ := newTestSequencer(t, camera)
+
+	safety := &fakeSafetySource{safe: true}
+
+	sequencer.Safety = safety
+
+	interrupted := 0
+
+	sequencer.OnStateChange = func(state SequenceState) {
+		switch state {
+		case SequenceInterrupted:
+			interrupted++
+			// Conditions clear once the sequence is waiting:
+			safety.safe = true
+		}
+	}
+
+	sequencer.OnFrame = func(frame SequenceFrame, image [][]uint32) error {
+		if frame.Index == 1 && frame.Filter == "Red" && frame.Target == "M42" {
+			safety.safe = false
+		}
+
+		return nil
+	}
+
+	if err := sequencer.Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if interrupted != 1 {
+		t.Errorf("got %d interruptions, wanted %d", interrupted, 1)
+	}
+
+	// M42 is re-acquired after the interruption:
+	if len(telescope.slews) != 3 {
+		t.Errorf("got %d slews, wanted %d", len(telescope.slews), 3)
+	}
+
+	if len(camera.exposures) != 5 {
+		t.Errorf("got %d exposures, wanted %d", len(camera.exposures), 5)
+	}
+}
+
+/*
+abortableSequenceCamera exposes until its image is made ready or the exposure is aborted, as a real camera
+does, calling onPoll whenever it is asked if the image is ready.
+*/
+type abortableSequenceCamera struct {
+	fakeSequenceCamera
+	state  OperationalState
+	ready  bool
+	polls  int
+	onPoll func(c *abortableSequenceCamera)
+}
+
+func (c *abortableSequenceCamera) StartExposure(duration float64, light bool) error {
+	c.state, c.ready, c.polls = CameraExposing, false, 0
+
+	return c.fakeSequenceCamera.StartExposure(duration, light)
+}
+
+func (c *abortableSequenceCamera) IsImageReady() (bool, error) {
+	c.polls++
+
+	if c.onPoll != nil {
+		c.onPoll(c)
+	}
+
+	return c.ready, nil
+}
+
+func (c *abortableSequenceCamera) GetOperationalState() (string, error) { return c.state.String(), nil }
+
+func (c *abortableSequenceCamera) AbortExposure() error {
+	c.state = CameraIdle
+	return nil
+}
+
+func TestCaptureAborted(t *testing.T) {
+	camera := &abortableSequenceCamera{onPoll: func(c *abortableSequenceCamera) {
+		if c.polls == 3 {
+			c.AbortExposure()
+		}
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if _, err := Capture(ctx, camera, 1, true, time.Millisecond); !errors.Is(err, ErrExposureAborted) {
+		t.Errorf("got %v, wanted %q", err, ErrExposureAborted)
+	}
+}
+
+func TestSequencerRetakesAbortedFrame(t *testing.T) {
+	safety := &fakeSafetySource{safe: true}
+
+	// The second exposure is aborted part way through, as conditions become unsafe; the others complete:
+	camera := &abortableSequenceCamera{onPoll: func(c *abortableSequenceCamera) {
+		switch {
+		case len(c.exposures) == 2 && c.polls == 2:
+			safety.safe = false
+			c.AbortExposure()
+		case len(c.exposures) != 2 && c.polls == 2:
+			c.state, c.ready = CameraIdle, true
+		}
+	}}
+
+	sequence, err := ParseSequence([]byte(testSequence))
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	sequencer := NewSequencer(sequence, camera)
+
+	sequencer.PollInterval = time.Millisecond
+	sequencer.SafetyInterval = time.Millisecond
+	sequencer.Safety = safety
+
+	sequencer.OnStateChange = func(state SequenceState) {
+		if state == SequenceInterrupted {
+			safety.safe = true
+		}
+	}
+
+	frames := 0
+
+	sequencer.OnFrame = func(frame SequenceFrame, image [][]uint32) error {
+		frames++
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if err := sequencer.Run(ctx); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if frames != 5 || len(camera.exposures) != 6 {
+		t.Errorf("got %d frames from %d exposures, wanted 5 from 6", frames, len(camera.exposures))
+	}
+}
+
+func TestSequenceStateString(t *testing.T) {
+	var got string = SequenceInterrupted.String()
+	var want string = "interrupted"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package alpacago
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+SafetyMonitorServer serves any SafetySource (e.g., a ConditionsSafetyMonitor) as an
+Alpaca safetymonitor device, so that other Alpaca clients can consume it over HTTP.
+
+@see https://ascom-standards.org/api/#/SafetyMonitor%20Specific%20Methods
+*/
+type SafetyMonitorServer struct {
+	Source       SafetySource
+	DeviceNumber uint
+	Name         string
+	Description  string
+
+	connected           bool
+	serverTransactionId uint32
+	mu                  sync.Mutex
+}
+
+func NewSafetyMonitorServer(source SafetySource, deviceNumber uint, name string) *SafetyMonitorServer {
+	server := SafetyMonitorServer{
+		Source:       source,
+		DeviceNumber: deviceNumber,
+		Name:         name,
+		Description:  name,
+		connected:    true,
+	}
+
+	return &server
+}
+
+/*
+getAlpacaParameter()
+
+Alpaca parameter names are case-insensitive, and are read from the query string for GET
+requests and from the form body for PUT requests.
+*/
+func getAlpacaParameter(r *http.Request, name string) (string, bool) {
+	values := r.URL.Query()
+
+	if r.Method == http.MethodPut {
+		if err := r.ParseForm(); err == nil {
+			values = r.PostForm
+		}
+	}
+
+	for key, v := range values {
+		if strings.EqualFold(key, name) && len(v) > 0 {
+			return v[0], true
+		}
+	}
+
+	return "", false
+}
+
+func (s *SafetyMonitorServer) respond(w http.ResponseWriter, r *http.Request, value interface{}, errorNumber int32, errorMessage string) {
+	var clientTransactionId uint32 = 0
+
+	if id, ok := getAlpacaParameter(r, "ClientTransactionID"); ok {
+		if n, err := strconv.ParseUint(id, 10, 32); err == nil {
+			clientTransactionId = uint32(n)
+		}
+	}
+
+	response := map[string]interface{}{
+		"ClientTransactionID": clientTransactionId,
+		"ServerTransactionID": atomic.AddUint32(&s.serverTransactionId, 1),
+		"ErrorNumber":         errorNumber,
+		"ErrorMessage":        errorMessage,
+	}
+
+	// Responses to PUT methods carry no value:
+	if value != nil {
+		response["Value"] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(response)
+}
+
+/*
+ServeHTTP()
+
+Handles the Alpaca device API for the safety monitor (/api/v1/safetymonitor/{device_number}/{method})
+and the management API (/management/...).
+*/
+func (s *SafetyMonitorServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.ToLower(r.URL.Path), "/")
+
+	switch path {
+	case "management/apiversions":
+		s.respond(w, r, []uint32{1}, 0, "")
+		return
+	case "management/v1/description":
+		s.respond(w, r, map[string]string{
+			"ServerName":          s.Name,
+			"Manufacturer":        "observerly",
+			"ManufacturerVersion": "1.0",
+			"Location":            "",
+		}, 0, "")
+		return
+	case "management/v1/configureddevices":
+		s.respond(w, r, []map[string]interface{}{{
+			"DeviceName":   s.Name,
+			"DeviceType":   "SafetyMonitor",
+			"DeviceNumber": s.DeviceNumber,
+			"UniqueID":     fmt.Sprintf("alpacago-safetymonitor-%d", s.DeviceNumber),
+		}}, 0, "")
+		return
+	}
+
+	prefix := fmt.Sprintf("api/v1/safetymonitor/%d/", s.DeviceNumber)
+
+	if !strings.HasPrefix(path, prefix) {
+		http.Error(w, fmt.Sprintf("unknown device or path %q", r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	method := strings.TrimPrefix(path, prefix)
+
+	if r.Method == http.MethodPut {
+		s.put(w, r, method)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("unsupported HTTP method %q", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch method {
+	case "connected":
+		s.mu.Lock()
+		connected := s.connected
+		s.mu.Unlock()
+		s.respond(w, r, connected, 0, "")
+	case "description":
+		s.respond(w, r, s.Description, 0, "")
+	case "driverinfo":
+		s.respond(w, r, "alpacago synthetic safety monitor", 0, "")
+	case "driverversion":
+		s.respond(w, r, "1.0", 0, "")
+	case "interfaceversion":
+		s.respond(w, r, 1, 0, "")
+	case "name":
+		s.respond(w, r, s.Name, 0, "")
+	case "supportedactions":
+		s.respond(w, r, []string{}, 0, "")
+	case "issafe":
+		s.mu.Lock()
+		connected := s.connected
+		s.mu.Unlock()
+
+		// IsSafe is only available whilst connected (ASCOM NotConnected, 0x407):
+		if !connected {
+			s.respond(w, r, false, 0x407, "the safety monitor is not connected")
+			return
+		}
+
+		// IsSafe must not raise an error; an unknown state is reported as unsafe:
+		safe, err := s.Source.IsSafe()
+		s.respond(w, r, err == nil && safe, 0, "")
+	default:
+		http.Error(w, fmt.Sprintf("unknown method %q", method), http.StatusBadRequest)
+	}
+}
+
+func (s *SafetyMonitorServer) put(w http.ResponseWriter, r *http.Request, method string) {
+	switch method {
+	case "connected":
+		value, ok := getAlpacaParameter(r, "Connected")
+
+		connected, err := strconv.ParseBool(value)
+
+		if !ok || err != nil {
+			http.Error(w, fmt.Sprintf("invalid Connected value %q", value), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.connected = connected
+		s.mu.Unlock()
+
+		s.respond(w, r, nil, 0, "")
+	case "action", "commandblind", "commandbool", "commandstring":
+		s.respond(w, r, nil, 0x400, fmt.Sprintf("%s is not implemented", method))
+	default:
+		http.Error(w, fmt.Sprintf("unknown method %q", method), http.StatusBadRequest)
+	}
+}
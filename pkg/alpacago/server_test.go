@@ -0,0 +1,125 @@
+package alpacago
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newTestSafetyMonitorClient(t *testing.T, server *httptest.Server) *SafetyMonitor {
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	p, _ := strconv.Atoi(port)
+
+	return NewSafetyMonitor(65535, false, "", host, int32(p), 0)
+}
+
+func TestSafetyMonitorServerIsSafe(t *testing.T) {
+	source := &fakeSafetySource{safe: true}
+
+	server := httptest.NewServer(NewSafetyMonitorServer(source, 0, "Synthetic Safety Monitor"))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	got, err := monitor.IsSafe()
+
+	if err != nil || !got {
+		t.Errorf("got %t (%v), wanted true", got, err)
+	}
+
+	source.safe = false
+
+	got, err = monitor.IsSafe()
+
+	if err != nil || got {
+		t.Errorf("got %t (%v), wanted false", got, err)
+	}
+}
+
+func TestSafetyMonitorServerDescription(t *testing.T) {
+	server := httptest.NewServer(NewSafetyMonitorServer(&fakeSafetySource{safe: true}, 0, "Synthetic Safety Monitor"))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	got, err := monitor.GetDescription()
+
+	var want string = "Synthetic Safety Monitor"
+
+	if err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestSafetyMonitorServerSetConnected(t *testing.T) {
+	server := httptest.NewServer(NewSafetyMonitorServer(&fakeSafetySource{safe: true}, 0, "Synthetic Safety Monitor"))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	if err := monitor.SetConnected(false); err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	got, err := monitor.IsConnected()
+
+	if err != nil || got {
+		t.Errorf("got %t (%v), wanted false", got, err)
+	}
+}
+
+func TestSafetyMonitorServerIsSafeNotConnected(t *testing.T) {
+	server := NewSafetyMonitorServer(&fakeSafetySource{safe: true}, 0, "Synthetic Safety Monitor")
+
+	server.connected = false
+
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/safetymonitor/0/issafe", nil))
+
+	result := TransportEnvelope{}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var got int32 = result.ErrorNumber
+
+	var want int32 = 0x407
+
+	if got != want || string(result.Value) != "false" {
+		t.Errorf("got %#x (%s), wanted %#x (false)", got, result.Value, want)
+	}
+}
+
+func TestSafetyMonitorServerUnknownMethod(t *testing.T) {
+	server := httptest.NewServer(NewSafetyMonitorServer(&fakeSafetySource{safe: true}, 0, "Synthetic Safety Monitor"))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	monitor.Alpaca.GetBooleanResponse("safetymonitor", 0, "isunsafe")
+
+	var got int = monitor.Alpaca.ErrorNumber
+	var want int = 400
+
+	if got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package alpacago
+
+import (
+	"context"
+	"time"
+)
+
+type SkyAngleRotator interface {
+	GetPosition() (float64, error)
+	SetMoveAbsolute(position float64) error
+	SetSync(position float64) error
+	IsMoving() (bool, error)
+}
+
+type PierSideSource interface {
+	GetSideOfPier() (PierPointingMode, error)
+}
+
+/*
+SkyRotator controls a rotator by the position angle of the camera on the sky (degrees, East of North),
+rather than by its raw position.
+
+Once synced to a plate-solved camera angle, the rotator position is the sky position angle with the
+mount on the West of the pier (PierWest). After a meridian flip (PierEast) the camera is turned through
+180° on the sky, so the same position angle is reached at the opposite rotator position. The ASCOM
+position already reflects the rotator's Reverse property, so only the Parity of the optical train is
+applied.
+*/
+type SkyRotator struct {
+	Rotator SkyAngleRotator
+	// The mount, whose pier side determines the flip of the camera on the sky (optional)
+	Telescope PierSideSource
+	// Set to -1 if the sky position angle decreases as the rotator position increases e.g., for an optical
+	// train with an odd number of reflections
+	Parity       float64
+	PollInterval time.Duration
+}
+
+func NewSkyRotator(rotator SkyAngleRotator, telescope PierSideSource) *SkyRotator {
+	sky := SkyRotator{
+		Rotator:      rotator,
+		Telescope:    telescope,
+		Parity:       1,
+		PollInterval: 250 * time.Millisecond,
+	}
+
+	return &sky
+}
+
+/*
+getOrientation()
+
+@returns the sense (±1) in which the sky position angle turns with the rotator position, and the flip (0° or
+180°) of the camera on the sky for the mount's current pier side.
+*/
+func (s *SkyRotator) getOrientation() (float64, float64, error) {
+	sense := s.Parity
+
+	if sense == 0 {
+		sense = 1
+	}
+
+	if s.Telescope == nil {
+		return sense, 0, nil
+	}
+
+	side, err := s.Telescope.GetSideOfPier()
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if side == PierEast {
+		return sense, 180, nil
+	}
+
+	return sense, 0, nil
+}
+
+/*
+GetRotatorPosition()
+
+@returns the rotator position (degrees, 0 to 360) at which the camera has the given sky position angle.
+*/
+func (s *SkyRotator) GetRotatorPosition(positionAngle float64) (float64, error) {
+	sense, flip, err := s.getOrientation()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return getNormalisedAngle(sense*(positionAngle-flip), 360), nil
+}
+
+/*
+GetPositionAngle()
+
+@returns the sky position angle (degrees, East of North, 0 to 360) of the camera.
+*/
+func (s *SkyRotator) GetPositionAngle() (float64, error) {
+	position, err := s.Rotator.GetPosition()
+
+	if err != nil {
+		return 0, err
+	}
+
+	sense, flip, err := s.getOrientation()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return getNormalisedAngle(sense*position+flip, 360), nil
+}
+
+/*
+SyncPositionAngle()
+
+Syncs the rotator so that its current position corresponds to the given sky position angle e.g., the
+camera angle from a plate solve.
+*/
+func (s *SkyRotator) SyncPositionAngle(positionAngle float64) error {
+	position, err := s.GetRotatorPosition(positionAngle)
+
+	if err != nil {
+		return err
+	}
+
+	return s.Rotator.SetSync(position)
+}
+
+/*
+MoveToPositionAngle()
+
+Moves the rotator to give the camera the sky position angle, and waits for the move to complete.
+*/
+func (s *SkyRotator) MoveToPositionAngle(ctx context.Context, positionAngle float64) error {
+	position, err := s.GetRotatorPosition(positionAngle)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.Rotator.SetMoveAbsolute(position); err != nil {
+		return err
+	}
+
+	return WaitWhileMoving(ctx, s.PollInterval, s.Rotator.IsMoving)
+}
@@ -0,0 +1,132 @@
+package alpacago
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+type fakeSkyAngleRotator struct {
+	position float64
+	moves    []float64
+}
+
+func (r *fakeSkyAngleRotator) GetPosition() (float64, error) { return r.position, nil }
+
+func (r *fakeSkyAngleRotator) SetMoveAbsolute(position float64) error {
+	r.moves = append(r.moves, position)
+	r.position = position
+	return nil
+}
+
+func (r *fakeSkyAngleRotator) SetSync(position float64) error {
+	r.position = position
+	return nil
+}
+
+func (r *fakeSkyAngleRotator) IsMoving() (bool, error) { return false, nil }
+
+type fakePierSideSource struct {
+	side PierPointingMode
+}
+
+func (t *fakePierSideSource) GetSideOfPier() (PierPointingMode, error) { return t.side, nil }
+
+func newTestSkyRotator(rotator *fakeSkyAngleRotator, side PierPointingMode) (*SkyRotator, *fakePierSideSource) {
+	telescope := &fakePierSideSource{side: side}
+
+	sky := NewSkyRotator(rotator, telescope)
+
+	sky.PollInterval = time.Millisecond
+
+	return sky, telescope
+}
+
+func TestSkyRotatorSyncAndMove(t *testing.T) {
+	rotator := &fakeSkyAngleRotator{position: 10}
+
+	sky, telescope := newTestSkyRotator(rotator, PierWest)
+
+	// A plate solve finds the camera at 100° on the sky:
+	if err := sky.SyncPositionAngle(100); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if got, _ := sky.GetPositionAngle(); math.Abs(got-100) > 1e-9 {
+		t.Errorf("got %f, wanted %f", got, 100.0)
+	}
+
+	if err := sky.MoveToPositionAngle(context.Background(), 45); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if rotator.position != 45 {
+		t.Errorf("got %f, wanted %f", rotator.position, 45.0)
+	}
+
+	// After the meridian flip, the same framing is 180° round:
+	telescope.side = PierEast
+
+	if got, _ := sky.GetPositionAngle(); math.Abs(got-225) > 1e-9 {
+		t.Errorf("got %f, wanted %f", got, 225.0)
+	}
+
+	if err := sky.MoveToPositionAngle(context.Background(), 45); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if rotator.position != 225 {
+		t.Errorf("got %f, wanted %f", rotator.position, 225.0)
+	}
+}
+
+func TestSkyRotatorParity(t *testing.T) {
+	rotator := &fakeSkyAngleRotator{}
+
+	sky, _ := newTestSkyRotator(rotator, PierEast)
+
+	sky.Parity = -1
+
+	if err := sky.SyncPositionAngle(30); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if got, _ := sky.GetPositionAngle(); math.Abs(got-30) > 1e-9 {
+		t.Errorf("got %f, wanted %f", got, 30.0)
+	}
+
+	// With a mirror in the optical train, the rotator turns the other way to increase the position angle:
+	before := rotator.position
+
+	if err := sky.MoveToPositionAngle(context.Background(), 40); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if got := getNormalisedAngle(rotator.position-before, 360); math.Abs(got-350) > 1e-9 {
+		t.Errorf("got a move of %f, wanted %f", got, 350.0)
+	}
+}
+
+func TestSequencerSkyRotator(t *testing.T) {
+	sequence, err := ParseSequence([]byte(`{"name": "M42", "targets": [{"ra": 5.588, "dec": -5.39, "rotation": 45, "exposures": [{"duration": 1, "count": 1}]}]}`))
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	rotator := &fakeSkyAngleRotator{}
+
+	sequencer := NewSequencer(sequence, &fakeSequenceCamera{})
+
+	sequencer.PollInterval = time.Millisecond
+	sequencer.SkyRotator, _ = newTestSkyRotator(rotator, PierEast)
+
+	if err := sequencer.Run(context.Background()); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(rotator.moves) != 1 || rotator.moves[0] != 225 {
+		t.Errorf("got %v, wanted a move to %f", rotator.moves, 225.0)
+	}
+}
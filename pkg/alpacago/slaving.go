@@ -0,0 +1,282 @@
+package alpacago
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type SlavingTelescope interface {
+	GetRightAscension() (float64, error)
+	GetDeclination() (float64, error)
+	GetSideOfPier() (PierPointingMode, error)
+	GetSiderealTime() (float64, error)
+	GetSiteLatitude() (float64, error)
+}
+
+type SlavingDome interface {
+	GetAzimuth() (float64, error)
+	IsSlewing() (bool, error)
+	SlewToAzimuth(azimuth float64) error
+}
+
+/*
+DomeGeometry describes the position of a German equatorial mount within the dome.
+
+All distances are in meters. The mount offsets are measured from the centre of the dome
+sphere to the intersection of the right ascension and declination axes.
+*/
+type DomeGeometry struct {
+	// The radius of the dome
+	DomeRadius float64
+	// The offset of the mount (axes intersection) east of the dome centre
+	MountOffsetEast float64
+	// The offset of the mount (axes intersection) north of the dome centre
+	MountOffsetNorth float64
+	// The offset of the mount (axes intersection) above the dome centre
+	MountOffsetUp float64
+	// The distance along the declination axis from the right ascension axis to the optical axis
+	DecAxisOffset float64
+	// The lateral offset of the optical axis, perpendicular to the declination axis (e.g., side-by-side OTAs)
+	OTAOffset float64
+}
+
+/*
+GetDomeAzimuth()
+
+@param hourAngle float64 (the hour angle of the target, in hours)
+@param declination float64 (the declination of the target, in degrees)
+@param latitude float64 (the site latitude, in degrees)
+@param sideOfPier PierPointingMode (the pointing state of the mount, pierUnknown infers it from the hour angle)
+@returns the azimuth and altitude (degrees) at which the optical axis intersects the dome, accounting for the
+mount offsets and the side of pier of the optical tube assembly.
+*/
+func (g DomeGeometry) GetDomeAzimuth(hourAngle float64, declination float64, latitude float64, sideOfPier PierPointingMode) (float64, float64) {
+	H := hourAngle * 15 * math.Pi / 180
+
+	δ := declination * math.Pi / 180
+
+	φ := latitude * math.Pi / 180
+
+	// In the pointing state "pierEast" the optical tube sits on the east side, looking west:
+	var side float64 = 1
+
+	switch sideOfPier {
+	case PierEast:
+		side = 1
+	case PierWest:
+		side = -1
+	default:
+		if math.Sin(H) < 0 {
+			side = -1
+		}
+	}
+
+	// The pointing direction in the equatorial (hour angle) frame, where x is towards the
+	// meridian on the celestial equator, y is towards the east point and z is the celestial pole:
+	pointing := [3]float64{math.Cos(δ) * math.Cos(H), -math.Cos(δ) * math.Sin(H), math.Sin(δ)}
+
+	// The declination axis is perpendicular to the polar axis and to the pointing direction:
+	axis := [3]float64{side * math.Sin(H), side * math.Cos(H), 0}
+
+	// The lateral direction is perpendicular to both the declination axis and the pointing direction:
+	lateral := [3]float64{
+		pointing[1]*axis[2] - pointing[2]*axis[1],
+		pointing[2]*axis[0] - pointing[0]*axis[2],
+		pointing[0]*axis[1] - pointing[1]*axis[0],
+	}
+
+	offset := [3]float64{}
+
+	for i := range offset {
+		offset[i] = g.DecAxisOffset*axis[i] + g.OTAOffset*lateral[i]
+	}
+
+	// Rotate from the equatorial frame to the horizontal (east, north, up) frame:
+	toHorizontal := func(v [3]float64) [3]float64 {
+		return [3]float64{
+			v[1],
+			-v[0]*math.Sin(φ) + v[2]*math.Cos(φ),
+			v[0]*math.Cos(φ) + v[2]*math.Sin(φ),
+		}
+	}
+
+	o := toHorizontal(offset)
+
+	origin := [3]float64{g.MountOffsetEast + o[0], g.MountOffsetNorth + o[1], g.MountOffsetUp + o[2]}
+
+	u := toHorizontal(pointing)
+
+	// Intersect the ray origin + t·u (t > 0) with the dome sphere |x| = R:
+	b := origin[0]*u[0] + origin[1]*u[1] + origin[2]*u[2]
+
+	c := origin[0]*origin[0] + origin[1]*origin[1] + origin[2]*origin[2] - g.DomeRadius*g.DomeRadius
+
+	t := -b + math.Sqrt(math.Max(b*b-c, 0))
+
+	x := [3]float64{origin[0] + t*u[0], origin[1] + t*u[1], origin[2] + t*u[2]}
+
+	azimuth := math.Mod(math.Atan2(x[0], x[1])*180/math.Pi+360, 360)
+
+	altitude := math.Atan2(x[2], math.Hypot(x[0], x[1])) * 180 / math.Pi
+
+	return azimuth, altitude
+}
+
+type DomeSlaving struct {
+	Telescope SlavingTelescope
+	Dome      SlavingDome
+	Geometry  DomeGeometry
+	// The minimum difference (degrees) between the dome and the required azimuth before the dome is moved
+	Hysteresis float64
+	// The interval at which the telescope position is polled
+	Interval time.Duration
+	// The most recently computed dome azimuth (degrees)
+	Azimuth float64
+
+	latitude    float64
+	hasLatitude bool
+}
+
+func NewDomeSlaving(telescope SlavingTelescope, dome SlavingDome, geometry DomeGeometry) *DomeSlaving {
+	slaving := DomeSlaving{
+		Telescope:  telescope,
+		Dome:       dome,
+		Geometry:   geometry,
+		Hysteresis: 3,
+		Interval:   5 * time.Second,
+	}
+
+	return &slaving
+}
+
+/*
+GetRequiredAzimuth()
+
+@returns the dome azimuth (degrees) required for the telescope's current position.
+*/
+func (s *DomeSlaving) GetRequiredAzimuth() (float64, error) {
+	if !s.hasLatitude {
+		latitude, err := s.Telescope.GetSiteLatitude()
+
+		if err != nil {
+			return 0, err
+		}
+
+		s.latitude = latitude
+
+		s.hasLatitude = true
+	}
+
+	lst, err := s.Telescope.GetSiderealTime()
+
+	if err != nil {
+		return 0, err
+	}
+
+	ra, err := s.Telescope.GetRightAscension()
+
+	if err != nil {
+		return 0, err
+	}
+
+	dec, err := s.Telescope.GetDeclination()
+
+	if err != nil {
+		return 0, err
+	}
+
+	pier, err := s.Telescope.GetSideOfPier()
+
+	if err != nil {
+		return 0, err
+	}
+
+	azimuth, _ := s.Geometry.GetDomeAzimuth(lst-ra, dec, s.latitude, pier)
+
+	s.Azimuth = azimuth
+
+	return azimuth, nil
+}
+
+/*
+Step()
+
+Computes the required dome azimuth and slews the dome if it lies outside of the hysteresis band.
+
+@returns true if the dome was commanded to slew.
+*/
+func (s *DomeSlaving) Step() (bool, error) {
+	azimuth, err := s.GetRequiredAzimuth()
+
+	if err != nil {
+		return false, err
+	}
+
+	slewing, err := s.Dome.IsSlewing()
+
+	if err != nil {
+		return false, err
+	}
+
+	// Let any in-progress dome movement complete before issuing another:
+	if slewing {
+		return false, nil
+	}
+
+	current, err := s.Dome.GetAzimuth()
+
+	if err != nil {
+		return false, err
+	}
+
+	if math.Abs(getAngularSeparation(current, azimuth)) <= s.Hysteresis {
+		return false, nil
+	}
+
+	return true, s.Dome.SlewToAzimuth(azimuth)
+}
+
+/*
+Run()
+
+Slaves the dome to the telescope, polling at the configured interval until the context is cancelled.
+
+@returns the context error once cancelled, or the first device error encountered.
+*/
+func (s *DomeSlaving) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Step(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+getAngularSeparation()
+
+@returns the signed shortest angular distance (degrees) from a to b, in the range (-180, 180].
+*/
+func getAngularSeparation(a float64, b float64) float64 {
+	d := math.Mod(b-a, 360)
+
+	if d > 180 {
+		d -= 360
+	}
+
+	if d <= -180 {
+		d += 360
+	}
+
+	return d
+}
@@ -0,0 +1,156 @@
+package alpacago
+
+import (
+	"math"
+	"testing"
+)
+
+type fakeSlavingTelescope struct {
+	ra       float64
+	dec      float64
+	pier     PierPointingMode
+	lst      float64
+	latitude float64
+}
+
+func (t *fakeSlavingTelescope) GetRightAscension() (float64, error) { return t.ra, nil }
+
+func (t *fakeSlavingTelescope) GetDeclination() (float64, error) { return t.dec, nil }
+
+func (t *fakeSlavingTelescope) GetSideOfPier() (PierPointingMode, error) { return t.pier, nil }
+
+func (t *fakeSlavingTelescope) GetSiderealTime() (float64, error) { return t.lst, nil }
+
+func (t *fakeSlavingTelescope) GetSiteLatitude() (float64, error) { return t.latitude, nil }
+
+type fakeSlavingDome struct {
+	azimuth float64
+	slewing bool
+	slews   []float64
+}
+
+func (d *fakeSlavingDome) GetAzimuth() (float64, error) { return d.azimuth, nil }
+
+func (d *fakeSlavingDome) IsSlewing() (bool, error) { return d.slewing, nil }
+
+func (d *fakeSlavingDome) SlewToAzimuth(azimuth float64) error {
+	d.slews = append(d.slews, azimuth)
+	d.azimuth = azimuth
+	return nil
+}
+
+func TestDomeGeometryCentredMountMatchesTelescopeAzimuth(t *testing.T) {
+	geometry := DomeGeometry{DomeRadius: 2.5}
+
+	var H, δ, φ float64 = 2, 20, 51.5
+
+	got, _ := geometry.GetDomeAzimuth(H, δ, φ, PierUnknown)
+
+	h := H * 15 * math.Pi / 180
+	d := δ * math.Pi / 180
+	l := φ * math.Pi / 180
+
+	want := math.Mod(math.Atan2(-math.Cos(d)*math.Sin(h), math.Sin(d)*math.Cos(l)-math.Cos(d)*math.Cos(h)*math.Sin(l))*180/math.Pi+360, 360)
+
+	if math.Abs(got-want) > 0.00001 {
+		t.Errorf("got %f, wanted %f", got, want)
+	}
+}
+
+func TestDomeGeometryDecAxisOffsetAtZenith(t *testing.T) {
+	geometry := DomeGeometry{DomeRadius: 2.5, DecAxisOffset: 0.5}
+
+	// Pointing at the zenith on the meridian, with the optical tube on the east of the pier:
+	azimuth, altitude := geometry.GetDomeAzimuth(0, 51.5, 51.5, PierEast)
+
+	if math.Abs(azimuth-90) > 0.00001 {
+		t.Errorf("got %f, wanted %f", azimuth, 90.0)
+	}
+
+	var want float64 = math.Acos(0.5/2.5) * 180 / math.Pi
+
+	if math.Abs(altitude-want) > 0.00001 {
+		t.Errorf("got %f, wanted %f", altitude, want)
+	}
+
+	// After a meridian flip, the optical tube is on the west of the pier:
+	azimuth, _ = geometry.GetDomeAzimuth(0, 51.5, 51.5, PierWest)
+
+	if math.Abs(azimuth-270) > 0.00001 {
+		t.Errorf("got %f, wanted %f", azimuth, 270.0)
+	}
+}
+
+func TestDomeGeometryMountOffsetNorth(t *testing.T) {
+	geometry := DomeGeometry{DomeRadius: 2, MountOffsetNorth: 1}
+
+	// Pointing due east on the horizon, from a mount one meter north of centre:
+	azimuth, _ := geometry.GetDomeAzimuth(-6, 0, 0, PierWest)
+
+	var want float64 = 90 - math.Asin(1./2.)*180/math.Pi
+
+	if math.Abs(azimuth-want) > 0.00001 {
+		t.Errorf("got %f, wanted %f", azimuth, want)
+	}
+}
+
+func TestDomeSlavingStepSlewsOutsideHysteresis(t *testing.T) {
+	telescope := &fakeSlavingTelescope{ra: 10, dec: 20, lst: 12, latitude: 51.5, pier: PierEast}
+
+	dome := &fakeSlavingDome{azimuth: 0}
+
+	slaving := NewDomeSlaving(telescope, dome, DomeGeometry{DomeRadius: 2.5})
+
+	slewed, err := slaving.Step()
+
+	if err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	if !slewed || len(dome.slews) != 1 {
+		t.Fatalf("got %v, wanted a single slew", dome.slews)
+	}
+
+	if math.Abs(dome.slews[0]-slaving.Azimuth) > 0.00001 {
+		t.Errorf("got %f, wanted %f", dome.slews[0], slaving.Azimuth)
+	}
+
+	// The telescope tracks a little, but not beyond the hysteresis band:
+	telescope.lst += 0.01
+
+	slewed, _ = slaving.Step()
+
+	if slewed {
+		t.Errorf("got a slew within the hysteresis band, wanted none")
+	}
+}
+
+func TestDomeSlavingStepWaitsForSlewingDome(t *testing.T) {
+	telescope := &fakeSlavingTelescope{ra: 10, dec: 20, lst: 12, latitude: 51.5, pier: PierEast}
+
+	dome := &fakeSlavingDome{azimuth: 0, slewing: true}
+
+	slaving := NewDomeSlaving(telescope, dome, DomeGeometry{DomeRadius: 2.5})
+
+	slewed, _ := slaving.Step()
+
+	if slewed {
+		t.Errorf("got a slew whilst the dome was slewing, wanted none")
+	}
+}
+
+func TestGetAngularSeparation(t *testing.T) {
+	var got float64 = getAngularSeparation(350, 10)
+	var want float64 = 20
+
+	if math.Abs(got-want) > 0.00001 {
+		t.Errorf("got %f, wanted %f", got, want)
+	}
+
+	got = getAngularSeparation(10, 350)
+	want = -20
+
+	if math.Abs(got-want) > 0.00001 {
+		t.Errorf("got %f, wanted %f", got, want)
+	}
+}
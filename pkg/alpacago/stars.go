@@ -0,0 +1,308 @@
+package alpacago
+
+import (
+	"math"
+	"sort"
+)
+
+type Star struct {
+	// The flux-weighted centroid (pixels), where X indexes the first dimension of the image array
+	X float64
+	Y float64
+	// The peak pixel value above the background (ADU)
+	Peak float64
+	// The total flux above the background (ADU)
+	Flux float64
+	// The half-flux radius (pixels)
+	HFR float64
+	// The full width at half maximum (pixels), from the second moment of the flux
+	FWHM float64
+}
+
+type StarDetectionOptions struct {
+	// The detection threshold, in standard deviations of the background noise
+	Sigma float64
+	// The radius (pixels) of the window used to measure each star
+	Radius int
+	// Stars whose peak pixel reaches this value (ADU) are rejected as saturated (zero disables)
+	Saturation float64
+	// The maximum number of (brightest) stars returned (zero returns all)
+	MaxStars int
+}
+
+func NewStarDetectionOptions() StarDetectionOptions {
+	return StarDetectionOptions{
+		Sigma:  5,
+		Radius: 8,
+	}
+}
+
+/*
+GetImageBackground()
+
+@returns the median pixel value of the image, and a robust estimate of the background noise
+(the median absolute deviation, scaled to a standard deviation). Large images are sampled on
+a regular grid of roughly a quarter of a million pixels.
+*/
+func GetImageBackground(image [][]uint32) (float64, float64) {
+	pixels := []float64{}
+
+	if len(image) == 0 {
+		return 0, 0
+	}
+
+	stride := int(math.Sqrt(float64(len(image)*len(image[0])) / 250000))
+
+	if stride < 1 {
+		stride = 1
+	}
+
+	for x := 0; x < len(image); x += stride {
+		for y := 0; y < len(image[x]); y += stride {
+			pixels = append(pixels, float64(image[x][y]))
+		}
+	}
+
+	if len(pixels) == 0 {
+		return 0, 0
+	}
+
+	median := getMedian(pixels)
+
+	deviations := make([]float64, len(pixels))
+
+	for i, v := range pixels {
+		deviations[i] = math.Abs(v - median)
+	}
+
+	return median, 1.4826 * getMedian(deviations)
+}
+
+/*
+getMedian()
+
+@returns the median of the values (the values are sorted in place).
+*/
+func getMedian(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sort.Float64s(values)
+
+	n := len(values)
+
+	if n%2 == 1 {
+		return values[n/2]
+	}
+
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+/*
+DetectStars()
+
+Finds local maxima above the background threshold, and measures the centroid, flux,
+half-flux radius and FWHM of each within a circular window.
+
+@returns the detected stars, sorted from brightest to faintest.
+*/
+func DetectStars(image [][]uint32, options StarDetectionOptions) []Star {
+	stars := []Star{}
+
+	width := len(image)
+
+	if width == 0 {
+		return stars
+	}
+
+	height := len(image[0])
+
+	background, noise := GetImageBackground(image)
+
+	// Guard against perfectly flat (e.g., synthetic) backgrounds:
+	noise = math.Max(noise, 1)
+
+	threshold := background + options.Sigma*noise
+
+	radius := options.Radius
+
+	if radius < 1 {
+		radius = 1
+	}
+
+	for x := 1; x < width-1; x++ {
+		for y := 1; y < height-1; y++ {
+			v := float64(image[x][y])
+
+			if v <= threshold || !isLocalMaximum(image, x, y) {
+				continue
+			}
+
+			if options.Saturation > 0 && v >= options.Saturation {
+				continue
+			}
+
+			star, ok := measureStar(image, x, y, radius, background)
+
+			if !ok {
+				continue
+			}
+
+			// Reject a secondary maximum within the window of an existing star:
+			duplicate := false
+
+			for _, s := range stars {
+				if math.Hypot(s.X-star.X, s.Y-star.Y) < float64(radius) {
+					duplicate = true
+					break
+				}
+			}
+
+			if !duplicate {
+				stars = append(stars, star)
+			}
+		}
+	}
+
+	sort.Slice(stars, func(i, j int) bool {
+		return stars[i].Flux > stars[j].Flux
+	})
+
+	if options.MaxStars > 0 && len(stars) > options.MaxStars {
+		stars = stars[:options.MaxStars]
+	}
+
+	return stars
+}
+
+/*
+isLocalMaximum()
+
+@returns true if the pixel is greater than its eight neighbours (ties resolve to the first pixel scanned).
+*/
+func isLocalMaximum(image [][]uint32, x int, y int) bool {
+	v := image[x][y]
+
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			n := image[x+dx][y+dy]
+
+			if n > v || (n == v && (dx < 0 || (dx == 0 && dy < 0))) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+/*
+measureStar()
+
+@returns the star measured in a circular window of the given radius about the pixel (x, y).
+*/
+func measureStar(image [][]uint32, x int, y int, radius int, background float64) (Star, bool) {
+	width, height := len(image), len(image[0])
+
+	var flux, sx, sy, peak float64 = 0, 0, 0, 0
+
+	type sample struct {
+		x, y, v float64
+	}
+
+	samples := []sample{}
+
+	for i := x - radius; i <= x+radius; i++ {
+		for j := y - radius; j <= y+radius; j++ {
+			if i < 0 || j < 0 || i >= width || j >= height {
+				continue
+			}
+
+			if (i-x)*(i-x)+(j-y)*(j-y) > radius*radius {
+				continue
+			}
+
+			v := float64(image[i][j]) - background
+
+			if v <= 0 {
+				continue
+			}
+
+			samples = append(samples, sample{float64(i), float64(j), v})
+
+			flux += v
+			sx += v * float64(i)
+			sy += v * float64(j)
+			peak = math.Max(peak, v)
+		}
+	}
+
+	if flux <= 0 {
+		return Star{}, false
+	}
+
+	cx, cy := sx/flux, sy/flux
+
+	var sr, sr2 float64 = 0, 0
+
+	for _, s := range samples {
+		r := math.Hypot(s.x-cx, s.y-cy)
+		sr += s.v * r
+		sr2 += s.v * r * r
+	}
+
+	star := Star{
+		X:    cx,
+		Y:    cy,
+		Peak: peak,
+		Flux: flux,
+		HFR:  sr / flux,
+		// For a circular Gaussian, the mean squared radius is 2σ², and FWHM = 2√(2 ln 2)σ:
+		FWHM: 2 * math.Sqrt(2*math.Ln2) * math.Sqrt(sr2/(2*flux)),
+	}
+
+	return star, true
+}
+
+/*
+GetMedianHFR()
+
+@returns the median half-flux radius (pixels) of the stars, or NaN if there are none.
+*/
+func GetMedianHFR(stars []Star) float64 {
+	if len(stars) == 0 {
+		return math.NaN()
+	}
+
+	hfr := make([]float64, len(stars))
+
+	for i, s := range stars {
+		hfr[i] = s.HFR
+	}
+
+	return getMedian(hfr)
+}
+
+/*
+GetMedianFWHM()
+
+@returns the median full width at half maximum (pixels) of the stars, or NaN if there are none.
+*/
+func GetMedianFWHM(stars []Star) float64 {
+	if len(stars) == 0 {
+		return math.NaN()
+	}
+
+	fwhm := make([]float64, len(stars))
+
+	for i, s := range stars {
+		fwhm[i] = s.FWHM
+	}
+
+	return getMedian(fwhm)
+}
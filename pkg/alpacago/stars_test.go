@@ -0,0 +1,141 @@
+package alpacago
+
+import (
+	"math"
+	"testing"
+)
+
+type syntheticStar struct {
+	x, y, flux float64
+}
+
+/*
+renderStarField()
+
+@returns a synthetic image[x][y] of circular Gaussian stars with the given σ (pixels) on a flat background.
+*/
+func renderStarField(width int, height int, background float64, sigma float64, stars []syntheticStar) [][]uint32 {
+	image := make([][]uint32, width)
+
+	for x := range image {
+		image[x] = make([]uint32, height)
+
+		for y := range image[x] {
+			v := background
+
+			for _, s := range stars {
+				d2 := (float64(x)-s.x)*(float64(x)-s.x) + (float64(y)-s.y)*(float64(y)-s.y)
+
+				if d2 < 36*sigma*sigma {
+					v += s.flux / (2 * math.Pi * sigma * sigma) * math.Exp(-d2/(2*sigma*sigma))
+				}
+			}
+
+			image[x][y] = uint32(math.Round(v))
+		}
+	}
+
+	return image
+}
+
+func TestGetImageBackground(t *testing.T) {
+	image := [][]uint32{{100, 101, 99}, {100, 5000, 100}, {98, 102, 100}}
+
+	median, noise := GetImageBackground(image)
+
+	var want float64 = 100
+
+	if median != want {
+		t.Errorf("got %f, wanted %f", median, want)
+	}
+
+	if noise < 1 || noise > 2 {
+		t.Errorf("got %f, wanted a noise estimate between 1 and 2", noise)
+	}
+}
+
+func TestDetectStarsCentroids(t *testing.T) {
+	image := renderStarField(64, 48, 100, 1.5, []syntheticStar{{20.3, 15.6, 40000}, {45.0, 30.0, 20000}})
+
+	stars := DetectStars(image, NewStarDetectionOptions())
+
+	if len(stars) != 2 {
+		t.Fatalf("got %d stars, wanted 2", len(stars))
+	}
+
+	// Stars are sorted from brightest to faintest:
+	if math.Abs(stars[0].X-20.3) > 0.1 || math.Abs(stars[0].Y-15.6) > 0.1 {
+		t.Errorf("got (%f, %f), wanted (20.3, 15.6)", stars[0].X, stars[0].Y)
+	}
+
+	if math.Abs(stars[1].X-45.0) > 0.1 || math.Abs(stars[1].Y-30.0) > 0.1 {
+		t.Errorf("got (%f, %f), wanted (45.0, 30.0)", stars[1].X, stars[1].Y)
+	}
+}
+
+func TestDetectStarsFWHM(t *testing.T) {
+	var sigma float64 = 2
+
+	image := renderStarField(64, 64, 100, sigma, []syntheticStar{{32, 32, 100000}})
+
+	options := NewStarDetectionOptions()
+
+	options.Radius = 12
+
+	stars := DetectStars(image, options)
+
+	if len(stars) != 1 {
+		t.Fatalf("got %d stars, wanted 1", len(stars))
+	}
+
+	var want float64 = 2 * math.Sqrt(2*math.Ln2) * sigma
+
+	if math.Abs(stars[0].FWHM-want)/want > 0.05 {
+		t.Errorf("got %f, wanted %f", stars[0].FWHM, want)
+	}
+
+	// The mean radius of a circular Gaussian is σ√(π/2):
+	want = sigma * math.Sqrt(math.Pi/2)
+
+	if math.Abs(stars[0].HFR-want)/want > 0.05 {
+		t.Errorf("got %f, wanted %f", stars[0].HFR, want)
+	}
+}
+
+func TestDetectStarsRejectsSaturated(t *testing.T) {
+	image := renderStarField(32, 32, 100, 1, []syntheticStar{{16, 16, 100000}})
+
+	options := NewStarDetectionOptions()
+
+	options.Saturation = 5000
+
+	stars := DetectStars(image, options)
+
+	if len(stars) != 0 {
+		t.Errorf("got %d stars, wanted 0", len(stars))
+	}
+}
+
+func TestDetectStarsMaxStars(t *testing.T) {
+	image := renderStarField(64, 64, 100, 1.5, []syntheticStar{{10, 10, 10000}, {30, 30, 30000}, {50, 50, 20000}})
+
+	options := NewStarDetectionOptions()
+
+	options.MaxStars = 2
+
+	stars := DetectStars(image, options)
+
+	if len(stars) != 2 {
+		t.Fatalf("got %d stars, wanted 2", len(stars))
+	}
+
+	if math.Abs(stars[0].X-30) > 0.1 {
+		t.Errorf("got %f, wanted the brightest star at 30", stars[0].X)
+	}
+}
+
+func TestGetMedianHFRWithoutStars(t *testing.T) {
+	if !math.IsNaN(GetMedianHFR([]Star{})) {
+		t.Errorf("got a number, wanted NaN")
+	}
+}
@@ -0,0 +1,410 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type SafetySource interface {
+	IsSafe() (bool, error)
+}
+
+type WeatherSource interface {
+	GetCloudCover() (float64, error)
+	GetDewPoint() (float64, error)
+	GetHumidity() (float64, error)
+	GetPressure() (float64, error)
+	GetRainRate() (float64, error)
+	GetSkyBrightness() (float64, error)
+	GetSkyQuality() (float64, error)
+	GetSkyTemperature() (float64, error)
+	GetSeeingStarFWHM() (float64, error)
+	GetTemperature() (float64, error)
+	GetWindDirection() (float64, error)
+	GetWindGust() (float64, error)
+	GetWindSpeed() (float64, error)
+}
+
+type SupervisedCamera interface {
+	AbortExposure() error
+}
+
+type SupervisedTelescope interface {
+	SetAbortSlew() error
+	SetPark() error
+}
+
+type SupervisedDome interface {
+	AbortSlew() error
+	CloseShutter() error
+	OpenShutter() error
+}
+
+type SupervisedCover interface {
+	CloseCover() error
+	OpenCover() error
+}
+
+const (
+	SensorCloudCover     = "CloudCover"
+	SensorDewPoint       = "DewPoint"
+	SensorHumidity       = "Humidity"
+	SensorPressure       = "Pressure"
+	SensorRainRate       = "RainRate"
+	SensorSkyBrightness  = "SkyBrightness"
+	SensorSkyQuality     = "SkyQuality"
+	SensorSkyTemperature = "SkyTemperature"
+	SensorStarFWHM       = "StarFWHM"
+	SensorTemperature    = "Temperature"
+	SensorWindDirection  = "WindDirection"
+	SensorWindGust       = "WindGust"
+	SensorWindSpeed      = "WindSpeed"
+	// The sky temperature minus the ambient temperature (°C); clear skies are much colder than ambient
+	SensorSkyAmbientDelta = "SkyAmbientDelta"
+)
+
+/*
+GetWeatherReading()
+
+@param sensor string (the ASCOM sensor name e.g., "RainRate", or "SkyAmbientDelta")
+@returns the current reading for the named sensor from the weather source.
+*/
+func GetWeatherReading(source WeatherSource, sensor string) (float64, error) {
+	switch sensor {
+	case SensorCloudCover:
+		return source.GetCloudCover()
+	case SensorDewPoint:
+		return source.GetDewPoint()
+	case SensorHumidity:
+		return source.GetHumidity()
+	case SensorPressure:
+		return source.GetPressure()
+	case SensorRainRate:
+		return source.GetRainRate()
+	case SensorSkyBrightness:
+		return source.GetSkyBrightness()
+	case SensorSkyQuality:
+		return source.GetSkyQuality()
+	case SensorSkyTemperature:
+		return source.GetSkyTemperature()
+	case SensorStarFWHM:
+		return source.GetSeeingStarFWHM()
+	case SensorTemperature:
+		return source.GetTemperature()
+	case SensorWindDirection:
+		return source.GetWindDirection()
+	case SensorWindGust:
+		return source.GetWindGust()
+	case SensorWindSpeed:
+		return source.GetWindSpeed()
+	case SensorSkyAmbientDelta:
+		sky, err := source.GetSkyTemperature()
+
+		if err != nil {
+			return 0, err
+		}
+
+		ambient, err := source.GetTemperature()
+
+		if err != nil {
+			return 0, err
+		}
+
+		return sky - ambient, nil
+	default:
+		return 0, fmt.Errorf("unknown observing conditions sensor %q", sensor)
+	}
+}
+
+type SafetyThreshold struct {
+	// The sensor name e.g., "RainRate", "WindGust", "Humidity", "CloudCover" or "SkyAmbientDelta"
+	Sensor string
+	// Conditions are unsafe when the reading is strictly greater than this limit
+	Max float64
+}
+
+type SafetySupervisor struct {
+	Monitors   []SafetySource
+	Conditions WeatherSource
+	Thresholds []SafetyThreshold
+	// The devices secured on an unsafe transition (any may be left nil)
+	Cameras   []SupervisedCamera
+	Telescope SupervisedTelescope
+	Dome      SupervisedDome
+	Cover     SupervisedCover
+	// The interval at which the monitors and conditions are evaluated
+	Interval time.Duration
+	// The period conditions must remain continuously safe before they are reported safe again
+	HoldOff time.Duration
+	// Re-open the dome shutter and cover once the hold-off period has elapsed
+	ReopenWhenSafe bool
+	// Called after every attempt to secure the observatory, with the reasons it was deemed unsafe and any
+	// error securing it
+	OnUnsafe func(reasons []string, err error)
+	// Called once conditions have been safe for the hold-off period (after any re-opening)
+	OnSafe func(err error)
+
+	mu          sync.Mutex
+	safe        bool
+	initialised bool
+	// Whether the observatory has been secured since conditions were last safe
+	secured   bool
+	safeSince time.Time
+	now       func() time.Time
+}
+
+func NewSafetySupervisor(monitors ...SafetySource) *SafetySupervisor {
+	supervisor := SafetySupervisor{
+		Monitors:   monitors,
+		Thresholds: []SafetyThreshold{},
+		Cameras:    []SupervisedCamera{},
+		Interval:   10 * time.Second,
+		HoldOff:    30 * time.Minute,
+		now:        time.Now,
+	}
+
+	return &supervisor
+}
+
+/*
+getTime()
+
+@returns the current time, from time.Now unless the supervisor's clock has been replaced (e.g., in tests).
+*/
+func (s *SafetySupervisor) getTime() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+
+	return s.now()
+}
+
+/*
+IsSafe()
+
+@returns the supervised (hold-off filtered) safety state, so a supervisor can itself be used as a SafetySource.
+*/
+func (s *SafetySupervisor) IsSafe() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialised {
+		return false, errors.New("the safety supervisor has not yet evaluated conditions")
+	}
+
+	return s.safe, nil
+}
+
+/*
+Evaluate()
+
+Reads every safety monitor and threshold. A device that cannot be read is treated as unsafe.
+
+@returns true if conditions are currently safe, otherwise false with the reasons why not.
+*/
+func (s *SafetySupervisor) Evaluate() (bool, []string) {
+	reasons := []string{}
+
+	for i, monitor := range s.Monitors {
+		safe, err := monitor.IsSafe()
+
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("safety monitor %d could not be read: %s", i, err.Error()))
+			continue
+		}
+
+		if !safe {
+			reasons = append(reasons, fmt.Sprintf("safety monitor %d reports unsafe", i))
+		}
+	}
+
+	if s.Conditions != nil {
+		for _, threshold := range s.Thresholds {
+			reading, err := GetWeatherReading(s.Conditions, threshold.Sensor)
+
+			if err != nil {
+				reasons = append(reasons, fmt.Sprintf("%s could not be read: %s", threshold.Sensor, err.Error()))
+				continue
+			}
+
+			if reading > threshold.Max {
+				reasons = append(reasons, fmt.Sprintf("%s of %.2f exceeds %.2f", threshold.Sensor, reading, threshold.Max))
+			}
+		}
+	}
+
+	return len(reasons) == 0, reasons
+}
+
+/*
+Secure()
+
+Secures the observatory in a defined order: aborts exposures, stops slews, parks the telescope,
+closes the dome shutter and closes the cover. Every step is attempted even if an earlier one fails.
+
+@returns the combined errors of any steps that failed.
+*/
+func (s *SafetySupervisor) Secure() error {
+	errs := []error{}
+
+	attempt := func(step string, action func() error) {
+		if err := action(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", step, err))
+		}
+	}
+
+	for i, camera := range s.Cameras {
+		attempt(fmt.Sprintf("abort exposure on camera %d", i), camera.AbortExposure)
+	}
+
+	if s.Telescope != nil {
+		attempt("abort telescope slew", s.Telescope.SetAbortSlew)
+	}
+
+	if s.Dome != nil {
+		attempt("abort dome slew", s.Dome.AbortSlew)
+	}
+
+	if s.Telescope != nil {
+		attempt("park telescope", s.Telescope.SetPark)
+	}
+
+	if s.Dome != nil {
+		attempt("close dome shutter", s.Dome.CloseShutter)
+	}
+
+	if s.Cover != nil {
+		attempt("close cover", s.Cover.CloseCover)
+	}
+
+	return errors.Join(errs...)
+}
+
+/*
+Reopen()
+
+Re-opens the dome shutter and then the cover, once conditions have been safe for the hold-off period.
+
+@returns the combined errors of any steps that failed.
+*/
+func (s *SafetySupervisor) Reopen() error {
+	errs := []error{}
+
+	if s.Dome != nil {
+		if err := s.Dome.OpenShutter(); err != nil {
+			errs = append(errs, fmt.Errorf("open dome shutter: %w", err))
+		}
+	}
+
+	if s.Cover != nil {
+		if err := s.Cover.OpenCover(); err != nil {
+			errs = append(errs, fmt.Errorf("open cover: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+/*
+Step()
+
+Evaluates conditions once, securing the observatory on an unsafe transition (retried on every unsafe
+evaluation until it succeeds) and, after the hold-off period, reporting (and optionally re-opening on) a
+safe transition.
+
+@returns the supervised safety state after this evaluation.
+*/
+func (s *SafetySupervisor) Step() bool {
+	safe, reasons := s.Evaluate()
+
+	now := s.getTime()
+
+	s.mu.Lock()
+
+	wasSafe, initialised, secured := s.safe, s.initialised, s.secured
+
+	s.initialised = true
+
+	if !safe {
+		s.safe = false
+		s.safeSince = time.Time{}
+		s.mu.Unlock()
+
+		// Secure on every unsafe evaluation until it succeeds, then not again until conditions are safe:
+		if !secured {
+			err := s.Secure()
+
+			s.mu.Lock()
+			s.secured = err == nil
+			s.mu.Unlock()
+
+			if s.OnUnsafe != nil {
+				s.OnUnsafe(reasons, err)
+			}
+		}
+
+		return false
+	}
+
+	// On start-up, safe conditions are reported as-is without re-opening anything:
+	if !initialised {
+		s.safe = true
+		s.mu.Unlock()
+		return true
+	}
+
+	if wasSafe {
+		s.mu.Unlock()
+		return true
+	}
+
+	if s.safeSince.IsZero() {
+		s.safeSince = now
+	}
+
+	if now.Sub(s.safeSince) < s.HoldOff {
+		s.mu.Unlock()
+		return false
+	}
+
+	s.safe, s.secured = true, false
+	s.mu.Unlock()
+
+	var err error
+
+	if s.ReopenWhenSafe {
+		err = s.Reopen()
+	}
+
+	if s.OnSafe != nil {
+		s.OnSafe(err)
+	}
+
+	return true
+}
+
+/*
+Run()
+
+Supervises the observatory at the configured interval until the context is cancelled.
+
+@returns the context error once cancelled.
+*/
+func (s *SafetySupervisor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+
+	defer ticker.Stop()
+
+	for {
+		s.Step()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
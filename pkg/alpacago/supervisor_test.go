@@ -0,0 +1,325 @@
+package alpacago
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSafetySource struct {
+	safe bool
+	err  error
+}
+
+func (m *fakeSafetySource) IsSafe() (bool, error) { return m.safe, m.err }
+
+type fakeWeatherSource struct {
+	readings map[string]float64
+}
+
+func (w *fakeWeatherSource) get(sensor string) (float64, error) {
+	if v, ok := w.readings[sensor]; ok {
+		return v, nil
+	}
+
+	return 0, errors.New("not implemented")
+}
+
+func (w *fakeWeatherSource) GetCloudCover() (float64, error)     { return w.get(SensorCloudCover) }
+func (w *fakeWeatherSource) GetDewPoint() (float64, error)       { return w.get(SensorDewPoint) }
+func (w *fakeWeatherSource) GetHumidity() (float64, error)       { return w.get(SensorHumidity) }
+func (w *fakeWeatherSource) GetPressure() (float64, error)       { return w.get(SensorPressure) }
+func (w *fakeWeatherSource) GetRainRate() (float64, error)       { return w.get(SensorRainRate) }
+func (w *fakeWeatherSource) GetSkyBrightness() (float64, error)  { return w.get(SensorSkyBrightness) }
+func (w *fakeWeatherSource) GetSkyQuality() (float64, error)     { return w.get(SensorSkyQuality) }
+func (w *fakeWeatherSource) GetSkyTemperature() (float64, error) { return w.get(SensorSkyTemperature) }
+func (w *fakeWeatherSource) GetSeeingStarFWHM() (float64, error) { return w.get(SensorStarFWHM) }
+func (w *fakeWeatherSource) GetTemperature() (float64, error)    { return w.get(SensorTemperature) }
+func (w *fakeWeatherSource) GetWindDirection() (float64, error)  { return w.get(SensorWindDirection) }
+func (w *fakeWeatherSource) GetWindGust() (float64, error)       { return w.get(SensorWindGust) }
+func (w *fakeWeatherSource) GetWindSpeed() (float64, error)      { return w.get(SensorWindSpeed) }
+
+type observatoryLog struct {
+	actions []string
+}
+
+func (l *observatoryLog) record(action string) error {
+	l.actions = append(l.actions, action)
+	return nil
+}
+
+type fakeSupervisedCamera struct{ log *observatoryLog }
+
+func (c *fakeSupervisedCamera) AbortExposure() error { return c.log.record("camera.abortexposure") }
+
+type fakeSupervisedTelescope struct{ log *observatoryLog }
+
+func (t *fakeSupervisedTelescope) SetAbortSlew() error { return t.log.record("telescope.abortslew") }
+
+func (t *fakeSupervisedTelescope) SetPark() error { return t.log.record("telescope.park") }
+
+type fakeSupervisedDome struct{ log *observatoryLog }
+
+func (d *fakeSupervisedDome) AbortSlew() error { return d.log.record("dome.abortslew") }
+
+func (d *fakeSupervisedDome) CloseShutter() error { return d.log.record("dome.closeshutter") }
+
+func (d *fakeSupervisedDome) OpenShutter() error { return d.log.record("dome.openshutter") }
+
+type fakeSupervisedCover struct{ log *observatoryLog }
+
+func (c *fakeSupervisedCover) CloseCover() error { return c.log.record("cover.closecover") }
+
+func (c *fakeSupervisedCover) OpenCover() error { return c.log.record("cover.opencover") }
+
+func newTestSafetySupervisor(monitor *fakeSafetySource, weather *fakeWeatherSource, log *observatoryLog) *SafetySupervisor {
+	supervisor := NewSafetySupervisor(monitor)
+
+	supervisor.Conditions = weather
+	supervisor.Cameras = []SupervisedCamera{&fakeSupervisedCamera{log}}
+	supervisor.Telescope = &fakeSupervisedTelescope{log}
+	supervisor.Dome = &fakeSupervisedDome{log}
+	supervisor.Cover = &fakeSupervisedCover{log}
+
+	return supervisor
+}
+
+func TestGetWeatherReadingSkyAmbientDelta(t *testing.T) {
+	weather := &fakeWeatherSource{readings: map[string]float64{SensorSkyTemperature: -25, SensorTemperature: 5}}
+
+	got, err := GetWeatherReading(weather, SensorSkyAmbientDelta)
+
+	var want float64 = -30
+
+	if err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	if got != want {
+		t.Errorf("got %f, wanted %f", got, want)
+	}
+}
+
+func TestSafetySupervisorEvaluateThresholds(t *testing.T) {
+	weather := &fakeWeatherSource{readings: map[string]float64{SensorRainRate: 0.2, SensorWindGust: 5}}
+
+	supervisor := NewSafetySupervisor(&fakeSafetySource{safe: true})
+
+	supervisor.Conditions = weather
+
+	supervisor.Thresholds = []SafetyThreshold{{Sensor: SensorRainRate, Max: 0}, {Sensor: SensorWindGust, Max: 15}}
+
+	safe, reasons := supervisor.Evaluate()
+
+	if safe {
+		t.Errorf("got safe, wanted unsafe")
+	}
+
+	if len(reasons) != 1 || !strings.HasPrefix(reasons[0], SensorRainRate) {
+		t.Errorf("got %q, wanted a single RainRate reason", reasons)
+	}
+}
+
+func TestSafetySupervisorUnreadableMonitorIsUnsafe(t *testing.T) {
+	supervisor := NewSafetySupervisor(&fakeSafetySource{err: errors.New("timeout")})
+
+	safe, _ := supervisor.Evaluate()
+
+	if safe {
+		t.Errorf("got safe, wanted unsafe")
+	}
+}
+
+func TestSafetySupervisorSecuresInOrder(t *testing.T) {
+	log := &observatoryLog{}
+
+	monitor := &fakeSafetySource{safe: true}
+
+	supervisor := newTestSafetySupervisor(monitor, &fakeWeatherSource{}, log)
+
+	if !supervisor.Step() {
+		t.Errorf("got unsafe, wanted safe")
+	}
+
+	if len(log.actions) != 0 {
+		t.Errorf("got %q, wanted no actions on start-up", log.actions)
+	}
+
+	monitor.safe = false
+
+	supervisor.Step()
+
+	var want []string = []string{
+		"camera.abortexposure",
+		"telescope.abortslew",
+		"dome.abortslew",
+		"telescope.park",
+		"dome.closeshutter",
+		"cover.closecover",
+	}
+
+	if strings.Join(log.actions, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, wanted %q", log.actions, want)
+	}
+
+	// Remaining unsafe should not repeat the shutdown sequence:
+	supervisor.Step()
+
+	if len(log.actions) != len(want) {
+		t.Errorf("got %d actions, wanted %d", len(log.actions), len(want))
+	}
+}
+
+/*
+busyDome is a dome whose shutter cannot be closed until it is no longer busy.
+*/
+type busyDome struct {
+	fakeSupervisedDome
+	busy bool
+}
+
+func (d *busyDome) CloseShutter() error {
+	if d.busy {
+		return errors.New("the dome is busy")
+	}
+
+	return d.fakeSupervisedDome.CloseShutter()
+}
+
+func TestSafetySupervisorRetriesSecure(t *testing.T) {
+	log := &observatoryLog{}
+
+	monitor := &fakeSafetySource{safe: false}
+
+	supervisor := newTestSafetySupervisor(monitor, &fakeWeatherSource{}, log)
+
+	dome := &busyDome{fakeSupervisedDome{log}, true}
+
+	supervisor.Dome = dome
+
+	errs := []error{}
+
+	supervisor.OnUnsafe = func(reasons []string, err error) {
+		errs = append(errs, err)
+	}
+
+	supervisor.Step()
+
+	dome.busy = false
+
+	// Conditions remain unsafe, so the observatory is secured again until the shutter closes:
+	supervisor.Step()
+
+	supervisor.Step()
+
+	if len(errs) != 2 || errs[0] == nil || errs[1] != nil {
+		t.Fatalf("got %v, wanted a failed attempt to secure and then a successful one", errs)
+	}
+
+	var got int = strings.Count(strings.Join(log.actions, ","), "dome.closeshutter")
+
+	var want int = 1
+
+	if got != want {
+		t.Errorf("got %d closed shutters, wanted %d", got, want)
+	}
+}
+
+func TestSafetySupervisorHoldOffBeforeReopening(t *testing.T) {
+	log := &observatoryLog{}
+
+	monitor := &fakeSafetySource{safe: false}
+
+	supervisor := newTestSafetySupervisor(monitor, &fakeWeatherSource{}, log)
+
+	supervisor.HoldOff = 10 * time.Minute
+
+	supervisor.ReopenWhenSafe = true
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	supervisor.now = func() time.Time { return now }
+
+	supervisor.Step()
+
+	log.actions = []string{}
+
+	monitor.safe = true
+
+	if supervisor.Step() {
+		t.Errorf("got safe, wanted unsafe during the hold-off period")
+	}
+
+	now = now.Add(5 * time.Minute)
+
+	if supervisor.Step() {
+		t.Errorf("got safe, wanted unsafe during the hold-off period")
+	}
+
+	now = now.Add(5 * time.Minute)
+
+	if !supervisor.Step() {
+		t.Errorf("got unsafe, wanted safe after the hold-off period")
+	}
+
+	var want []string = []string{"dome.openshutter", "cover.opencover"}
+
+	if strings.Join(log.actions, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, wanted %q", log.actions, want)
+	}
+
+	safe, err := supervisor.IsSafe()
+
+	if err != nil || !safe {
+		t.Errorf("got %t (%v), wanted true", safe, err)
+	}
+}
+
+func TestSafetySupervisorHoldOffResetsOnUnsafe(t *testing.T) {
+	log := &observatoryLog{}
+
+	monitor := &fakeSafetySource{safe: false}
+
+	supervisor := newTestSafetySupervisor(monitor, &fakeWeatherSource{}, log)
+
+	supervisor.HoldOff = 10 * time.Minute
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	supervisor.now = func() time.Time { return now }
+
+	supervisor.Step()
+
+	monitor.safe = true
+
+	supervisor.Step()
+
+	now = now.Add(8 * time.Minute)
+
+	monitor.safe = false
+
+	supervisor.Step()
+
+	monitor.safe = true
+
+	supervisor.Step()
+
+	now = now.Add(8 * time.Minute)
+
+	if supervisor.Step() {
+		t.Errorf("got safe, wanted the hold-off period to restart after an unsafe reading")
+	}
+}
+
+func TestSafetySupervisorWithoutConstructor(t *testing.T) {
+	supervisor := &SafetySupervisor{Monitors: []SafetySource{&fakeSafetySource{safe: true}}}
+
+	var got bool = supervisor.Step()
+
+	var want bool = true
+
+	if got != want {
+		t.Errorf("got %t, wanted %t", got, want)
+	}
+}
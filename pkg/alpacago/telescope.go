@@ -1,6 +1,7 @@
 package alpacago
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -57,18 +58,28 @@ func (at AxisType) String() string {
 	}
 }
 
+// The values are those of ASCOM's EquatorialCoordinateType (equOther, equTopocentric, equJ2000, ...):
 const (
-	Topocentric EquatorialSystem = iota
+	EquatorialOther EquatorialSystem = iota
+	Topocentric
 	J2000
+	J2050
+	B1950
 )
 
 // String returns the string representation of the EquatorialSystem value.
 func (es EquatorialSystem) String() string {
 	switch es {
+	case EquatorialOther:
+		return "Other"
 	case Topocentric:
 		return "Topocentric"
 	case J2000:
 		return "J2000"
+	case J2050:
+		return "J2050"
+	case B1950:
+		return "B1950"
 	default:
 		return fmt.Sprintf("Unknown EquatorialSystem value: %d", es)
 	}
@@ -93,14 +104,6 @@ type Telescope struct {
 	Tracking     TrackingMode
 }
 
-type AxisRatesResponse struct {
-	Value               []map[string]float64 `json:"Value"`
-	ClientTransactionID int32                `json:"ClientTransactionID"`
-	ServerTransactionID int32                `json:"ServerTransactionID"`
-	ErrorNumber         int32                `json:"ErrorNumber"`
-	ErrorMessage        string               `json:"ErrorMessage"`
-}
-
 func NewTelescope(clientId uint32, secure bool, domain string, ip string, port int32, deviceNumber uint, tm TrackingMode) *Telescope {
 	alpaca := NewAlpacaAPI(clientId, secure, domain, ip, port)
 
@@ -216,26 +219,24 @@ func (t *Telescope) GetApertureDiameter() (float64, error) {
 /*
 GetAxisRates()
 
-@returns the rates at which the telescope may be moved about the specified axis by the MoveAxis(TelescopeAxes, Double) method.
+@returns the rates at which the telescope may be moved about the specified axis by the MoveAxis(TelescopeAxes, Double) method,
+or an empty map if the telescope cannot be moved about the axis.
 @see https://ascom-standards.org/api/#/Telescope%20Specific%20Methods/get_telescope__device_number__axisrates
 */
 func (t *Telescope) GetAxisRates(axis AxisType) (map[string]float64, error) {
-	url := t.Alpaca.getEndpoint("telescope", t.DeviceNumber, "axisrates")
-
 	querystring := fmt.Sprintf("axis=%d&%s", axis, t.Alpaca.getQueryString())
 
-	// Setup the resty client:
-	resp, err := t.Alpaca.Client.R().SetResult(&AxisRatesResponse{}).SetQueryString(querystring).SetHeader("Accept", "application/json").Get(url)
+	rates := []map[string]float64{}
 
-	// If the response object has a REST error:
-	if err != nil {
+	if _, err := t.Alpaca.get(context.Background(), "telescope", t.DeviceNumber, "axisrates", querystring, &rates); err != nil {
 		return map[string]float64{}, err
 	}
 
-	// Return the result:
-	result := (resp.Result().(*AxisRatesResponse))
+	if len(rates) == 0 {
+		return map[string]float64{}, nil
+	}
 
-	return result.Value[0], nil
+	return rates[0], nil
 }
 
 /*
@@ -302,27 +303,15 @@ CanMoveAxis()
 @see https://ascom-standards.org/api/#/Telescope%20Specific%20Methods/get_telescope__device_number__canmoveaxis
 */
 func (t *Telescope) CanMoveAxis(axis AxisType) (bool, error) {
-	url := t.Alpaca.getEndpoint("telescope", t.DeviceNumber, "canmoveaxis")
-
 	querystring := fmt.Sprintf("axis=%d&%s", axis, t.Alpaca.getQueryString())
 
-	// Setup the resty client:
-	resp, err := t.Alpaca.Client.R().SetResult(&booleanResponse{}).SetQueryString(querystring).SetHeader("Accept", "application/json").Get(url)
+	var value bool
 
-	if err != nil {
+	if _, err := t.Alpaca.get(context.Background(), "telescope", t.DeviceNumber, "canmoveaxis", querystring, &value); err != nil {
 		return false, err
 	}
 
-	// If the response object has a REST error:
-	if resp.IsError() {
-		t.Alpaca.ErrorNumber = resp.StatusCode()
-		t.Alpaca.ErrorMessage = resp.String()
-	}
-
-	// Return the result:
-	result := (resp.Result().(*booleanResponse))
-
-	return result.Value, nil
+	return value, nil
 }
 
 /*
@@ -550,6 +539,36 @@ func (t *Telescope) SetDeclinationRate(declinationRate float64) error {
 	return t.Alpaca.Put("telescope", t.DeviceNumber, "declinationrate", form)
 }
 
+/*
+GetDestinationSideOfPier()
+
+@param rightAscension float64 (degrees, as for SetSlewToCoordinatesAsync)
+@param declination float64 (degrees)
+@returns the side of the pier on which the telescope would be after a slew to the given equatorial
+coordinates, without actually slewing the telescope.
+@see https://ascom-standards.org/api/#/Telescope%20Specific%20Methods/get_telescope__device_number__destinationsideofpier
+*/
+func (t *Telescope) GetDestinationSideOfPier(rightAscension float64, declination float64) (PierPointingMode, error) {
+	rightAscension /= 15
+
+	querystring := fmt.Sprintf("RightAscension=%f&Declination=%f&%s", rightAscension, declination, t.Alpaca.getQueryString())
+
+	var value int32
+
+	envelope, err := t.Alpaca.get(context.Background(), "telescope", t.DeviceNumber, "destinationsideofpier", querystring, &value)
+
+	if err != nil {
+		return PierUnknown, err
+	}
+
+	// Drivers that cannot predict the pointing state respond with an ASCOM error e.g., not implemented:
+	if envelope.ErrorNumber != 0 {
+		return PierUnknown, &ASCOMError{Number: envelope.ErrorNumber, Message: envelope.ErrorMessage}
+	}
+
+	return PierPointingMode(value), nil
+}
+
 /*
 DoesRefraction()
 
@@ -611,6 +630,26 @@ func (t *Telescope) IsPulseGuiding() (bool, error) {
 	return t.Alpaca.GetBooleanResponse("telescope", t.DeviceNumber, "ispulseguiding")
 }
 
+/*
+SetPulseGuide()
+
+@returns an error or nil, if nil moves the scope in the given direction for the given interval or time (milliseconds)
+at the rate given by the corresponding guide rate property
+@see https://ascom-standards.org/api/#/Telescope%20Specific%20Methods/put_telescope__device_number__pulseguide
+*/
+func (t *Telescope) SetPulseGuide(direction Direction, duration int32) error {
+	t.Alpaca.TransactionId++
+
+	var form map[string]string = map[string]string{
+		"Direction":           fmt.Sprintf("%d", direction),
+		"Duration":            fmt.Sprintf("%d", duration),
+		"ClientID":            fmt.Sprintf("%d", t.Alpaca.ClientId),
+		"ClientTransactionID": fmt.Sprintf("%d", t.Alpaca.TransactionId),
+	}
+
+	return t.Alpaca.Put("telescope", t.DeviceNumber, "pulseguide", form)
+}
+
 /*
 GetRightAscension()
 
@@ -993,6 +1032,36 @@ func (t *Telescope) SetSlewToTargetAsync() error {
 	return t.Alpaca.Put("telescope", t.DeviceNumber, "slewtotargetasync", form)
 }
 
+/*
+SetSyncToCoordinates
+
+@returns an error or nil, if nil it matches the scope's equatorial coordinates to the given equatorial coordinates
+(the right ascension given in degrees, as for SetSlewToCoordinates).
+@see https://ascom-standards.org/api/#/Telescope%20Specific%20Methods/put_telescope__device_number__synctocoordinates
+*/
+func (t *Telescope) SetSyncToCoordinates(rightAscension float64, declination float64) error {
+	t.Alpaca.TransactionId++
+
+	if declination < -90 || declination > 90 {
+		return errors.New("please provide a valid declination between -90° and +90°")
+	}
+
+	if rightAscension < 0 || rightAscension > 360 {
+		return errors.New("please provide a valid right ascension between 0° and +360°")
+	}
+
+	rightAscension /= 15
+
+	var form map[string]string = map[string]string{
+		"RightAscension":      fmt.Sprintf("%f", rightAscension),
+		"Declination":         fmt.Sprintf("%f", declination),
+		"ClientID":            fmt.Sprintf("%d", t.Alpaca.ClientId),
+		"ClientTransactionID": fmt.Sprintf("%d", t.Alpaca.TransactionId),
+	}
+
+	return t.Alpaca.Put("telescope", t.DeviceNumber, "synctocoordinates", form)
+}
+
 /*
 GetTargetDeclination()
 
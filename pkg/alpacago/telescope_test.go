@@ -2,6 +2,10 @@ package alpacago
 
 import (
 	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -279,6 +283,33 @@ func TestNewTelescopeAxisRates(t *testing.T) {
 	}
 }
 
+func TestTelescopeAxisRatesEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		w.Write([]byte(`{"Value":[],"ClientTransactionID":0,"ServerTransactionID":1,"ErrorNumber":0,"ErrorMessage":""}`))
+	}))
+
+	defer server.Close()
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	p, _ := strconv.Atoi(port)
+
+	telescope := NewTelescope(65535, false, "", host, int32(p), 0, 1)
+
+	// A telescope which cannot be moved about the axis has no rates:
+	got, err := telescope.GetAxisRates(AxisTertiary)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("got %v, wanted no rates", got)
+	}
+}
+
 func TestNewTelescopeAzimuth(t *testing.T) {
 	var got, err = telescope.GetAzimuth()
 
@@ -653,6 +684,22 @@ func TestNewTelescopeDeclinationRatePut(t *testing.T) {
 	}
 }
 
+func TestNewTelescopeDestinationSideOfPier(t *testing.T) {
+	var got, err = telescope.GetDestinationSideOfPier(180, 45)
+
+	if err != nil {
+		t.Errorf("got %q", err)
+	}
+
+	if got != PierEast && got != PierWest {
+		t.Errorf("got %d, wanted %d or %d", got, PierEast, PierWest)
+	}
+
+	if telescope.Alpaca.ErrorNumber != 0 {
+		t.Errorf("got %q", telescope.Alpaca.ErrorMessage)
+	}
+}
+
 func TestNewTelescopeDoesRefraction(t *testing.T) {
 	var got, err = telescope.DoesRefraction()
 
@@ -686,7 +733,8 @@ func TestNewTelescopeSetDoesRefractionPut(t *testing.T) {
 func TestNewTelescopeEquatorialSystem(t *testing.T) {
 	var got, err = telescope.GetEquatorialSystem()
 
-	var want = "J2000"
+	// The simulator works in topocentric (JNow) coordinates, ASCOM's equTopocentric (1):
+	var want = "Topocentric"
 
 	if err != nil {
 		t.Errorf("got %q, wanted %q", err, want)
@@ -737,6 +785,14 @@ func TestNewTelescopeIsPulseGuiding(t *testing.T) {
 	}
 }
 
+func TestNewTelescopeSetPulseGuide(t *testing.T) {
+	var err = telescope.SetPulseGuide(North, 100)
+
+	if err != nil {
+		t.Errorf("got %q", err)
+	}
+}
+
 func TestNewTelescopeRightAscension(t *testing.T) {
 	var got, err = telescope.GetRightAscension()
 
@@ -1272,6 +1328,24 @@ func TestNewTelescopeSetSlewToTargetAsync(t *testing.T) {
 	}
 }
 
+// Sync To Equatorial Coordinates
+
+func TestNewTelescopeSetSyncToCoordinates(t *testing.T) {
+	var err = telescope.SetSyncToCoordinates(45.0, 45.0)
+
+	if err != nil {
+		t.Errorf("got %q", err)
+	}
+}
+
+func TestNewTelescopeSetSyncToCoordinatesInValidDeclination(t *testing.T) {
+	var err = telescope.SetSyncToCoordinates(45.0, -91)
+
+	if err == nil {
+		t.Errorf("got %q", err)
+	}
+}
+
 func TestNewTelescopeSetPark(t *testing.T) {
 	var err = telescope.SetPark()
 
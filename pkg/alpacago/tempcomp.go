@@ -0,0 +1,250 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+type TemperatureSource interface {
+	GetTemperature() (float64, error)
+}
+
+type TemperatureModel struct {
+	// The change in best focus position per degree (steps/°C)
+	StepsPerDegree float64
+	// The modelled best focus position at 0°C
+	Intercept float64
+	// The coefficient of determination of the fit
+	RSquared float64
+	// The number of autofocus runs contributing to the fit
+	Samples int
+}
+
+/*
+FitTemperatureModel()
+
+Fits the best focus position of historical autofocus runs against their temperature. Failed runs, runs
+without a temperature reading, and runs of a relative focuser (whose positions are relative to the start of
+each run) are ignored.
+
+@param reports []AutofocusReport (autofocus results through the same filter and optical train)
+@returns a linear model of best focus position against temperature.
+*/
+func FitTemperatureModel(reports []AutofocusReport) (TemperatureModel, error) {
+	model := TemperatureModel{}
+
+	xs, ys := []float64{}, []float64{}
+
+	for _, r := range reports {
+		// A failed run records its temperature, but has no best focus position to fit:
+		if r.Error != nil || !r.Absolute || math.IsNaN(r.Temperature) {
+			continue
+		}
+
+		xs = append(xs, r.Temperature)
+		ys = append(ys, float64(r.BestPosition))
+	}
+
+	model.Samples = len(xs)
+
+	if len(xs) < 2 {
+		return model, errors.New("at least two autofocus runs with a temperature are required")
+	}
+
+	var min, max float64 = xs[0], xs[0]
+
+	for _, x := range xs {
+		min, max = math.Min(min, x), math.Max(max, x)
+	}
+
+	if max-min < 0.5 {
+		return model, errors.New("the autofocus runs span less than 0.5°C, the temperature model is undetermined")
+	}
+
+	model.StepsPerDegree, model.Intercept = fitLine(xs, ys)
+
+	var mean, residual, total float64 = 0, 0, 0
+
+	for _, y := range ys {
+		mean += y / float64(len(ys))
+	}
+
+	for i, x := range xs {
+		r := ys[i] - (model.StepsPerDegree*x + model.Intercept)
+		residual += r * r
+		total += (ys[i] - mean) * (ys[i] - mean)
+	}
+
+	model.RSquared = 1
+
+	if total > 0 {
+		model.RSquared = 1 - residual/total
+	}
+
+	return model, nil
+}
+
+/*
+GetPosition()
+
+@returns the modelled best focus position at the given temperature (°C).
+*/
+func (m TemperatureModel) GetPosition(temperature float64) int32 {
+	return int32(math.Round(m.StepsPerDegree*temperature + m.Intercept))
+}
+
+type TemperatureCompensator struct {
+	Focuser AutofocusFocuser
+	// The temperature sensor e.g., the Focuser itself, or ObservingConditions
+	Temperature TemperatureSource
+	// The change in best focus position per degree (steps/°C)
+	StepsPerDegree float64
+	// Focus is only nudged once the temperature has drifted by at least this much (°C)
+	MinTemperatureChange float64
+	// Focus is only nudged once the required move is at least this many steps
+	MinSteps int32
+	// The largest single nudge (steps), or 0 for no limit
+	MaxSteps int32
+	// How often Run checks the temperature
+	Interval     time.Duration
+	PollInterval time.Duration
+
+	// The temperature at which focus was last known to be correct
+	reference   float64
+	initialised bool
+}
+
+func NewTemperatureCompensator(focuser AutofocusFocuser, source TemperatureSource, stepsPerDegree float64) *TemperatureCompensator {
+	compensator := TemperatureCompensator{
+		Focuser:              focuser,
+		Temperature:          source,
+		StepsPerDegree:       stepsPerDegree,
+		MinTemperatureChange: 0.5,
+		MinSteps:             5,
+		MaxSteps:             0,
+		Interval:             time.Minute,
+		PollInterval:         250 * time.Millisecond,
+	}
+
+	return &compensator
+}
+
+/*
+SetReference()
+
+Records the temperature at which the focuser is currently in focus e.g., immediately after an
+autofocus run, from which subsequent nudges are calculated.
+
+@param temperature float64 (°C)
+*/
+func (c *TemperatureCompensator) SetReference(temperature float64) {
+	c.reference = temperature
+	c.initialised = !math.IsNaN(temperature)
+}
+
+/*
+GetReference()
+
+@returns the temperature (°C) at which focus was last known to be correct, and whether it is set.
+*/
+func (c *TemperatureCompensator) GetReference() (float64, bool) {
+	return c.reference, c.initialised
+}
+
+/*
+Step()
+
+Reads the temperature and, if it has drifted sufficiently since the reference, nudges the focuser
+by the modelled amount and waits for the move to complete. Intended to be called between exposures.
+The first call, if no reference has been set, records the current temperature as the reference.
+
+@returns the number of steps the focuser was moved (0 if no nudge was required).
+*/
+func (c *TemperatureCompensator) Step(ctx context.Context) (int32, error) {
+	temperature, err := c.Temperature.GetTemperature()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !c.initialised {
+		c.SetReference(temperature)
+		return 0, nil
+	}
+
+	drift := temperature - c.reference
+
+	if math.Abs(drift) < c.MinTemperatureChange || c.StepsPerDegree == 0 {
+		return 0, nil
+	}
+
+	move := int32(math.Round(c.StepsPerDegree * drift))
+
+	if c.MaxSteps > 0 && move > c.MaxSteps {
+		move = c.MaxSteps
+	}
+
+	if c.MaxSteps > 0 && move < -c.MaxSteps {
+		move = -c.MaxSteps
+	}
+
+	if move == 0 || (move < c.MinSteps && move > -c.MinSteps) {
+		return 0, nil
+	}
+
+	absolute, err := c.Focuser.IsAbsolute()
+
+	if err != nil {
+		return 0, err
+	}
+
+	target := move
+
+	if absolute {
+		position, err := c.Focuser.GetPosition()
+
+		if err != nil {
+			return 0, err
+		}
+
+		target = position + move
+	}
+
+	if err := c.Focuser.SetMove(target); err != nil {
+		return 0, err
+	}
+
+	// Advance the reference by the drift actually compensated, so that rounding and any
+	// limited nudges carry over to the next step:
+	c.reference += float64(move) / c.StepsPerDegree
+
+	return move, WaitWhileMoving(ctx, c.PollInterval, c.Focuser.IsMoving)
+}
+
+/*
+Run()
+
+Calls Step at the compensator's interval until the context is done, for setups where focus may be nudged
+at any time. Where moves must wait for the end of an exposure, call Step between exposures instead.
+
+@returns the first error from Step, or the context's error once it is done.
+*/
+func (c *TemperatureCompensator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Step(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
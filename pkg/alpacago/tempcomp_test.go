@@ -0,0 +1,138 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestTemperatureCompensator(focuser *simulatedFocuser, stepsPerDegree float64) *TemperatureCompensator {
+	compensator := NewTemperatureCompensator(focuser, focuser, stepsPerDegree)
+
+	compensator.PollInterval = time.Millisecond
+
+	return compensator
+}
+
+func TestFitTemperatureModel(t *testing.T) {
+	reports := []AutofocusReport{
+		{Absolute: true, BestPosition: 5000, Temperature: 10},
+		{Absolute: true, BestPosition: 5120, Temperature: 6},
+		{Absolute: true, BestPosition: 5240, Temperature: 2},
+		{Absolute: true, BestPosition: 4800, Temperature: math.NaN()},
+		{Absolute: true, Temperature: 4, Error: errors.New("too few stars were detected")},
+		{BestPosition: 100, Temperature: 0},
+	}
+
+	model, err := FitTemperatureModel(reports)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if math.Abs(model.StepsPerDegree+30) > 1e-9 {
+		t.Errorf("got %f, wanted %f", model.StepsPerDegree, -30.0)
+	}
+
+	if model.Samples != 3 {
+		t.Errorf("got %d, wanted %d", model.Samples, 3)
+	}
+
+	if got := model.GetPosition(4); got != 5180 {
+		t.Errorf("got %d, wanted %d", got, 5180)
+	}
+}
+
+func TestFitTemperatureModelNarrowRange(t *testing.T) {
+	reports := []AutofocusReport{{Absolute: true, BestPosition: 5000, Temperature: 10}, {Absolute: true, BestPosition: 5010, Temperature: 10.1}}
+
+	if _, err := FitTemperatureModel(reports); err == nil {
+		t.Errorf("got nil, wanted an error for an undetermined model")
+	}
+}
+
+func TestTemperatureCompensatorStep(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: true, temperature: 10}
+
+	compensator := newTestTemperatureCompensator(focuser, -30)
+
+	// The first step records the reference temperature:
+	if move, err := compensator.Step(context.Background()); err != nil || move != 0 {
+		t.Fatalf("got %d, %v, wanted 0, nil", move, err)
+	}
+
+	// A drift below the threshold does not move the focuser:
+	focuser.temperature = 9.8
+
+	if move, _ := compensator.Step(context.Background()); move != 0 {
+		t.Errorf("got %d, wanted %d", move, 0)
+	}
+
+	focuser.temperature = 8
+
+	move, err := compensator.Step(context.Background())
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if move != 60 || focuser.position != 5060 {
+		t.Errorf("got a move of %d to %d, wanted %d to %d", move, focuser.position, 60, 5060)
+	}
+
+	if reference, _ := compensator.GetReference(); reference != 8 {
+		t.Errorf("got %f, wanted %f", reference, 8.0)
+	}
+}
+
+func TestTemperatureCompensatorMaxSteps(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: false, temperature: 5}
+
+	compensator := newTestTemperatureCompensator(focuser, -30)
+
+	compensator.MaxSteps = 50
+
+	compensator.SetReference(10)
+
+	if move, _ := compensator.Step(context.Background()); move != 50 {
+		t.Errorf("got %d, wanted %d", move, 50)
+	}
+
+	// The remaining drift is compensated on the next step:
+	if move, _ := compensator.Step(context.Background()); move != 50 {
+		t.Errorf("got %d, wanted %d", move, 50)
+	}
+
+	if focuser.position != 5100 {
+		t.Errorf("got %d, wanted %d", focuser.position, 5100)
+	}
+}
+
+func TestTemperatureCompensatorRun(t *testing.T) {
+	focuser := &simulatedFocuser{position: 5000, absolute: true, temperature: 8}
+
+	compensator := newTestTemperatureCompensator(focuser, -30)
+
+	compensator.Interval = time.Millisecond
+
+	compensator.SetReference(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+
+	defer cancel()
+
+	if err := compensator.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, wanted %v", err, context.DeadlineExceeded)
+	}
+
+	// A single nudge compensates the drift, after which the reference follows the temperature:
+	var got int32 = focuser.position
+
+	var want int32 = 5060
+
+	if got != want || len(focuser.moves) != 1 {
+		t.Errorf("got %d after %d moves, wanted %d after 1", got, len(focuser.moves), want)
+	}
+}
@@ -0,0 +1,470 @@
+package alpacago
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+/*
+TransportRequest is a single Alpaca request: a GET with its query string parameters, or a PUT with its form.
+*/
+type TransportRequest struct {
+	// The HTTP verb, http.MethodGet or http.MethodPut
+	Verb string
+	// The full endpoint URL e.g., http://localhost:11111/api/v1/telescope/0/altitude
+	URL          string
+	DeviceType   string
+	DeviceNumber uint
+	Method       string
+	// The query string of a GET request
+	Query string
+	// The form of a PUT request
+	Form map[string]string
+}
+
+/*
+Key()
+
+@returns the request as e.g., "GET telescope/0/axisrates?axis=0", with its parameters sorted and the client
+and transaction IDs (which change from run to run) removed.
+*/
+func (r TransportRequest) Key() string {
+	parameters := r.getParameters()
+
+	names := []string{}
+
+	for name := range parameters {
+		if strings.EqualFold(name, "ClientID") || strings.EqualFold(name, "ClientTransactionID") {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	key := fmt.Sprintf("%s %s/%d/%s", r.Verb, r.DeviceType, r.DeviceNumber, r.Method)
+
+	for i, name := range names {
+		separator := "&"
+
+		if i == 0 {
+			separator = "?"
+		}
+
+		key += separator + name + "=" + parameters.Get(name)
+	}
+
+	return key
+}
+
+/*
+getParameters()
+
+@returns the parameters of the request: its form if a PUT, otherwise its query string.
+*/
+func (r TransportRequest) getParameters() url.Values {
+	parameters := url.Values{}
+
+	if r.Verb == http.MethodPut {
+		for name, value := range r.Form {
+			parameters.Set(name, value)
+		}
+	} else if query, err := url.ParseQuery(r.Query); err == nil {
+		parameters = query
+	}
+
+	return parameters
+}
+
+/*
+getClientTransactionID()
+
+@returns the ClientTransactionID sent with the request (the name matched case-insensitively), or 0 if none was.
+*/
+func (r TransportRequest) getClientTransactionID() uint32 {
+	for name, values := range r.getParameters() {
+		if !strings.EqualFold(name, "ClientTransactionID") || len(values) == 0 {
+			continue
+		}
+
+		id, err := strconv.ParseUint(values[0], 10, 32)
+
+		if err == nil {
+			return uint32(id)
+		}
+	}
+
+	return 0
+}
+
+/*
+TransportEnvelope is the JSON envelope of an Alpaca response, its Value left encoded for the caller to decode
+into the type the method returns.
+*/
+type TransportEnvelope struct {
+	Value json.RawMessage `json:"Value,omitempty"`
+	// The rank of an image array e.g., 2 for a camera's imagearray
+	Rank                uint32 `json:"Rank,omitempty"`
+	ClientTransactionID uint32 `json:"ClientTransactionID"`
+	ServerTransactionID uint32 `json:"ServerTransactionID"`
+	ErrorNumber         int32  `json:"ErrorNumber"`
+	ErrorMessage        string `json:"ErrorMessage"`
+}
+
+/*
+decodeValue()
+
+Decodes the envelope's Value into the given pointer, leaving it untouched if the envelope has no Value.
+*/
+func (e TransportEnvelope) decodeValue(value interface{}) error {
+	if len(e.Value) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(e.Value, value)
+}
+
+/*
+TransportResponse is the response to a TransportRequest: the decoded envelope of a successful response, or
+the text of a REST error (HTTP 4xx or 5xx) e.g., "Invalid value".
+*/
+type TransportResponse struct {
+	StatusCode int
+	Envelope   TransportEnvelope
+	// The text of a REST error, or of a successful response which is not an Alpaca JSON envelope
+	Text string
+}
+
+/*
+ErrInvalidEnvelope is returned, wrapped, by a Transport when a successful response is not an Alpaca JSON
+envelope. The response still carries its status code and text.
+*/
+var ErrInvalidEnvelope = errors.New("the response is not an Alpaca JSON envelope")
+
+/*
+decodeTransportResponse()
+
+@returns the response with the given status code and body, its envelope decoded unless it is a REST error.
+*/
+func decodeTransportResponse(statusCode int, body []byte) (TransportResponse, error) {
+	response := TransportResponse{StatusCode: statusCode}
+
+	if statusCode >= http.StatusBadRequest {
+		response.Text = string(body)
+		return response, nil
+	}
+
+	if err := json.Unmarshal(body, &response.Envelope); err != nil {
+		response.Text = string(body)
+		return response, fmt.Errorf("%w: %q", ErrInvalidEnvelope, body)
+	}
+
+	return response, nil
+}
+
+/*
+Transport carries Alpaca requests to a device, and their decoded response envelopes back, decoupling the
+devices from HTTP (e.g., for mocks, recording proxies or non-HTTP bridges).
+*/
+type Transport interface {
+	Get(ctx context.Context, request TransportRequest) (TransportResponse, error)
+	Put(ctx context.Context, request TransportRequest) (TransportResponse, error)
+}
+
+/*
+RestyTransport is the default Transport, over HTTP with a resty client.
+*/
+type RestyTransport struct {
+	Client *resty.Client
+}
+
+func NewRestyTransport(client *resty.Client) *RestyTransport {
+	return &RestyTransport{Client: client}
+}
+
+func (t *RestyTransport) Get(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	resp, err := t.Client.R().SetContext(ctx).SetQueryString(request.Query).SetHeader("Accept", "application/json").Get(request.URL)
+
+	if err != nil {
+		return TransportResponse{}, err
+	}
+
+	return decodeTransportResponse(resp.StatusCode(), resp.Body())
+}
+
+func (t *RestyTransport) Put(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	resp, err := t.Client.R().SetContext(ctx).SetHeader("Content-Type", "application/x-www-form-urlencoded").SetHeader("Accept", "application/json").SetFormData(request.Form).Put(request.URL)
+
+	if err != nil {
+		return TransportResponse{}, err
+	}
+
+	return decodeTransportResponse(resp.StatusCode(), resp.Body())
+}
+
+/*
+FakeTransport is an in-memory Transport for unit tests, answering requests from the values and errors set on
+it, and recording every request it is sent. Requests without a value or error set answer with the ASCOM
+"not implemented" error.
+*/
+type FakeTransport struct {
+	mu       sync.Mutex
+	values   map[string]interface{}
+	errors   map[string]fakeTransportError
+	requests []TransportRequest
+}
+
+type fakeTransportError struct {
+	number  int32
+	message string
+}
+
+func NewFakeTransport() *FakeTransport {
+	return &FakeTransport{values: map[string]interface{}{}, errors: map[string]fakeTransportError{}}
+}
+
+/*
+getFakeKey()
+
+@returns the key a fake response is held under e.g., "GET telescope/0/altitude".
+*/
+func getFakeKey(verb string, deviceType string, deviceNumber uint, method string) string {
+	return fmt.Sprintf("%s %s/%d/%s", verb, strings.ToLower(deviceType), deviceNumber, strings.ToLower(method))
+}
+
+/*
+SetValue()
+
+Sets the value answered to GET requests for the device's method, whatever their parameters.
+*/
+func (t *FakeTransport) SetValue(deviceType string, deviceNumber uint, method string, value interface{}) {
+	t.mu.Lock()
+
+	defer t.mu.Unlock()
+
+	t.values[getFakeKey(http.MethodGet, deviceType, deviceNumber, method)] = value
+}
+
+/*
+SetError()
+
+Sets the ASCOM error answered to requests with the given verb (http.MethodGet or http.MethodPut) for the
+device's method.
+*/
+func (t *FakeTransport) SetError(verb string, deviceType string, deviceNumber uint, method string, errorNumber int32, errorMessage string) {
+	t.mu.Lock()
+
+	defer t.mu.Unlock()
+
+	t.errors[getFakeKey(verb, deviceType, deviceNumber, method)] = fakeTransportError{errorNumber, errorMessage}
+}
+
+/*
+GetRequests()
+
+@returns the requests the transport has been sent, in order.
+*/
+func (t *FakeTransport) GetRequests() []TransportRequest {
+	t.mu.Lock()
+
+	defer t.mu.Unlock()
+
+	return append([]TransportRequest{}, t.requests...)
+}
+
+func (t *FakeTransport) respond(request TransportRequest) (TransportResponse, error) {
+	t.mu.Lock()
+
+	defer t.mu.Unlock()
+
+	t.requests = append(t.requests, request)
+
+	key := getFakeKey(request.Verb, request.DeviceType, request.DeviceNumber, request.Method)
+
+	response := TransportResponse{StatusCode: http.StatusOK}
+
+	value, ok := t.values[key]
+
+	switch e, failed := t.errors[key]; {
+	case failed:
+		response.Envelope.ErrorNumber, response.Envelope.ErrorMessage = e.number, e.message
+	case request.Verb == http.MethodPut:
+	case ok:
+		encoded, err := json.Marshal(value)
+
+		if err != nil {
+			return TransportResponse{}, err
+		}
+
+		response.Envelope.Value = encoded
+	default:
+		response.Envelope.ErrorNumber, response.Envelope.ErrorMessage = ASCOMNotImplemented, fmt.Sprintf("%s is not implemented", key)
+	}
+
+	return response, nil
+}
+
+func (t *FakeTransport) Get(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	return t.respond(request)
+}
+
+func (t *FakeTransport) Put(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	return t.respond(request)
+}
+
+/*
+TransportInteraction is a request and its response, as recorded in a golden file.
+*/
+type TransportInteraction struct {
+	Request    string `json:"request"`
+	StatusCode int    `json:"status"`
+	// The JSON response envelope, or the text of a response which is not JSON
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+/*
+RecordingTransport passes requests through to another Transport (e.g., to a real device), recording every
+interaction so that the session can be saved to a golden file and replayed offline by a ReplayTransport.
+*/
+type RecordingTransport struct {
+	Transport    Transport
+	mu           sync.Mutex
+	interactions []TransportInteraction
+}
+
+func NewRecordingTransport(transport Transport) *RecordingTransport {
+	return &RecordingTransport{Transport: transport}
+}
+
+func (t *RecordingTransport) record(request TransportRequest, response TransportResponse, err error) (TransportResponse, error) {
+	if err != nil && !errors.Is(err, ErrInvalidEnvelope) {
+		return response, err
+	}
+
+	interaction := TransportInteraction{Request: request.Key(), StatusCode: response.StatusCode}
+
+	if err != nil || response.StatusCode >= http.StatusBadRequest {
+		interaction.Text = response.Text
+	} else if body, err := json.Marshal(response.Envelope); err == nil {
+		interaction.Body = body
+	}
+
+	t.mu.Lock()
+
+	t.interactions = append(t.interactions, interaction)
+
+	t.mu.Unlock()
+
+	return response, err
+}
+
+func (t *RecordingTransport) Get(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	response, err := t.Transport.Get(ctx, request)
+
+	return t.record(request, response, err)
+}
+
+func (t *RecordingTransport) Put(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	response, err := t.Transport.Put(ctx, request)
+
+	return t.record(request, response, err)
+}
+
+/*
+Save()
+
+Writes the interactions recorded so far to the golden file at the given path.
+*/
+func (t *RecordingTransport) Save(path string) error {
+	t.mu.Lock()
+
+	data, err := json.MarshalIndent(t.interactions, "", "  ")
+
+	t.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+/*
+ReplayTransport answers requests from a golden file saved by a RecordingTransport. Repeated requests are
+answered with their recorded responses in order, the last being repeated once they run out.
+*/
+type ReplayTransport struct {
+	mu           sync.Mutex
+	interactions map[string][]TransportInteraction
+}
+
+func LoadReplayTransport(path string) (*ReplayTransport, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := []TransportInteraction{}
+
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("invalid golden file %s: %w", path, err)
+	}
+
+	transport := ReplayTransport{interactions: map[string][]TransportInteraction{}}
+
+	for _, interaction := range recorded {
+		transport.interactions[interaction.Request] = append(transport.interactions[interaction.Request], interaction)
+	}
+
+	return &transport, nil
+}
+
+func (t *ReplayTransport) replay(request TransportRequest) (TransportResponse, error) {
+	t.mu.Lock()
+
+	defer t.mu.Unlock()
+
+	key := request.Key()
+
+	queue := t.interactions[key]
+
+	if len(queue) == 0 {
+		return TransportResponse{}, fmt.Errorf("no response was recorded for %s", key)
+	}
+
+	interaction := queue[0]
+
+	if len(queue) > 1 {
+		t.interactions[key] = queue[1:]
+	}
+
+	body := []byte(interaction.Body)
+
+	if len(body) == 0 {
+		body = []byte(interaction.Text)
+	}
+
+	return decodeTransportResponse(interaction.StatusCode, body)
+}
+
+func (t *ReplayTransport) Get(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	return t.replay(request)
+}
+
+func (t *ReplayTransport) Put(ctx context.Context, request TransportRequest) (TransportResponse, error) {
+	return t.replay(request)
+}
@@ -0,0 +1,230 @@
+package alpacago
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestTransportRequestKey(t *testing.T) {
+	request := TransportRequest{
+		Verb:         http.MethodGet,
+		DeviceType:   "telescope",
+		DeviceNumber: 0,
+		Method:       "destinationsideofpier",
+		Query:        "RightAscension=3.790000&Declination=24.100000&ClientID=65535&ClientTransactionID=12",
+	}
+
+	var got string = request.Key()
+
+	var want string = "GET telescope/0/destinationsideofpier?Declination=24.100000&RightAscension=3.790000"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	request = TransportRequest{
+		Verb:         http.MethodPut,
+		DeviceType:   "telescope",
+		DeviceNumber: 0,
+		Method:       "tracking",
+		Form:         map[string]string{"Tracking": "true", "ClientID": "65535", "ClientTransactionID": "13"},
+	}
+
+	got = request.Key()
+
+	want = "PUT telescope/0/tracking?Tracking=true"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestFakeTransportTelescope(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetValue("telescope", 0, "altitude", 45.5)
+
+	transport.SetValue("telescope", 0, "sideofpier", int32(PierWest))
+
+	telescope := NewTelescope(65535, false, "", "0.0.0.0", 8000, 0, 1)
+
+	telescope.Alpaca.Transport = transport
+
+	altitude, err := telescope.GetAltitude()
+
+	if err != nil || altitude != 45.5 {
+		t.Errorf("got %f (%v), wanted 45.5", altitude, err)
+	}
+
+	side, err := telescope.GetSideOfPier()
+
+	if err != nil || side != PierWest {
+		t.Errorf("got %v (%v), wanted %v", side, err, PierWest)
+	}
+
+	if err := telescope.SetTracking(true); err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	requests := transport.GetRequests()
+
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, wanted 3", len(requests))
+	}
+
+	var got string = requests[2].Key()
+
+	var want string = "PUT telescope/0/tracking?Tracking=true"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestFakeTransportError(t *testing.T) {
+	transport := NewFakeTransport()
+
+	transport.SetError(http.MethodPut, "telescope", 0, "park", 0x40B, "the telescope cannot be parked")
+
+	telescope := NewTelescope(65535, false, "", "0.0.0.0", 8000, 0, 1)
+
+	telescope.Alpaca.Transport = transport
+
+	err := telescope.SetPark()
+
+	if err == nil {
+		t.Errorf("got nil, wanted an error")
+	}
+
+	// A property without a value set is not implemented:
+	_, err = telescope.GetDestinationSideOfPier(56.85, 24.1)
+
+	if err == nil {
+		t.Errorf("got nil, wanted an error")
+	}
+}
+
+func TestRecordReplayTransport(t *testing.T) {
+	source := &fakeSafetySource{safe: true}
+
+	server := httptest.NewServer(NewSafetyMonitorServer(source, 0, "Synthetic Safety Monitor"))
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	recorder := NewRecordingTransport(NewRestyTransport(monitor.Alpaca.Client))
+
+	monitor.Alpaca.Transport = recorder
+
+	if err := monitor.SetConnected(true); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	safe, err := monitor.IsSafe()
+
+	if err != nil || !safe {
+		t.Fatalf("got %t (%v), wanted true", safe, err)
+	}
+
+	source.safe = false
+
+	safe, err = monitor.IsSafe()
+
+	if err != nil || safe {
+		t.Fatalf("got %t (%v), wanted false", safe, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "safetymonitor.json")
+
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	// Replay the session offline:
+	server.Close()
+
+	replay, err := LoadReplayTransport(path)
+
+	if err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	monitor.Alpaca.Transport = replay
+
+	// The transaction IDs differ from the recording, but are not matched:
+	monitor.Alpaca.TransactionId += 100
+
+	if err := monitor.SetConnected(true); err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	for i, want := range []bool{true, false, false} {
+		got, err := monitor.IsSafe()
+
+		if err != nil || got != want {
+			t.Errorf("replay %d: got %t (%v), wanted %t", i, got, err, want)
+		}
+	}
+
+	_, err = monitor.GetDescription()
+
+	if err == nil {
+		t.Errorf("got nil, wanted an error for an unrecorded request")
+	}
+}
+
+func TestAlpacaClientReplaced(t *testing.T) {
+	agent := ""
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.Header.Get("User-Agent")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		w.Write([]byte(`{"Value":true,"ClientTransactionID":0,"ServerTransactionID":1,"ErrorNumber":0,"ErrorMessage":""}`))
+	}))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	// The transport is derived from the client, so replacing the client after construction takes effect:
+	monitor.Alpaca.Client = resty.New().SetHeader("User-Agent", "observatory/1.0")
+
+	if _, err := monitor.IsSafe(); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	var got string = agent
+
+	var want string = "observatory/1.0"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestAlpacaPutInvalidEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	defer server.Close()
+
+	monitor := newTestSafetyMonitorClient(t, server)
+
+	// A PUT answered without an envelope is not an error, as before transports:
+	if err := monitor.SetConnected(true); err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	_, err := monitor.IsSafe()
+
+	if !errors.Is(err, ErrInvalidEnvelope) {
+		t.Errorf("got %v, wanted %q", err, ErrInvalidEnvelope)
+	}
+}
@@ -0,0 +1,579 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type WatchEventType int32
+
+const (
+	// The value of a watched property has changed
+	PropertyChanged WatchEventType = iota
+	// A watched property could not be read
+	PropertyError
+	// The telescope or dome has started slewing
+	SlewStarted
+	// The telescope or dome has finished slewing
+	SlewFinished
+	// The dome shutter (or roll-off roof) has opened
+	ShutterOpened
+	// The dome shutter (or roll-off roof) has closed
+	ShutterClosed
+	// The safety monitor has transitioned to a safe state
+	BecameSafe
+	// The safety monitor has transitioned to an unsafe state
+	BecameUnsafe
+	// The camera has started an exposure
+	ExposureStarted
+	// The camera has completed an exposure, and the image is ready
+	ExposureComplete
+)
+
+func (e WatchEventType) String() string {
+	name := []string{
+		"property_changed",
+		"property_error",
+		"slew_started",
+		"slew_finished",
+		"shutter_opened",
+		"shutter_closed",
+		"became_safe",
+		"became_unsafe",
+		"exposure_started",
+		"exposure_complete",
+	}
+
+	switch {
+	case e >= PropertyChanged && e <= ExposureComplete:
+		return name[e]
+	default:
+		return strconv.Itoa(int(e))
+	}
+}
+
+type WatchEvent struct {
+	Type     WatchEventType
+	Property string
+	Previous interface{}
+	Value    interface{}
+	Time     time.Time
+	Error    error
+}
+
+type WatchProperty struct {
+	// The unique name of the watched property, e.g., "telescope/0/slewing"
+	Name string
+	// The interval at which the property is polled
+	Interval time.Duration
+	// Read returns the current value of the property, usually from one of the device getters
+	Read func() (interface{}, error)
+	// Equal reports whether two values are considered unchanged (defaults to ==)
+	Equal func(previous interface{}, current interface{}) bool
+	// Transitions returns the typed events raised by a change of value (optional)
+	Transitions func(previous interface{}, current interface{}) []WatchEventType
+}
+
+type Watcher struct {
+	// The window within which successive PropertyChanged events for the same property are merged
+	Coalesce time.Duration
+	// Emit a PropertyChanged event for the first value read of each property
+	EmitInitial bool
+
+	properties []WatchProperty
+	events     chan WatchEvent
+	running    bool
+	mu         sync.Mutex
+}
+
+func NewWatcher(buffer int) *Watcher {
+	watcher := Watcher{
+		properties: []WatchProperty{},
+		events:     make(chan WatchEvent, buffer),
+	}
+
+	return &watcher
+}
+
+/*
+Watch()
+
+@param property WatchProperty (the property to poll, at its own interval)
+@returns an error if the property is invalid, or if the watcher is already running
+*/
+func (w *Watcher) Watch(property WatchProperty) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return errors.New("unable to watch a new property whilst the watcher is running")
+	}
+
+	if property.Read == nil {
+		return fmt.Errorf("the watched property %q has no reader", property.Name)
+	}
+
+	if property.Interval <= 0 {
+		return fmt.Errorf("the watched property %q must have a positive polling interval", property.Name)
+	}
+
+	for _, p := range w.properties {
+		if p.Name == property.Name {
+			return fmt.Errorf("the property %q is already being watched", property.Name)
+		}
+	}
+
+	w.properties = append(w.properties, property)
+
+	return nil
+}
+
+/*
+Events()
+
+@returns the channel on which change events are delivered; it is closed when Run() returns.
+*/
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+/*
+Run()
+
+Polls every watched property at its own interval until the context is cancelled,
+deduplicating unchanged values and coalescing bursts of changes to the same property.
+
+@returns the context error once the watcher has stopped.
+*/
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+
+	if w.running {
+		w.mu.Unlock()
+		return errors.New("the watcher is already running")
+	}
+
+	w.running = true
+
+	properties := make([]WatchProperty, len(w.properties))
+
+	copy(properties, w.properties)
+
+	w.mu.Unlock()
+
+	defer close(w.events)
+
+	incoming := make(chan WatchEvent)
+
+	var wg sync.WaitGroup
+
+	pollCtx, cancel := context.WithCancel(ctx)
+
+	for _, property := range properties {
+		wg.Add(1)
+
+		go func(property WatchProperty) {
+			defer wg.Done()
+			w.poll(pollCtx, property, incoming)
+		}(property)
+	}
+
+	err := w.dispatch(ctx, incoming)
+
+	cancel()
+
+	wg.Wait()
+
+	return err
+}
+
+/*
+poll()
+
+Reads a single property at its interval, and forwards any change (or new error) to the dispatcher.
+*/
+func (w *Watcher) poll(ctx context.Context, property WatchProperty, incoming chan<- WatchEvent) {
+	var previous interface{}
+
+	var hasPrevious bool = false
+
+	var lastError string = ""
+
+	ticker := time.NewTicker(property.Interval)
+
+	defer ticker.Stop()
+
+	send := func(event WatchEvent) bool {
+		select {
+		case incoming <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		value, err := property.Read()
+
+		now := time.Now()
+
+		if err != nil {
+			// Only report an error once, until it changes or the property recovers:
+			if err.Error() != lastError {
+				lastError = err.Error()
+
+				if !send(WatchEvent{Type: PropertyError, Property: property.Name, Previous: previous, Time: now, Error: err}) {
+					return
+				}
+			}
+		} else {
+			lastError = ""
+
+			switch {
+			case !hasPrevious:
+				if w.EmitInitial && !send(WatchEvent{Type: PropertyChanged, Property: property.Name, Value: value, Time: now}) {
+					return
+				}
+
+				previous = value
+
+				hasPrevious = true
+			case !isEqualWatchValue(property, previous, value):
+				if property.Transitions != nil {
+					for _, t := range property.Transitions(previous, value) {
+						if !send(WatchEvent{Type: t, Property: property.Name, Previous: previous, Value: value, Time: now}) {
+							return
+						}
+					}
+				}
+
+				if !send(WatchEvent{Type: PropertyChanged, Property: property.Name, Previous: previous, Value: value, Time: now}) {
+					return
+				}
+
+				// Only move the reference value when a change is reported, so that small steps within the
+				// tolerance still accumulate into a change:
+				previous = value
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+/*
+dispatch()
+
+Delivers events to the consumer, merging PropertyChanged events for the same property
+that arrive within the coalescing window. Transition events are never dropped.
+*/
+func (w *Watcher) dispatch(ctx context.Context, incoming <-chan WatchEvent) error {
+	pending := map[string]WatchEvent{}
+
+	deadlines := map[string]time.Time{}
+
+	// The order in which pending properties were first queued, so flushes are stable:
+	order := []string{}
+
+	timer := time.NewTimer(time.Hour)
+
+	timer.Stop()
+
+	deliver := func(event WatchEvent) bool {
+		select {
+		case w.events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	flush := func(name string) bool {
+		event, ok := pending[name]
+
+		if !ok {
+			return true
+		}
+
+		delete(pending, name)
+
+		delete(deadlines, name)
+
+		for i, n := range order {
+			if n == name {
+				order = append(order[:i], order[i+1:]...)
+				break
+			}
+		}
+
+		return deliver(event)
+	}
+
+	rearm := func() {
+		timer.Stop()
+
+		if len(order) == 0 {
+			return
+		}
+
+		next := deadlines[order[0]]
+
+		for _, name := range order[1:] {
+			if deadlines[name].Before(next) {
+				next = deadlines[name]
+			}
+		}
+
+		timer.Reset(time.Until(next))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event := <-incoming:
+			if event.Type != PropertyChanged || w.Coalesce <= 0 {
+				// Keep ordering per property: anything pending is older than this event:
+				if !flush(event.Property) || !deliver(event) {
+					return ctx.Err()
+				}
+
+				rearm()
+				continue
+			}
+
+			if queued, ok := pending[event.Property]; ok {
+				// Merge, keeping the value from before the burst of changes:
+				event.Previous = queued.Previous
+			} else {
+				deadlines[event.Property] = time.Now().Add(w.Coalesce)
+				order = append(order, event.Property)
+			}
+
+			pending[event.Property] = event
+
+			rearm()
+
+		case <-timer.C:
+			now := time.Now()
+
+			due := []string{}
+
+			for _, name := range order {
+				if !deadlines[name].After(now) {
+					due = append(due, name)
+				}
+			}
+
+			for _, name := range due {
+				if !flush(name) {
+					return ctx.Err()
+				}
+			}
+
+			rearm()
+		}
+	}
+}
+
+func isEqualWatchValue(property WatchProperty, previous interface{}, current interface{}) bool {
+	if property.Equal != nil {
+		return property.Equal(previous, current)
+	}
+
+	return previous == current
+}
+
+/*
+NewBooleanWatchProperty()
+
+@returns a WatchProperty over a boolean getter, raising onTrue when the value becomes
+true and onFalse when the value becomes false.
+*/
+func NewBooleanWatchProperty(name string, interval time.Duration, read func() (bool, error), onTrue WatchEventType, onFalse WatchEventType) WatchProperty {
+	return WatchProperty{
+		Name:     name,
+		Interval: interval,
+		Read: func() (interface{}, error) {
+			return read()
+		},
+		Transitions: func(previous interface{}, current interface{}) []WatchEventType {
+			if current.(bool) {
+				return []WatchEventType{onTrue}
+			}
+
+			return []WatchEventType{onFalse}
+		},
+	}
+}
+
+/*
+NewFloat64WatchProperty()
+
+@returns a WatchProperty over a float64 getter, treating changes no greater than tolerance as unchanged.
+*/
+func NewFloat64WatchProperty(name string, interval time.Duration, read func() (float64, error), tolerance float64) WatchProperty {
+	return WatchProperty{
+		Name:     name,
+		Interval: interval,
+		Read: func() (interface{}, error) {
+			return read()
+		},
+		Equal: func(previous interface{}, current interface{}) bool {
+			return math.Abs(previous.(float64)-current.(float64)) <= tolerance
+		},
+	}
+}
+
+/*
+NewStringWatchProperty()
+
+@returns a WatchProperty over a string getter, raising the event mapped to the new value (if any).
+*/
+func NewStringWatchProperty(name string, interval time.Duration, read func() (string, error), transitions map[string]WatchEventType) WatchProperty {
+	return WatchProperty{
+		Name:     name,
+		Interval: interval,
+		Read: func() (interface{}, error) {
+			return read()
+		},
+		Transitions: func(previous interface{}, current interface{}) []WatchEventType {
+			if t, ok := transitions[current.(string)]; ok {
+				return []WatchEventType{t}
+			}
+
+			return nil
+		},
+	}
+}
+
+/*
+WatchTelescopeSlewing()
+
+@returns a WatchProperty raising SlewStarted and SlewFinished from Telescope.IsSlewing()
+*/
+func WatchTelescopeSlewing(t *Telescope, interval time.Duration) WatchProperty {
+	name := fmt.Sprintf("telescope/%d/slewing", t.DeviceNumber)
+	return NewBooleanWatchProperty(name, interval, t.IsSlewing, SlewStarted, SlewFinished)
+}
+
+/*
+WatchTelescopeRightAscension()
+
+@returns a WatchProperty over Telescope.GetRightAscension(), ignoring changes below tolerance (hours)
+*/
+func WatchTelescopeRightAscension(t *Telescope, interval time.Duration, tolerance float64) WatchProperty {
+	name := fmt.Sprintf("telescope/%d/rightascension", t.DeviceNumber)
+	return NewFloat64WatchProperty(name, interval, t.GetRightAscension, tolerance)
+}
+
+/*
+WatchTelescopeDeclination()
+
+@returns a WatchProperty over Telescope.GetDeclination(), ignoring changes below tolerance (degrees)
+*/
+func WatchTelescopeDeclination(t *Telescope, interval time.Duration, tolerance float64) WatchProperty {
+	name := fmt.Sprintf("telescope/%d/declination", t.DeviceNumber)
+	return NewFloat64WatchProperty(name, interval, t.GetDeclination, tolerance)
+}
+
+/*
+WatchDomeSlewing()
+
+@returns a WatchProperty raising SlewStarted and SlewFinished from Dome.IsSlewing()
+*/
+func WatchDomeSlewing(d *Dome, interval time.Duration) WatchProperty {
+	name := fmt.Sprintf("dome/%d/slewing", d.DeviceNumber)
+	return NewBooleanWatchProperty(name, interval, d.IsSlewing, SlewStarted, SlewFinished)
+}
+
+/*
+WatchDomeAzimuth()
+
+@returns a WatchProperty over Dome.GetAzimuth(), ignoring changes below tolerance (degrees)
+*/
+func WatchDomeAzimuth(d *Dome, interval time.Duration, tolerance float64) WatchProperty {
+	name := fmt.Sprintf("dome/%d/azimuth", d.DeviceNumber)
+	return NewFloat64WatchProperty(name, interval, d.GetAzimuth, tolerance)
+}
+
+/*
+WatchDomeShutter()
+
+@returns a WatchProperty raising ShutterOpened and ShutterClosed from Dome.GetShutterStatus()
+*/
+func WatchDomeShutter(d *Dome, interval time.Duration) WatchProperty {
+	name := fmt.Sprintf("dome/%d/shutterstatus", d.DeviceNumber)
+
+	return NewStringWatchProperty(name, interval, d.GetShutterStatus, map[string]WatchEventType{
+		Open.String():   ShutterOpened,
+		Closed.String(): ShutterClosed,
+	})
+}
+
+/*
+WatchSafetyMonitor()
+
+@returns a WatchProperty raising BecameSafe and BecameUnsafe from SafetyMonitor.IsSafe()
+*/
+func WatchSafetyMonitor(m *SafetyMonitor, interval time.Duration) WatchProperty {
+	name := fmt.Sprintf("safetymonitor/%d/issafe", m.DeviceNumber)
+	return NewBooleanWatchProperty(name, interval, m.IsSafe, BecameSafe, BecameUnsafe)
+}
+
+/*
+WatchCameraState()
+
+@returns a WatchProperty raising ExposureStarted from Camera.GetOperationalState()
+*/
+func WatchCameraState(c *Camera, interval time.Duration) WatchProperty {
+	name := fmt.Sprintf("camera/%d/camerastate", c.DeviceNumber)
+
+	return NewStringWatchProperty(name, interval, c.GetOperationalState, map[string]WatchEventType{
+		CameraExposing.String(): ExposureStarted,
+	})
+}
+
+/*
+WatchCameraImageReady()
+
+@returns a WatchProperty raising ExposureComplete when Camera.IsImageReady() becomes true
+*/
+func WatchCameraImageReady(c *Camera, interval time.Duration) WatchProperty {
+	name := fmt.Sprintf("camera/%d/imageready", c.DeviceNumber)
+
+	return WatchProperty{
+		Name:     name,
+		Interval: interval,
+		Read: func() (interface{}, error) {
+			return c.IsImageReady()
+		},
+		Transitions: func(previous interface{}, current interface{}) []WatchEventType {
+			if current.(bool) {
+				return []WatchEventType{ExposureComplete}
+			}
+
+			return nil
+		},
+	}
+}
+
+/*
+WatchCameraTemperature()
+
+@returns a WatchProperty over Camera.GetCCDTemperature(), ignoring changes below tolerance (°C)
+*/
+func WatchCameraTemperature(c *Camera, interval time.Duration, tolerance float64) WatchProperty {
+	name := fmt.Sprintf("camera/%d/ccdtemperature", c.DeviceNumber)
+	return NewFloat64WatchProperty(name, interval, c.GetCCDTemperature, tolerance)
+}
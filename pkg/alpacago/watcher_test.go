@@ -0,0 +1,258 @@
+package alpacago
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type readSequence struct {
+	mu     sync.Mutex
+	values []interface{}
+	index  int
+}
+
+func (s *readSequence) read() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value := s.values[s.index]
+
+	if s.index < len(s.values)-1 {
+		s.index++
+	}
+
+	if err, ok := value.(error); ok {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func collectWatchEvents(t *testing.T, watcher *Watcher, timeout time.Duration) []WatchEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer cancel()
+
+	events := []WatchEvent{}
+
+	done := make(chan error)
+
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	for event := range watcher.Events() {
+		events = append(events, event)
+	}
+
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %q, wanted %q", err, context.DeadlineExceeded)
+	}
+
+	return events
+}
+
+func TestWatchEventTypeString(t *testing.T) {
+	var got string = BecameUnsafe.String()
+	var want string = "became_unsafe"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	// Values beyond the last event type must not wrap around onto a valid name:
+	got = WatchEventType(256 + int32(BecameUnsafe)).String()
+
+	want = "263"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestWatcherWatchRejectsDuplicateProperty(t *testing.T) {
+	watcher := NewWatcher(8)
+
+	s := &readSequence{values: []interface{}{true}}
+
+	if err := watcher.Watch(WatchProperty{Name: "telescope/0/slewing", Interval: time.Millisecond, Read: s.read}); err != nil {
+		t.Errorf("got %q, wanted nil", err)
+	}
+
+	if err := watcher.Watch(WatchProperty{Name: "telescope/0/slewing", Interval: time.Millisecond, Read: s.read}); err == nil {
+		t.Errorf("got nil, wanted an error for a duplicate property")
+	}
+}
+
+func TestWatcherWatchRejectsInvalidInterval(t *testing.T) {
+	watcher := NewWatcher(8)
+
+	s := &readSequence{values: []interface{}{true}}
+
+	if err := watcher.Watch(WatchProperty{Name: "telescope/0/slewing", Read: s.read}); err == nil {
+		t.Errorf("got nil, wanted an error for a zero polling interval")
+	}
+}
+
+func TestWatcherSlewTransitions(t *testing.T) {
+	watcher := NewWatcher(16)
+
+	s := &readSequence{values: []interface{}{false, false, true, true, false}}
+
+	watcher.Watch(NewBooleanWatchProperty("telescope/0/slewing", time.Millisecond, func() (bool, error) {
+		v, err := s.read()
+		return v.(bool), err
+	}, SlewStarted, SlewFinished))
+
+	events := collectWatchEvents(t, watcher, 50*time.Millisecond)
+
+	var got []WatchEventType
+
+	for _, e := range events {
+		got = append(got, e.Type)
+	}
+
+	var want []WatchEventType = []WatchEventType{SlewStarted, PropertyChanged, SlewFinished, PropertyChanged}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+	}
+}
+
+func TestWatcherEmitInitial(t *testing.T) {
+	watcher := NewWatcher(16)
+
+	watcher.EmitInitial = true
+
+	s := &readSequence{values: []interface{}{"closed"}}
+
+	watcher.Watch(WatchProperty{Name: "dome/0/shutterstatus", Interval: time.Millisecond, Read: s.read})
+
+	events := collectWatchEvents(t, watcher, 20*time.Millisecond)
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, wanted 1", len(events))
+	}
+
+	if events[0].Value != "closed" {
+		t.Errorf("got %q, wanted %q", events[0].Value, "closed")
+	}
+}
+
+func TestWatcherFloat64Tolerance(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []interface{}
+		previous float64
+		value    float64
+	}{
+		{"jump", []interface{}{-10.0, -10.05, -10.08, -11.0}, -10.0, -11.0},
+		// Each step is within the tolerance, but the cumulative drift from the last reported value is not:
+		{"drift", []interface{}{-10.0, -10.06, -10.12, -10.18}, -10.0, -10.12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := NewWatcher(16)
+
+			s := &readSequence{values: tt.values}
+
+			watcher.Watch(NewFloat64WatchProperty("camera/0/ccdtemperature", time.Millisecond, func() (float64, error) {
+				v, err := s.read()
+				return v.(float64), err
+			}, 0.1))
+
+			events := collectWatchEvents(t, watcher, 30*time.Millisecond)
+
+			if len(events) != 1 {
+				t.Fatalf("got %d events, wanted 1", len(events))
+			}
+
+			if events[0].Previous != tt.previous {
+				t.Errorf("got %v, wanted %v", events[0].Previous, tt.previous)
+			}
+
+			if events[0].Value != tt.value {
+				t.Errorf("got %v, wanted %v", events[0].Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestWatcherErrorsAreDeduplicated(t *testing.T) {
+	watcher := NewWatcher(16)
+
+	failure := errors.New("connection refused")
+
+	s := &readSequence{values: []interface{}{true, failure, failure, failure, true}}
+
+	watcher.Watch(WatchProperty{Name: "safetymonitor/0/issafe", Interval: time.Millisecond, Read: s.read})
+
+	events := collectWatchEvents(t, watcher, 30*time.Millisecond)
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, wanted 1", len(events))
+	}
+
+	if events[0].Type != PropertyError {
+		t.Errorf("got %v, wanted %v", events[0].Type, PropertyError)
+	}
+}
+
+func TestWatcherCoalescesChanges(t *testing.T) {
+	watcher := NewWatcher(16)
+
+	watcher.Coalesce = 200 * time.Millisecond
+
+	s := &readSequence{values: []interface{}{1.0, 2.0, 3.0, 4.0}}
+
+	watcher.Watch(WatchProperty{Name: "dome/0/azimuth", Interval: time.Millisecond, Read: s.read})
+
+	events := collectWatchEvents(t, watcher, 300*time.Millisecond)
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, wanted 1", len(events))
+	}
+
+	if events[0].Previous != 1.0 {
+		t.Errorf("got %v, wanted %v", events[0].Previous, 1.0)
+	}
+
+	if events[0].Value != 4.0 {
+		t.Errorf("got %v, wanted %v", events[0].Value, 4.0)
+	}
+}
+
+func TestWatcherShutterTransitions(t *testing.T) {
+	watcher := NewWatcher(16)
+
+	s := &readSequence{values: []interface{}{"closed", "opening", "open"}}
+
+	watcher.Watch(NewStringWatchProperty("dome/0/shutterstatus", time.Millisecond, func() (string, error) {
+		v, err := s.read()
+		return v.(string), err
+	}, map[string]WatchEventType{"open": ShutterOpened, "closed": ShutterClosed}))
+
+	events := collectWatchEvents(t, watcher, 30*time.Millisecond)
+
+	var opened int = 0
+
+	for _, e := range events {
+		if e.Type == ShutterOpened {
+			opened++
+		}
+	}
+
+	if opened != 1 {
+		t.Errorf("got %d, wanted %d", opened, 1)
+	}
+}
@@ -0,0 +1,618 @@
+// Package conformance checks that an Alpaca server implements a device interface per the ASCOM
+// specification, in the spirit of ASCOM ConformU.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+const (
+	// ASCOM error numbers
+	notImplemented  int32 = 0x400
+	invalidValue    int32 = 0x401
+	notConnected    int32 = 0x407
+	minErrorNumber  int32 = 0x400
+	maxErrorNumber  int32 = 0xFFF
+	transactionBase       = 4000
+)
+
+type Status uint8
+
+const (
+	Passed Status = iota
+	Failed
+	Skipped
+)
+
+func (s Status) String() string {
+	name := []string{"passed", "failed", "skipped"}
+
+	i := uint8(s)
+
+	switch {
+	case i <= uint8(Skipped):
+		return name[i]
+	default:
+		return fmt.Sprintf("Status(%d)", i)
+	}
+}
+
+type Category uint8
+
+const (
+	// The member must be implemented
+	Required Category = iota
+	// The member must work, or fail with an ASCOM error (0x400 if not implemented) rather than an HTTP error
+	Optional
+	// An invalid value must be rejected with an HTTP 400 (unparseable) or ASCOM 0x401 (out of range) error
+	InvalidValue
+	// The client transaction ID must be echoed, with a server transaction ID
+	TransactionID
+	// The GET parameter names must be case-insensitive
+	CaseInsensitive
+	// An asynchronous operation must run through its states to completion
+	Async
+	// The device members must fail with ASCOM error 0x407 while the device is disconnected
+	NotConnected
+)
+
+func (c Category) String() string {
+	name := []string{"required", "optional", "invalid value", "transaction id", "case insensitive", "async", "not connected"}
+
+	i := uint8(c)
+
+	switch {
+	case i <= uint8(NotConnected):
+		return name[i]
+	default:
+		return fmt.Sprintf("Category(%d)", i)
+	}
+}
+
+/*
+Result is the outcome of a single check of a device member.
+*/
+type Result struct {
+	Category Category
+	Member   string
+	Status   Status
+	Message  string
+}
+
+/*
+Report is the outcome of every check of a device.
+*/
+type Report struct {
+	URL          string
+	DeviceType   string
+	DeviceNumber uint
+	Results      []Result
+}
+
+/*
+GetSummary()
+
+@returns the number of checks which passed, failed and were skipped.
+*/
+func (r *Report) GetSummary() (int, int, int) {
+	count := [3]int{}
+
+	for _, result := range r.Results {
+		if result.Status <= Skipped {
+			count[result.Status]++
+		}
+	}
+
+	return count[Passed], count[Failed], count[Skipped]
+}
+
+/*
+IsConformant()
+
+@returns true if no check failed.
+*/
+func (r *Report) IsConformant() bool {
+	_, failed, _ := r.GetSummary()
+
+	return failed == 0
+}
+
+/*
+WriteText()
+
+Writes the report as a table of checks, with a summary.
+*/
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%s %d conformance at %s\n\n", r.DeviceType, r.DeviceNumber, r.URL)
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(table, "CHECK\tMEMBER\tSTATUS\tMESSAGE")
+
+	for _, result := range r.Results {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", result.Category, result.Member, result.Status, strings.ReplaceAll(result.Message, "\n", " "))
+	}
+
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	passed, failed, skipped := r.GetSummary()
+
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+
+	return err
+}
+
+/*
+Checker runs the conformance checks of a device interface against an Alpaca server.
+*/
+type Checker struct {
+	Transport alpacago.Transport
+	// The server URL e.g., http://localhost:11111
+	URL          string
+	DeviceType   string
+	DeviceNumber uint
+	ClientID     uint32
+	// How long an asynchronous operation may take to complete
+	Timeout      time.Duration
+	PollInterval time.Duration
+
+	transactionID uint32
+}
+
+func NewChecker(url string, deviceType string, deviceNumber uint) *Checker {
+	client := resty.New()
+
+	client.SetTimeout(60 * time.Second)
+
+	checker := Checker{
+		Transport:     alpacago.NewRestyTransport(client),
+		URL:           strings.TrimRight(url, "/"),
+		DeviceType:    strings.ToLower(deviceType),
+		DeviceNumber:  deviceNumber,
+		ClientID:      65535,
+		Timeout:       60 * time.Second,
+		PollInterval:  250 * time.Millisecond,
+		transactionID: transactionBase,
+	}
+
+	return &checker
+}
+
+type reply struct {
+	alpacago.TransportEnvelope
+	StatusCode int
+	// The text of an HTTP error, or of a response which is not an envelope
+	Body string
+	// Whether the body was a valid response envelope
+	Decoded bool
+}
+
+/*
+getError()
+
+@returns an error describing an HTTP or ASCOM error in the reply, or nil.
+*/
+func (r reply) getError() error {
+	switch {
+	case r.StatusCode != http.StatusOK:
+		return fmt.Errorf("HTTP %d: %s", r.StatusCode, strings.TrimSpace(r.Body))
+	case !r.Decoded:
+		return fmt.Errorf("the response is not an Alpaca JSON envelope: %q", r.Body)
+	case r.ErrorNumber != 0:
+		return fmt.Errorf("ASCOM error 0x%X: %s", r.ErrorNumber, r.ErrorMessage)
+	default:
+		return nil
+	}
+}
+
+func (c *Checker) nextTransactionID() uint32 {
+	return atomic.AddUint32(&c.transactionID, 1)
+}
+
+/*
+send()
+
+Sends the request with the given parameters (in order, with the names as given), adding the ClientID and
+ClientTransactionID unless already present.
+
+@returns the reply, and the client transaction ID sent.
+*/
+func (c *Checker) send(ctx context.Context, verb string, method string, parameters [][2]string) (reply, uint32, error) {
+	id := c.nextTransactionID()
+
+	names := map[string]bool{}
+
+	for _, p := range parameters {
+		names[strings.ToLower(p[0])] = true
+	}
+
+	if !names["clientid"] {
+		parameters = append(parameters, [2]string{"ClientID", fmt.Sprintf("%d", c.ClientID)})
+	}
+
+	if !names["clienttransactionid"] {
+		parameters = append(parameters, [2]string{"ClientTransactionID", fmt.Sprintf("%d", id)})
+	}
+
+	request := alpacago.TransportRequest{
+		Verb:         verb,
+		URL:          fmt.Sprintf("%s/api/v1/%s/%d/%s", c.URL, c.DeviceType, c.DeviceNumber, method),
+		DeviceType:   c.DeviceType,
+		DeviceNumber: c.DeviceNumber,
+		Method:       method,
+	}
+
+	var resp alpacago.TransportResponse
+
+	var err error
+
+	if verb == http.MethodPut {
+		request.Form = map[string]string{}
+
+		for _, p := range parameters {
+			request.Form[p[0]] = p[1]
+		}
+
+		resp, err = c.Transport.Put(ctx, request)
+	} else {
+		query := []string{}
+
+		for _, p := range parameters {
+			query = append(query, url.QueryEscape(p[0])+"="+url.QueryEscape(p[1]))
+		}
+
+		request.Query = strings.Join(query, "&")
+
+		resp, err = c.Transport.Get(ctx, request)
+	}
+
+	if err != nil && !errors.Is(err, alpacago.ErrInvalidEnvelope) {
+		return reply{}, id, err
+	}
+
+	r := reply{TransportEnvelope: resp.Envelope, StatusCode: resp.StatusCode, Body: resp.Text, Decoded: err == nil && resp.StatusCode == http.StatusOK}
+
+	return r, id, nil
+}
+
+func (c *Checker) get(ctx context.Context, method string, parameters ...[2]string) (reply, error) {
+	r, _, err := c.send(ctx, http.MethodGet, method, parameters)
+
+	return r, err
+}
+
+func (c *Checker) put(ctx context.Context, method string, parameters ...[2]string) (reply, error) {
+	r, _, err := c.send(ctx, http.MethodPut, method, parameters)
+
+	return r, err
+}
+
+/*
+getValue()
+
+@returns the value of the GET member decoded as the given type, or an error if the request failed.
+*/
+func getValue[T any](ctx context.Context, c *Checker, method string, parameters ...[2]string) (T, error) {
+	var value T
+
+	r, err := c.get(ctx, method, parameters...)
+
+	if err != nil {
+		return value, err
+	}
+
+	if err := r.getError(); err != nil {
+		return value, err
+	}
+
+	err = json.Unmarshal(r.Value, &value)
+
+	return value, err
+}
+
+/*
+command()
+
+Sends the PUT command, returning an error if the request failed.
+*/
+func (c *Checker) command(ctx context.Context, method string, parameters ...[2]string) error {
+	r, err := c.put(ctx, method, parameters...)
+
+	if err != nil {
+		return err
+	}
+
+	return r.getError()
+}
+
+/*
+waitUntil()
+
+Waits for the condition, for at most the Checker's Timeout.
+*/
+func (c *Checker) waitUntil(ctx context.Context, condition func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+
+	defer cancel()
+
+	err := alpacago.WaitUntil(ctx, c.PollInterval, condition)
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("the operation did not complete within %s", c.Timeout)
+	}
+
+	return err
+}
+
+/*
+Run()
+
+Connects to the device, then runs every conformance check of its interface in turn: required and optional
+members, invalid values, transaction IDs, case-insensitive parameters, asynchronous operations and members
+read while disconnected. The asynchronous checks may briefly move the device e.g., a focuser by one step,
+returning it afterwards, and a device found disconnected is disconnected again.
+*/
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{URL: c.URL, DeviceType: c.DeviceType, DeviceNumber: c.DeviceNumber}
+
+	spec, ok := specifications[c.DeviceType]
+
+	if !ok {
+		report.Results = append(report.Results, Result{Required, c.DeviceType, Failed, "unknown ASCOM device type"})
+		return report
+	}
+
+	connected, err := getValue[bool](ctx, c, "connected")
+
+	if err != nil {
+		report.Results = append(report.Results, Result{Required, "connected", Failed, err.Error()})
+		return report
+	}
+
+	if err := c.command(ctx, "connected", [2]string{"Connected", "true"}); err != nil {
+		report.Results = append(report.Results, Result{Required, "connected", Failed, fmt.Sprintf("connecting: %v", err)})
+		return report
+	}
+
+	for _, check := range []func(context.Context, specification) []Result{
+		c.checkRequired,
+		c.checkOptional,
+		c.checkInvalidValues,
+		c.checkTransactionIDs,
+		c.checkCaseInsensitive,
+		c.checkAsync,
+		c.checkNotConnected,
+	} {
+		if ctx.Err() != nil {
+			break
+		}
+
+		report.Results = append(report.Results, check(ctx, spec)...)
+	}
+
+	// Leave the device as it was found, even if the checks were cancelled:
+	if !connected {
+		if err := c.command(context.WithoutCancel(ctx), "connected", [2]string{"Connected", "false"}); err != nil {
+			report.Results = append(report.Results, Result{Required, "connected", Failed, fmt.Sprintf("disconnecting: %v", err)})
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) checkRequired(ctx context.Context, spec specification) []Result {
+	results := []Result{}
+
+	for _, member := range append(append([]string{}, commonMembers...), spec.required...) {
+		result := Result{Category: Required, Member: member, Status: Passed}
+
+		r, err := c.get(ctx, member)
+
+		switch {
+		case err != nil:
+			result.Status, result.Message = Failed, err.Error()
+		case r.getError() != nil:
+			result.Status, result.Message = Failed, r.getError().Error()
+		case len(r.Value) == 0 || string(r.Value) == "null":
+			result.Status, result.Message = Failed, "the response has no Value"
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (c *Checker) checkOptional(ctx context.Context, spec specification) []Result {
+	results := []Result{}
+
+	for _, member := range spec.optional {
+		result := Result{Category: Optional, Member: member, Status: Passed}
+
+		r, err := c.get(ctx, member)
+
+		switch {
+		case err != nil:
+			result.Status, result.Message = Failed, err.Error()
+		case r.StatusCode != http.StatusOK || !r.Decoded:
+			result.Status, result.Message = Failed, fmt.Sprintf("%v, wanted an ASCOM error in the envelope", r.getError())
+		case r.ErrorNumber == notImplemented:
+			result.Message = "not implemented"
+		case r.ErrorNumber != 0 && (r.ErrorNumber < minErrorNumber || r.ErrorNumber > maxErrorNumber):
+			result.Status, result.Message = Failed, fmt.Sprintf("error number 0x%X is outside the ASCOM range 0x400 to 0xFFF", r.ErrorNumber)
+		case r.ErrorNumber != 0 && r.ErrorMessage == "":
+			result.Status, result.Message = Failed, fmt.Sprintf("error number 0x%X has no error message", r.ErrorNumber)
+		case r.ErrorNumber != 0:
+			result.Message = fmt.Sprintf("ASCOM error 0x%X: %s", r.ErrorNumber, r.ErrorMessage)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (c *Checker) checkInvalidValues(ctx context.Context, spec specification) []Result {
+	// A value which cannot be parsed is an HTTP 400 Bad Request, rather than an ASCOM error:
+	results := []Result{}
+
+	r, err := c.put(ctx, "connected", [2]string{"Connected", "conformance"})
+
+	result := Result{Category: InvalidValue, Member: "connected", Status: Passed}
+
+	switch {
+	case err != nil:
+		result.Status, result.Message = Failed, err.Error()
+	case r.StatusCode != http.StatusBadRequest:
+		result.Status, result.Message = Failed, fmt.Sprintf("got HTTP %d for Connected=conformance, wanted HTTP 400", r.StatusCode)
+	}
+
+	results = append(results, result)
+
+	for _, invalid := range spec.invalid {
+		result := Result{Category: InvalidValue, Member: invalid.method, Status: Passed}
+
+		if invalid.requires != "" {
+			required, err := getValue[bool](ctx, c, invalid.requires)
+
+			switch {
+			case err != nil:
+				result.Status, result.Message = Failed, err.Error()
+			case !required:
+				result.Status, result.Message = Skipped, fmt.Sprintf("%s is false", invalid.requires)
+			}
+
+			if result.Status != Passed {
+				results = append(results, result)
+				continue
+			}
+		}
+
+		r, _, err := c.send(ctx, invalid.verb, invalid.method, invalid.parameters)
+
+		switch {
+		case err != nil:
+			result.Status, result.Message = Failed, err.Error()
+		case r.StatusCode == http.StatusOK && r.Decoded && r.ErrorNumber == notImplemented:
+			result.Status, result.Message = Skipped, "not implemented"
+		case r.StatusCode != http.StatusOK || !r.Decoded || r.ErrorNumber != invalidValue:
+			result.Status, result.Message = Failed, fmt.Sprintf("got %v for %v, wanted ASCOM error 0x401", r.getError(), invalid.parameters)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (c *Checker) checkTransactionIDs(ctx context.Context, spec specification) []Result {
+	results := []Result{}
+
+	for _, verb := range []string{http.MethodGet, http.MethodPut} {
+		method, parameters := "name", [][2]string{}
+
+		if verb == http.MethodPut {
+			method, parameters = "connected", [][2]string{{"Connected", "true"}}
+		}
+
+		r, id, err := c.send(ctx, verb, method, parameters)
+
+		result := Result{Category: TransactionID, Member: fmt.Sprintf("%s %s", verb, method), Status: Passed}
+
+		switch {
+		case err != nil:
+			result.Status, result.Message = Failed, err.Error()
+		case r.getError() != nil:
+			result.Status, result.Message = Failed, r.getError().Error()
+		case r.ClientTransactionID != id:
+			result.Status, result.Message = Failed, fmt.Sprintf("got ClientTransactionID %d, wanted %d", r.ClientTransactionID, id)
+		case r.ServerTransactionID == 0:
+			result.Status, result.Message = Failed, "got no ServerTransactionID"
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (c *Checker) checkCaseInsensitive(ctx context.Context, spec specification) []Result {
+	results := []Result{}
+
+	for _, names := range [][2]string{{"clientid", "clienttransactionid"}, {"CLIENTID", "CLIENTTRANSACTIONID"}} {
+		id := c.nextTransactionID()
+
+		r, err := c.get(ctx, "name", [2]string{names[0], fmt.Sprintf("%d", c.ClientID)}, [2]string{names[1], fmt.Sprintf("%d", id)})
+
+		result := Result{Category: CaseInsensitive, Member: fmt.Sprintf("GET name?%s", names[1]), Status: Passed}
+
+		switch {
+		case err != nil:
+			result.Status, result.Message = Failed, err.Error()
+		case r.getError() != nil:
+			result.Status, result.Message = Failed, r.getError().Error()
+		case r.ClientTransactionID != id:
+			result.Status, result.Message = Failed, fmt.Sprintf("got ClientTransactionID %d, wanted %d", r.ClientTransactionID, id)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (c *Checker) checkAsync(ctx context.Context, spec specification) []Result {
+	results := []Result{}
+
+	for _, check := range spec.async {
+		results = append(results, check(ctx, c))
+	}
+
+	return results
+}
+
+func (c *Checker) checkNotConnected(ctx context.Context, spec specification) []Result {
+	results := []Result{}
+
+	if err := c.command(ctx, "connected", [2]string{"Connected", "false"}); err != nil {
+		return append(results, Result{NotConnected, "connected", Failed, fmt.Sprintf("disconnecting: %v", err)})
+	}
+
+	for _, member := range spec.required {
+		result := Result{Category: NotConnected, Member: member, Status: Passed}
+
+		r, err := c.get(ctx, member)
+
+		switch {
+		case err != nil:
+			result.Status, result.Message = Failed, err.Error()
+		case r.getError() == nil:
+			result.Status, result.Message = Failed, "got a Value while disconnected, wanted ASCOM error 0x407"
+		case r.StatusCode != http.StatusOK || !r.Decoded || r.ErrorNumber != notConnected:
+			result.Status, result.Message = Failed, fmt.Sprintf("got %v while disconnected, wanted ASCOM error 0x407", r.getError())
+		}
+
+		results = append(results, result)
+	}
+
+	if err := c.command(ctx, "connected", [2]string{"Connected", "true"}); err != nil {
+		results = append(results, Result{NotConnected, "connected", Failed, fmt.Sprintf("reconnecting: %v", err)})
+	}
+
+	return results
+}
@@ -0,0 +1,393 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+type fakeSafetySource struct{}
+
+func (fakeSafetySource) IsSafe() (bool, error) { return true, nil }
+
+/*
+testDevice is a minimal Alpaca device server, answering GETs from its values and PUTs with its commands.
+While disconnected, it answers GETs of all but the common members with ASCOM error 0x407.
+*/
+type testDevice struct {
+	mu       sync.Mutex
+	values   map[string]interface{}
+	commands map[string]func(form map[string]string) (int32, int)
+	// Whether to echo the client transaction ID
+	echo       bool
+	serverTxID uint32
+}
+
+func newTestDevice() *testDevice {
+	device := &testDevice{
+		values: map[string]interface{}{
+			"connected":        true,
+			"description":      "Synthetic device",
+			"driverinfo":       "conformance test",
+			"driverversion":    "1.0",
+			"interfaceversion": 3,
+			"name":             "Synthetic device",
+			"supportedactions": []string{},
+		},
+		commands: map[string]func(map[string]string) (int32, int){},
+		echo:     true,
+	}
+
+	device.commands["connected"] = func(form map[string]string) (int32, int) {
+		connected, err := strconv.ParseBool(form["connected"])
+
+		if err != nil {
+			return 0, http.StatusBadRequest
+		}
+
+		device.values["connected"] = connected
+
+		return 0, http.StatusOK
+	}
+
+	return device
+}
+
+/*
+isCommonMember()
+
+@returns true if the member is common to all devices, so answered while disconnected.
+*/
+func isCommonMember(method string) bool {
+	for _, member := range commonMembers {
+		if member == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *testDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+
+	defer d.mu.Unlock()
+
+	method := strings.ToLower(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+
+	r.ParseForm()
+
+	parameters := map[string]string{}
+
+	for name, values := range r.Form {
+		parameters[strings.ToLower(name)] = values[0]
+	}
+
+	response := map[string]interface{}{"ErrorNumber": 0, "ErrorMessage": ""}
+
+	if r.Method == http.MethodPut {
+		command, ok := d.commands[method]
+
+		if !ok {
+			response["ErrorNumber"], response["ErrorMessage"] = 0x400, "not implemented"
+		} else if number, status := command(parameters); status != http.StatusOK {
+			http.Error(w, "bad request", status)
+			return
+		} else if number != 0 {
+			response["ErrorNumber"], response["ErrorMessage"] = number, "invalid value"
+		}
+	} else if d.values["connected"] == false && !isCommonMember(method) {
+		response["ErrorNumber"], response["ErrorMessage"] = 0x407, "not connected"
+	} else if value, ok := d.values[method]; ok {
+		// A value may be computed afresh for each request:
+		if compute, ok := value.(func() interface{}); ok {
+			value = compute()
+		}
+
+		response["Value"] = value
+	} else {
+		response["ErrorNumber"], response["ErrorMessage"] = 0x400, "not implemented"
+	}
+
+	if d.echo {
+		id, _ := strconv.Atoi(parameters["clienttransactionid"])
+		response["ClientTransactionID"] = id
+		d.serverTxID++
+		response["ServerTransactionID"] = d.serverTxID
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func newTestChecker(server *httptest.Server, deviceType string) *Checker {
+	checker := NewChecker(server.URL, deviceType, 0)
+
+	checker.Timeout = 2 * time.Second
+
+	checker.PollInterval = time.Millisecond
+
+	return checker
+}
+
+func TestStatusString(t *testing.T) {
+	var got string = Skipped.String()
+
+	var want string = "skipped"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestCheckerSafetyMonitorServer(t *testing.T) {
+	server := httptest.NewServer(alpacago.NewSafetyMonitorServer(fakeSafetySource{}, 0, "Synthetic Safety Monitor"))
+
+	defer server.Close()
+
+	report := newTestChecker(server, "SafetyMonitor").Run(context.Background())
+
+	if !report.IsConformant() {
+		var text bytes.Buffer
+		report.WriteText(&text)
+		t.Errorf("got a non-conformant report, wanted conformant:\n%s", text.String())
+	}
+
+	passed, _, _ := report.GetSummary()
+
+	// 8 required, 1 invalid value, 2 transaction ID, 2 case insensitive and 1 not connected checks:
+	if passed != 14 {
+		t.Errorf("got %d passed, wanted 14", passed)
+	}
+}
+
+func TestCheckerNonConformant(t *testing.T) {
+	device := newTestDevice()
+
+	device.echo = false
+
+	// Unparseable values are accepted:
+	device.commands["connected"] = func(map[string]string) (int32, int) { return 0, http.StatusOK }
+
+	server := httptest.NewServer(device)
+
+	defer server.Close()
+
+	report := newTestChecker(server, "safetymonitor").Run(context.Background())
+
+	failures := map[string]bool{}
+
+	for _, result := range report.Results {
+		if result.Status == Failed {
+			failures[result.Category.String()+" "+result.Member] = true
+		}
+	}
+
+	for _, want := range []string{
+		"required issafe",
+		"invalid value connected",
+		"transaction id GET name",
+		"transaction id PUT connected",
+		"case insensitive GET name?clienttransactionid",
+		"not connected issafe",
+	} {
+		if !failures[want] {
+			t.Errorf("got failures %v, wanted %q", failures, want)
+		}
+	}
+}
+
+/*
+newTestFocuser()
+
+@returns an absolute focuser at position 500 of 1000, reporting moving for a few milliseconds after each move.
+*/
+func newTestFocuser() *testDevice {
+	device := newTestDevice()
+
+	for method, value := range map[string]interface{}{
+		"absolute": true, "ismoving": false, "maxincrement": 1000, "maxstep": 1000, "tempcomp": false,
+		"tempcompavailable": false, "position": 500,
+	} {
+		device.values[method] = value
+	}
+
+	device.commands["move"] = func(form map[string]string) (int32, int) {
+		position, err := strconv.Atoi(form["position"])
+
+		if err != nil {
+			return 0, http.StatusBadRequest
+		}
+
+		if position < 0 || position > 1000 {
+			return 0x401, http.StatusOK
+		}
+
+		device.values["position"], device.values["ismoving"] = position, true
+
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			device.mu.Lock()
+			device.values["ismoving"] = false
+			device.mu.Unlock()
+		}()
+
+		return 0, http.StatusOK
+	}
+
+	return device
+}
+
+func TestCheckerFocuser(t *testing.T) {
+	device := newTestFocuser()
+
+	server := httptest.NewServer(device)
+
+	defer server.Close()
+
+	report := newTestChecker(server, "focuser").Run(context.Background())
+
+	var text bytes.Buffer
+
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("got %q, wanted nil", err)
+	}
+
+	if !report.IsConformant() {
+		t.Errorf("got a non-conformant report, wanted conformant:\n%s", text.String())
+	}
+
+	for _, want := range []string{"focuser 0 conformance", "async", "move", "optional", "not implemented"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("got %q, wanted %q", text.String(), want)
+		}
+	}
+
+	if device.values["position"] != 500 {
+		t.Errorf("got position %v, wanted the focuser returned to 500", device.values["position"])
+	}
+}
+
+func TestCheckerRestoresConnected(t *testing.T) {
+	device := newTestFocuser()
+
+	device.values["connected"] = false
+
+	server := httptest.NewServer(device)
+
+	defer server.Close()
+
+	report := newTestChecker(server, "focuser").Run(context.Background())
+
+	if !report.IsConformant() {
+		var text bytes.Buffer
+		report.WriteText(&text)
+		t.Errorf("got a non-conformant report, wanted conformant:\n%s", text.String())
+	}
+
+	if device.values["connected"] != false {
+		t.Errorf("got connected %v, wanted the focuser disconnected again", device.values["connected"])
+	}
+}
+
+func TestCheckerRelativeFocuser(t *testing.T) {
+	device := newTestFocuser()
+
+	device.values["absolute"] = false
+
+	// A relative focuser moves by the given number of steps, so -1 is valid:
+	moves := []string{}
+
+	device.commands["move"] = func(form map[string]string) (int32, int) {
+		moves = append(moves, form["position"])
+		return 0, http.StatusOK
+	}
+
+	server := httptest.NewServer(device)
+
+	defer server.Close()
+
+	report := newTestChecker(server, "focuser").Run(context.Background())
+
+	for _, result := range report.Results {
+		if result.Category == InvalidValue && result.Member == "move" && result.Status != Skipped {
+			t.Errorf("got %s (%s), wanted the invalid move skipped", result.Status, result.Message)
+		}
+	}
+
+	if len(moves) != 0 {
+		t.Errorf("got moves %v, wanted none", moves)
+	}
+}
+
+/*
+newTestCamera()
+
+@returns a camera whose exposures run through the given states (one per poll) before the image is ready.
+*/
+func newTestCamera(states ...int32) *testDevice {
+	device := newTestDevice()
+
+	device.values["exposuremin"], device.values["camerastate"], device.values["imageready"] = 0.001, 0, false
+
+	device.commands["startexposure"] = func(form map[string]string) (int32, int) {
+		remaining := append([]int32{}, states...)
+
+		device.values["imageready"] = len(remaining) == 0
+
+		polls := 0
+
+		device.values["camerastate"] = func() interface{} {
+			if polls < len(remaining) {
+				polls++
+				return remaining[polls-1]
+			}
+
+			device.values["imageready"] = true
+
+			return 0
+		}
+
+		return 0, http.StatusOK
+	}
+
+	return device
+}
+
+func TestCheckCameraExposure(t *testing.T) {
+	for _, test := range []struct {
+		states []int32
+		want   Status
+	}{
+		// Exposing, then reading:
+		{[]int32{2, 3}, Passed},
+		// An image ready at once, without leaving the idle state:
+		{[]int32{}, Failed},
+	} {
+		server := httptest.NewServer(newTestCamera(test.states...))
+
+		result := checkCameraExposure(context.Background(), newTestChecker(server, "camera"))
+
+		server.Close()
+
+		if result.Status != test.want {
+			t.Errorf("%v: got %s (%s), wanted %s", test.states, result.Status, result.Message, test.want)
+		}
+	}
+}
+
+func TestCheckerUnknownDeviceType(t *testing.T) {
+	report := NewChecker("http://localhost:11111", "telescopes", 0).Run(context.Background())
+
+	if report.IsConformant() || len(report.Results) != 1 {
+		t.Errorf("got %+v, wanted a single failure", report.Results)
+	}
+}
@@ -0,0 +1,464 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+)
+
+/*
+invalidRequest is a request with a value out of range, which the device must reject with ASCOM error 0x401.
+*/
+type invalidRequest struct {
+	verb       string
+	method     string
+	parameters [][2]string
+	// The boolean GET member which must be true for the value to be invalid e.g., "absolute", or ""
+	requires string
+}
+
+/*
+specification is the ASCOM interface of a device type: the GET members it must implement, those it may
+implement, its invalid values, and its asynchronous operations.
+*/
+type specification struct {
+	required []string
+	optional []string
+	invalid  []invalidRequest
+	async    []func(ctx context.Context, c *Checker) Result
+}
+
+/*
+commonMembers are the GET members required of all ASCOM Alpaca compliant devices.
+*/
+var commonMembers = []string{"connected", "description", "driverinfo", "driverversion", "interfaceversion", "name", "supportedactions"}
+
+var specifications = map[string]specification{
+	"camera": {
+		required: []string{
+			"binx", "biny", "camerastate", "cameraxsize", "cameraysize", "canabortexposure", "canasymmetricbin",
+			"cangetcoolerpower", "canpulseguide", "cansetccdtemperature", "canstopexposure", "exposuremax",
+			"exposuremin", "hasshutter", "imageready", "maxadu", "maxbinx", "maxbiny", "numx", "numy",
+			"pixelsizex", "pixelsizey", "startx", "starty",
+		},
+		optional: []string{
+			"bayeroffsetx", "bayeroffsety", "canfastreadout", "ccdtemperature", "cooleron", "coolerpower",
+			"electronsperadu", "exposureresolution", "fastreadout", "fullwellcapacity", "gain", "gainmax",
+			"gainmin", "gains", "heatsinktemperature", "ispulseguiding", "lastexposureduration",
+			"lastexposurestarttime", "offset", "percentcompleted", "readoutmode", "readoutmodes", "sensorname",
+			"sensortype", "setccdtemperature", "subexposureduration",
+		},
+		invalid: []invalidRequest{
+			{http.MethodPut, "binx", [][2]string{{"BinX", "0"}}, ""},
+			{http.MethodPut, "biny", [][2]string{{"BinY", "0"}}, ""},
+		},
+		async: []func(context.Context, *Checker) Result{checkCameraExposure},
+	},
+	"covercalibrator": {
+		required: []string{"calibratorstate", "coverstate"},
+		optional: []string{"brightness", "maxbrightness"},
+		invalid: []invalidRequest{
+			{http.MethodPut, "calibratoron", [][2]string{{"Brightness", "-1"}}, ""},
+		},
+		async: []func(context.Context, *Checker) Result{checkCalibrator},
+	},
+	"dome": {
+		required: []string{
+			"athome", "atpark", "canfindhome", "canpark", "cansetaltitude", "cansetazimuth", "cansetpark",
+			"cansetshutter", "canslave", "cansyncazimuth", "slaved", "slewing",
+		},
+		optional: []string{"altitude", "azimuth", "shutterstatus"},
+		invalid: []invalidRequest{
+			{http.MethodPut, "slewtoazimuth", [][2]string{{"Azimuth", "-10"}}, ""},
+		},
+		async: []func(context.Context, *Checker) Result{checkDomeSlew},
+	},
+	"filterwheel": {
+		required: []string{"focusoffsets", "names", "position"},
+		invalid: []invalidRequest{
+			{http.MethodPut, "position", [][2]string{{"Position", "-2"}}, ""},
+		},
+		async: []func(context.Context, *Checker) Result{checkFilterWheelMove},
+	},
+	"focuser": {
+		required: []string{"absolute", "ismoving", "maxincrement", "maxstep", "tempcomp", "tempcompavailable"},
+		optional: []string{"position", "stepsize", "temperature"},
+		invalid: []invalidRequest{
+			{http.MethodPut, "move", [][2]string{{"Position", "-1"}}, "absolute"},
+		},
+		async: []func(context.Context, *Checker) Result{checkFocuserMove},
+	},
+	"observingconditions": {
+		required: []string{"averageperiod"},
+		optional: []string{
+			"cloudcover", "dewpoint", "humidity", "pressure", "rainrate", "skybrightness", "skyquality",
+			"skytemperature", "starfwhm", "temperature", "winddirection", "windgust", "windspeed",
+		},
+		invalid: []invalidRequest{
+			{http.MethodPut, "averageperiod", [][2]string{{"AveragePeriod", "-1"}}, ""},
+		},
+	},
+	"rotator": {
+		required: []string{"canreverse", "ismoving", "mechanicalposition", "position", "targetposition"},
+		optional: []string{"reverse", "stepsize"},
+		invalid: []invalidRequest{
+			{http.MethodPut, "moveabsolute", [][2]string{{"Position", "-1"}}, ""},
+		},
+		async: []func(context.Context, *Checker) Result{checkRotatorMove},
+	},
+	"safetymonitor": {
+		required: []string{"issafe"},
+	},
+	"switch": {
+		required: []string{"maxswitch"},
+		invalid: []invalidRequest{
+			{http.MethodGet, "getswitchvalue", [][2]string{{"Id", "-1"}}, ""},
+		},
+	},
+	"telescope": {
+		required: []string{
+			"alignmentmode", "athome", "atpark", "canfindhome", "canpark", "canpulseguide", "cansetdeclinationrate",
+			"cansetguiderates", "cansetpark", "cansetpierside", "cansetrightascensionrate", "cansettracking",
+			"canslew", "canslewaltaz", "canslewaltazasync", "canslewasync", "cansync", "cansyncaltaz",
+			"canunpark", "declination", "doesrefraction", "equatorialsystem", "ispulseguiding", "rightascension",
+			"siderealtime", "slewing", "tracking", "trackingrates", "utcdate",
+		},
+		optional: []string{
+			"altitude", "aperturearea", "aperturediameter", "azimuth", "declinationrate", "focallength",
+			"guideratedeclination", "guideraterightascension", "rightascensionrate", "sideofpier",
+			"siteelevation", "sitelatitude", "sitelongitude", "slewsettletime", "targetdeclination",
+			"targetrightascension", "trackingrate",
+		},
+		invalid: []invalidRequest{
+			{http.MethodPut, "targetdeclination", [][2]string{{"TargetDeclination", "100"}}, ""},
+			{http.MethodPut, "targetrightascension", [][2]string{{"TargetRightAscension", "25"}}, ""},
+		},
+		async: []func(context.Context, *Checker) Result{checkPulseGuide},
+	},
+}
+
+/*
+getAsyncResult()
+
+@returns the result of an asynchronous check: passed if err is nil.
+*/
+func getAsyncResult(member string, err error) Result {
+	if err != nil {
+		return Result{Category: Async, Member: member, Status: Failed, Message: err.Error()}
+	}
+
+	return Result{Category: Async, Member: member, Status: Passed}
+}
+
+/*
+checkCameraExposure()
+
+Takes a short dark exposure, which must leave the idle state (e.g., exposing, then reading) and return to it
+with an image ready.
+*/
+func checkCameraExposure(ctx context.Context, c *Checker) Result {
+	minimum, err := getValue[float64](ctx, c, "exposuremin")
+
+	if err != nil {
+		return getAsyncResult("startexposure", err)
+	}
+
+	duration := math.Max(minimum, 0.1)
+
+	if err := c.command(ctx, "startexposure", [2]string{"Duration", fmt.Sprintf("%g", duration)}, [2]string{"Light", "false"}); err != nil {
+		return getAsyncResult("startexposure", err)
+	}
+
+	busy := false
+
+	err = c.waitUntil(ctx, func() (bool, error) {
+		state, err := getValue[int32](ctx, c, "camerastate")
+
+		if err != nil {
+			return false, err
+		}
+
+		// CameraError:
+		if state == 5 {
+			return false, fmt.Errorf("the camera state is error")
+		}
+
+		// Waiting, exposing, reading or downloading:
+		if state != 0 {
+			busy = true
+			return false, nil
+		}
+
+		ready, err := getValue[bool](ctx, c, "imageready")
+
+		if err == nil && ready && !busy {
+			err = fmt.Errorf("the camera reported an image ready without leaving the idle state")
+		}
+
+		return ready, err
+	})
+
+	return getAsyncResult("startexposure", err)
+}
+
+/*
+checkCalibrator()
+
+Turns the calibrator on at its lowest brightness, which must become ready, then off.
+*/
+func checkCalibrator(ctx context.Context, c *Checker) Result {
+	state, err := getValue[int32](ctx, c, "calibratorstate")
+
+	if err != nil {
+		return getAsyncResult("calibratoron", err)
+	}
+
+	// NotPresent:
+	if state == 0 {
+		return Result{Category: Async, Member: "calibratoron", Status: Skipped, Message: "no calibrator is present"}
+	}
+
+	waitForState := func(want int32) error {
+		return c.waitUntil(ctx, func() (bool, error) {
+			state, err := getValue[int32](ctx, c, "calibratorstate")
+
+			if err == nil && state == 5 {
+				err = fmt.Errorf("the calibrator state is error")
+			}
+
+			return state == want, err
+		})
+	}
+
+	if err := c.command(ctx, "calibratoron", [2]string{"Brightness", "1"}); err != nil {
+		return getAsyncResult("calibratoron", err)
+	}
+
+	// Ready:
+	if err := waitForState(3); err != nil {
+		return getAsyncResult("calibratoron", err)
+	}
+
+	if err := c.command(ctx, "calibratoroff"); err != nil {
+		return getAsyncResult("calibratoroff", err)
+	}
+
+	// Off:
+	return getAsyncResult("calibratoroff", waitForState(1))
+}
+
+/*
+checkDomeSlew()
+
+Slews the dome to its current azimuth, which must complete.
+*/
+func checkDomeSlew(ctx context.Context, c *Checker) Result {
+	can, err := getValue[bool](ctx, c, "cansetazimuth")
+
+	if err != nil {
+		return getAsyncResult("slewtoazimuth", err)
+	}
+
+	if !can {
+		return Result{Category: Async, Member: "slewtoazimuth", Status: Skipped, Message: "the dome cannot set its azimuth"}
+	}
+
+	azimuth, err := getValue[float64](ctx, c, "azimuth")
+
+	if err != nil {
+		return getAsyncResult("slewtoazimuth", err)
+	}
+
+	if err := c.command(ctx, "slewtoazimuth", [2]string{"Azimuth", fmt.Sprintf("%g", azimuth)}); err != nil {
+		return getAsyncResult("slewtoazimuth", err)
+	}
+
+	return getAsyncResult("slewtoazimuth", c.waitUntil(ctx, func() (bool, error) {
+		slewing, err := getValue[bool](ctx, c, "slewing")
+		return !slewing, err
+	}))
+}
+
+/*
+checkFilterWheelMove()
+
+Moves the filter wheel to the next filter, which must report position -1 until it arrives, then back.
+*/
+func checkFilterWheelMove(ctx context.Context, c *Checker) Result {
+	names, err := getValue[[]string](ctx, c, "names")
+
+	if err != nil {
+		return getAsyncResult("position", err)
+	}
+
+	position, err := getValue[int32](ctx, c, "position")
+
+	if err != nil {
+		return getAsyncResult("position", err)
+	}
+
+	if len(names) < 2 || position < 0 {
+		return Result{Category: Async, Member: "position", Status: Skipped, Message: "the filter wheel has fewer than 2 filters, or is moving"}
+	}
+
+	move := func(target int32) error {
+		if err := c.command(ctx, "position", [2]string{"Position", fmt.Sprintf("%d", target)}); err != nil {
+			return err
+		}
+
+		return c.waitUntil(ctx, func() (bool, error) {
+			position, err := getValue[int32](ctx, c, "position")
+
+			if err == nil && position != -1 && position != target {
+				err = fmt.Errorf("got position %d while moving, wanted -1 or %d", position, target)
+			}
+
+			return position == target, err
+		})
+	}
+
+	if err := move((position + 1) % int32(len(names))); err != nil {
+		return getAsyncResult("position", err)
+	}
+
+	return getAsyncResult("position", move(position))
+}
+
+/*
+checkFocuserMove()
+
+Moves an absolute focuser by one step, which must complete at the target position, then back.
+*/
+func checkFocuserMove(ctx context.Context, c *Checker) Result {
+	absolute, err := getValue[bool](ctx, c, "absolute")
+
+	if err != nil {
+		return getAsyncResult("move", err)
+	}
+
+	if !absolute {
+		return Result{Category: Async, Member: "move", Status: Skipped, Message: "the focuser is not absolute"}
+	}
+
+	position, err := getValue[int32](ctx, c, "position")
+
+	if err != nil {
+		return getAsyncResult("move", err)
+	}
+
+	maximum, err := getValue[int32](ctx, c, "maxstep")
+
+	if err != nil {
+		return getAsyncResult("move", err)
+	}
+
+	move := func(target int32) error {
+		if err := c.command(ctx, "move", [2]string{"Position", fmt.Sprintf("%d", target)}); err != nil {
+			return err
+		}
+
+		err := c.waitUntil(ctx, func() (bool, error) {
+			moving, err := getValue[bool](ctx, c, "ismoving")
+			return !moving, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		position, err := getValue[int32](ctx, c, "position")
+
+		if err == nil && position != target {
+			err = fmt.Errorf("got position %d, wanted %d", position, target)
+		}
+
+		return err
+	}
+
+	target := position + 1
+
+	if target > maximum {
+		target = position - 1
+	}
+
+	if err := move(target); err != nil {
+		return getAsyncResult("move", err)
+	}
+
+	return getAsyncResult("move", move(position))
+}
+
+/*
+checkRotatorMove()
+
+Moves the rotator by one degree, which must complete at the target position, then back.
+*/
+func checkRotatorMove(ctx context.Context, c *Checker) Result {
+	position, err := getValue[float64](ctx, c, "position")
+
+	if err != nil {
+		return getAsyncResult("moveabsolute", err)
+	}
+
+	tolerance := 0.1
+
+	if step, err := getValue[float64](ctx, c, "stepsize"); err == nil {
+		tolerance = math.Max(tolerance, step)
+	}
+
+	move := func(target float64) error {
+		if err := c.command(ctx, "moveabsolute", [2]string{"Position", fmt.Sprintf("%g", target)}); err != nil {
+			return err
+		}
+
+		err := c.waitUntil(ctx, func() (bool, error) {
+			moving, err := getValue[bool](ctx, c, "ismoving")
+			return !moving, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		position, err := getValue[float64](ctx, c, "position")
+
+		if offset := math.Abs(math.Remainder(position-target, 360)); err == nil && offset > tolerance {
+			err = fmt.Errorf("got position %g, wanted %g", position, target)
+		}
+
+		return err
+	}
+
+	if err := move(math.Mod(position+1, 360)); err != nil {
+		return getAsyncResult("moveabsolute", err)
+	}
+
+	return getAsyncResult("moveabsolute", move(position))
+}
+
+/*
+checkPulseGuide()
+
+Pulse guides the telescope North for 200 ms, which must complete.
+*/
+func checkPulseGuide(ctx context.Context, c *Checker) Result {
+	can, err := getValue[bool](ctx, c, "canpulseguide")
+
+	if err != nil {
+		return getAsyncResult("pulseguide", err)
+	}
+
+	if !can {
+		return Result{Category: Async, Member: "pulseguide", Status: Skipped, Message: "the telescope cannot pulse guide"}
+	}
+
+	if err := c.command(ctx, "pulseguide", [2]string{"Direction", "0"}, [2]string{"Duration", "200"}); err != nil {
+		return getAsyncResult("pulseguide", err)
+	}
+
+	return getAsyncResult("pulseguide", c.waitUntil(ctx, func() (bool, error) {
+		guiding, err := getValue[bool](ctx, c, "ispulseguiding")
+		return !guiding, err
+	}))
+}
@@ -0,0 +1,574 @@
+package indi
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+DefaultAddress is the address INDI servers listen on by default.
+*/
+const DefaultAddress = ":7624"
+
+/*
+numberHandler handles a client's new values of a number vector, merged with its current values.
+*/
+type numberHandler func(ctx context.Context, values map[string]float64) error
+
+/*
+switchHandler handles a client's new states of a switch vector, merged with its current states (per its rule).
+*/
+type switchHandler func(ctx context.Context, on map[string]bool) error
+
+type property struct {
+	vector   *Vector
+	onNumber numberHandler
+	onSwitch switchHandler
+}
+
+type device struct {
+	name       string
+	properties []*property
+	// Read the current values of the properties from the Alpaca device
+	refreshers []func()
+}
+
+/*
+getProperty()
+
+@returns the named property of the device, or nil.
+*/
+func (d *device) getProperty(name string) *property {
+	for _, p := range d.properties {
+		if p.vector.Name == name {
+			return p
+		}
+	}
+
+	return nil
+}
+
+/*
+clientQueueSize is the number of elements queued for a client before it is dropped as too slow.
+*/
+const clientQueueSize = 256
+
+type client struct {
+	conn net.Conn
+	// The elements waiting to be written by the client's writer
+	queue chan []byte
+	done  chan struct{}
+	once  sync.Once
+	mu    sync.Mutex
+	// The BLOB policy (Never, Also or Only) by device, Never by default
+	blobs map[string]string
+}
+
+func newClient(conn net.Conn) *client {
+	return &client{conn: conn, queue: make(chan []byte, clientQueueSize), done: make(chan struct{}), blobs: map[string]string{}}
+}
+
+/*
+send()
+
+Queues the element for the client's writer, without waiting for it to be written. A client whose queue is
+full is too slow to keep up, so is dropped.
+*/
+func (c *client) send(element interface{}) error {
+	data, err := xml.Marshal(element)
+
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.queue <- append(data, '\n'):
+		return nil
+	default:
+		c.close()
+		return errors.New("the client is too slow to keep up, so was dropped")
+	}
+}
+
+/*
+write()
+
+Writes the queued elements to the client, until it is closed. A client whose write fails or does not
+complete within the timeout is dropped.
+*/
+func (c *client) write(timeout time.Duration) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.queue:
+			c.conn.SetWriteDeadline(time.Now().Add(timeout))
+
+			if _, err := c.conn.Write(data); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+/*
+close()
+
+Closes the client's connection, which ends its writer and (as its reads fail) its handler.
+*/
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *client) getBLOBPolicy(device string) string {
+	c.mu.Lock()
+
+	defer c.mu.Unlock()
+
+	if policy, ok := c.blobs[device]; ok {
+		return policy
+	}
+
+	return "Never"
+}
+
+/*
+Bridge serves Alpaca devices (accessed through this library, or any implementation of their device APIs)
+as INDI devices to INDI clients over TCP.
+*/
+type Bridge struct {
+	// How often the devices are polled during an operation e.g., a slew
+	PollInterval time.Duration
+	// How long an operation may take (seconds), as advertised to clients
+	Timeout int
+	// How long a write to a client may take before the client is dropped
+	WriteTimeout time.Duration
+
+	mu      sync.Mutex
+	devices []*device
+	clients map[*client]struct{}
+	now     func() time.Time
+}
+
+func NewBridge() *Bridge {
+	bridge := Bridge{
+		PollInterval: 500 * time.Millisecond,
+		Timeout:      60,
+		WriteTimeout: 10 * time.Second,
+		clients:      map[*client]struct{}{},
+		now:          time.Now,
+	}
+
+	return &bridge
+}
+
+/*
+addDevice()
+
+Adds the device, with the CONNECTION and DRIVER_INFO properties common to all INDI devices.
+*/
+func (b *Bridge) addDevice(name string, connector deviceConnector, driverInterface int) *device {
+	d := &device{name: name}
+
+	connection := &Vector{
+		Name:  "CONNECTION",
+		Label: "Connection",
+		Group: "Main Control",
+		Kind:  SwitchKind,
+		Perm:  ReadWrite,
+		Rule:  OneOfMany,
+		Members: []Member{
+			{Name: "CONNECT", Label: "Connect"},
+			{Name: "DISCONNECT", Label: "Disconnect", On: true},
+		},
+	}
+
+	b.addProperty(d, connection, nil, func(ctx context.Context, on map[string]bool) error {
+		if err := connector.SetConnected(on["CONNECT"]); err != nil {
+			return err
+		}
+
+		b.update(connection, Ok, "", func() { setSwitches(connection, on) })
+
+		return nil
+	})
+
+	b.addProperty(d, &Vector{
+		Name:  "DRIVER_INFO",
+		Label: "Driver Info",
+		Group: "General Info",
+		Kind:  TextKind,
+		Perm:  ReadOnly,
+		Members: []Member{
+			{Name: "DRIVER_NAME", Label: "Name", Text: "alpacago"},
+			{Name: "DRIVER_EXEC", Label: "Exec", Text: "alpacago-indi"},
+			{Name: "DRIVER_VERSION", Label: "Version", Text: "1.0"},
+			{Name: "DRIVER_INTERFACE", Label: "Interface", Text: strconv.Itoa(driverInterface)},
+		},
+	}, nil, nil)
+
+	d.refreshers = append(d.refreshers, func() {
+		if connected, err := connector.IsConnected(); err == nil {
+			b.modify(func() {
+				setSwitches(connection, map[string]bool{"CONNECT": connected, "DISCONNECT": !connected})
+			})
+		}
+	})
+
+	b.mu.Lock()
+
+	b.devices = append(b.devices, d)
+
+	b.mu.Unlock()
+
+	return d
+}
+
+func (b *Bridge) addProperty(d *device, vector *Vector, onNumber numberHandler, onSwitch switchHandler) {
+	vector.Device = d.name
+
+	if vector.State == "" {
+		vector.State = Idle
+	}
+
+	if vector.Timeout == 0 {
+		vector.Timeout = b.Timeout
+	}
+
+	d.properties = append(d.properties, &property{vector: vector, onNumber: onNumber, onSwitch: onSwitch})
+}
+
+/*
+modify()
+
+Changes the values of vectors under the bridge's lock, without notifying the clients e.g., when refreshing
+them before they are defined.
+*/
+func (b *Bridge) modify(change func()) {
+	b.mu.Lock()
+
+	defer b.mu.Unlock()
+
+	change()
+}
+
+/*
+update()
+
+Changes the vector's values and state, then sends the update to the clients.
+*/
+func (b *Bridge) update(vector *Vector, state PropertyState, message string, change func()) {
+	b.mu.Lock()
+
+	if change != nil {
+		change()
+	}
+
+	vector.State, vector.Message = state, message
+
+	element := vector.getElement(false, b.now())
+
+	vector.Message = ""
+
+	clients := []*client{}
+
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+
+	b.mu.Unlock()
+
+	for _, c := range clients {
+		policy := c.getBLOBPolicy(vector.Device)
+
+		// Images are only sent to clients which enabled BLOBs, which may receive only BLOBs:
+		if (vector.Kind == BLOBKind && policy == "Never") || (vector.Kind != BLOBKind && policy == "Only") {
+			continue
+		}
+
+		c.send(element)
+	}
+}
+
+/*
+fail()
+
+Puts the vector in the Alert state with the error as its message.
+*/
+func (b *Bridge) fail(vector *Vector, err error) {
+	b.update(vector, Alert, err.Error(), nil)
+}
+
+/*
+Serve()
+
+Serves INDI clients connecting to the listener, until the context is done.
+*/
+func (b *Bridge) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return err
+		}
+
+		go b.handle(ctx, conn)
+	}
+}
+
+/*
+ListenAndServe()
+
+Listens on the TCP address (e.g., DefaultAddress) and serves INDI clients, until the context is done.
+*/
+func (b *Bridge) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	return b.Serve(ctx, listener)
+}
+
+func (b *Bridge) handle(ctx context.Context, conn net.Conn) {
+	c := newClient(conn)
+
+	b.mu.Lock()
+
+	b.clients[c] = struct{}{}
+
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+		c.close()
+	}()
+
+	go c.write(b.WriteTimeout)
+
+	// Operations (e.g., a slew) started by the client outlive its connection, until the bridge is done:
+	closing, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	go func() {
+		<-closing.Done()
+		c.close()
+	}()
+
+	decoder := xml.NewDecoder(conn)
+
+	for {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return
+		}
+
+		start, ok := token.(xml.StartElement)
+
+		if !ok {
+			continue
+		}
+
+		element := incomingElement{}
+
+		if err := decoder.DecodeElement(&element, &start); err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			continue
+		}
+
+		b.dispatch(ctx, c, &element)
+	}
+}
+
+func (b *Bridge) dispatch(ctx context.Context, c *client, element *incomingElement) {
+	switch element.XMLName.Local {
+	case "getProperties":
+		b.define(c, element.Device, element.Name)
+	case "enableBLOB":
+		c.mu.Lock()
+		c.blobs[element.Device] = strings.TrimSpace(element.Value)
+		c.mu.Unlock()
+	case "newNumberVector":
+		if p := b.getProperty(element.Device, element.Name, NumberKind); p != nil && p.onNumber != nil {
+			go b.setNumbers(ctx, p, element.getValues())
+		}
+	case "newSwitchVector":
+		if p := b.getProperty(element.Device, element.Name, SwitchKind); p != nil && p.onSwitch != nil {
+			go b.setSwitches(ctx, p, element.getValues())
+		}
+	}
+}
+
+func (b *Bridge) getProperty(deviceName string, name string, kind VectorKind) *property {
+	b.mu.Lock()
+
+	defer b.mu.Unlock()
+
+	for _, d := range b.devices {
+		if d.name != deviceName {
+			continue
+		}
+
+		if p := d.getProperty(name); p != nil && p.vector.Kind == kind && p.vector.Perm != ReadOnly {
+			return p
+		}
+	}
+
+	return nil
+}
+
+/*
+define()
+
+Sends the client the definitions of the properties of all devices, or of the named device and property,
+with their current values.
+*/
+func (b *Bridge) define(c *client, deviceName string, name string) {
+	b.mu.Lock()
+
+	devices := append([]*device{}, b.devices...)
+
+	b.mu.Unlock()
+
+	for _, d := range devices {
+		if deviceName != "" && d.name != deviceName {
+			continue
+		}
+
+		for _, refresh := range d.refreshers {
+			refresh()
+		}
+
+		for _, p := range d.properties {
+			if name != "" && p.vector.Name != name {
+				continue
+			}
+
+			b.mu.Lock()
+
+			element := p.vector.getElement(true, b.now())
+
+			b.mu.Unlock()
+
+			c.send(element)
+		}
+	}
+}
+
+func (b *Bridge) setNumbers(ctx context.Context, p *property, values map[string]string) {
+	numbers := map[string]float64{}
+
+	b.mu.Lock()
+
+	for _, m := range p.vector.Members {
+		numbers[m.Name] = m.Number
+	}
+
+	b.mu.Unlock()
+
+	for name, value := range values {
+		if _, ok := numbers[name]; !ok {
+			continue
+		}
+
+		number, err := parseNumber(value)
+
+		if err != nil {
+			b.fail(p.vector, err)
+			return
+		}
+
+		numbers[name] = number
+	}
+
+	if err := p.onNumber(ctx, numbers); err != nil {
+		b.fail(p.vector, err)
+	}
+}
+
+func (b *Bridge) setSwitches(ctx context.Context, p *property, values map[string]string) {
+	on := map[string]bool{}
+
+	b.mu.Lock()
+
+	for _, m := range p.vector.Members {
+		on[m.Name] = m.On
+	}
+
+	rule := p.vector.Rule
+
+	b.mu.Unlock()
+
+	// A switch turned on turns the others off, unless any may be on:
+	for _, value := range values {
+		if value == "On" && rule != AnyOfMany {
+			for n := range on {
+				on[n] = false
+			}
+		}
+	}
+
+	for name, value := range values {
+		if _, ok := on[name]; ok {
+			on[name] = value == "On"
+		}
+	}
+
+	if err := p.onSwitch(ctx, on); err != nil {
+		b.fail(p.vector, err)
+	}
+}
+
+/*
+setSwitches()
+
+Sets the vector's switches from the states, which must be called under the bridge's lock.
+*/
+func setSwitches(vector *Vector, on map[string]bool) {
+	for i := range vector.Members {
+		if state, ok := on[vector.Members[i].Name]; ok {
+			vector.Members[i].On = state
+		}
+	}
+}
+
+/*
+setNumbers()
+
+Sets the vector's numbers from the values, which must be called under the bridge's lock.
+*/
+func setNumbers(vector *Vector, values map[string]float64) {
+	for i := range vector.Members {
+		if value, ok := values[vector.Members[i].Name]; ok {
+			vector.Members[i].Number = value
+		}
+	}
+}
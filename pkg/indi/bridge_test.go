@@ -0,0 +1,570 @@
+package indi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+	"github.com/observerly/alpacago/pkg/alpacago/mocks"
+)
+
+type testResponse struct {
+	XMLName xml.Name
+	Device  string           `xml:"device,attr"`
+	Name    string           `xml:"name,attr"`
+	State   PropertyState    `xml:"state,attr"`
+	Perm    Permission       `xml:"perm,attr"`
+	Message string           `xml:"message,attr"`
+	Members []incomingMember `xml:",any"`
+}
+
+type testClient struct {
+	t       *testing.T
+	conn    net.Conn
+	decoder *xml.Decoder
+}
+
+func newTestClient(t *testing.T, bridge *Bridge) *testClient {
+	bridge.PollInterval = time.Millisecond
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Cleanup(cancel)
+
+	go bridge.Serve(ctx, listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return &testClient{t: t, conn: conn, decoder: xml.NewDecoder(conn)}
+}
+
+func (c *testClient) send(message string) {
+	if _, err := fmt.Fprintln(c.conn, message); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+/*
+waitFor()
+
+@returns the first element received with the tag and property name, and (if not empty) state.
+*/
+func (c *testClient) waitFor(tag string, name string, state PropertyState) testResponse {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for {
+		token, err := c.decoder.Token()
+
+		if err != nil {
+			c.t.Fatalf("got %v, waiting for %s %s", err, tag, name)
+		}
+
+		start, ok := token.(xml.StartElement)
+
+		if !ok {
+			continue
+		}
+
+		response := testResponse{}
+
+		if err := c.decoder.DecodeElement(&response, &start); err != nil {
+			c.t.Fatal(err)
+		}
+
+		if response.XMLName.Local == tag && response.Name == name && (state == "" || response.State == state) {
+			return response
+		}
+	}
+}
+
+func (r testResponse) getValue(name string) string {
+	for _, m := range r.Members {
+		if m.Name == name {
+			return m.Value
+		}
+	}
+
+	return ""
+}
+
+/*
+getCommands()
+
+@returns the calls which command the device (its setters), leaving out the reads.
+*/
+func getCommands(calls []mocks.Call) []mocks.Call {
+	commands := []mocks.Call{}
+
+	for _, call := range calls {
+		if strings.HasPrefix(call.Method, "Set") {
+			commands = append(commands, call)
+		}
+	}
+
+	return commands
+}
+
+func TestGetProperties(t *testing.T) {
+	telescope := &mocks.Telescope{
+		IsConnectedFunc:       func() (bool, error) { return true, nil },
+		GetRightAscensionFunc: func() (float64, error) { return 3.79, nil },
+		GetDeclinationFunc:    func() (float64, error) { return 24.1, nil },
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddTelescope("Telescope Simulator", telescope)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<getProperties version="1.7"/>`)
+
+	connection := client.waitFor("defSwitchVector", "CONNECTION", "")
+
+	if got := connection.getValue("CONNECT"); got != "On" {
+		t.Errorf("got %q, wanted CONNECT On", got)
+	}
+
+	coordinates := client.waitFor("defNumberVector", "EQUATORIAL_EOD_COORD", "")
+
+	if coordinates.Device != "Telescope Simulator" || coordinates.getValue("RA") != "3.79" || coordinates.getValue("DEC") != "24.1" {
+		t.Errorf("got %+v, wanted RA 3.79 and DEC 24.1", coordinates)
+	}
+}
+
+func TestTelescopeSlew(t *testing.T) {
+	polls := atomic.Int32{}
+
+	telescope := &mocks.Telescope{
+		IsSlewingFunc:         func() (bool, error) { return polls.Add(1) < 3, nil },
+		GetRightAscensionFunc: func() (float64, error) { return 3.79, nil },
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddTelescope("Telescope Simulator", telescope)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newNumberVector device="Telescope Simulator" name="EQUATORIAL_EOD_COORD">
+		<oneNumber name="RA">3:47:24</oneNumber>
+		<oneNumber name="DEC">24.1</oneNumber>
+	</newNumberVector>`)
+
+	client.waitFor("setNumberVector", "EQUATORIAL_EOD_COORD", Busy)
+
+	coordinates := client.waitFor("setNumberVector", "EQUATORIAL_EOD_COORD", Ok)
+
+	if got := coordinates.getValue("RA"); got != "3.79" {
+		t.Errorf("got RA %q, wanted 3.79", got)
+	}
+
+	calls := getCommands(telescope.GetCalls())
+
+	if len(calls) != 2 {
+		t.Fatalf("got %+v, wanted SetTracking(true) and SetSlewToCoordinatesAsync", calls)
+	}
+
+	if calls[0].Method != "SetTracking" || calls[0].Arguments[0] != true {
+		t.Errorf("got %+v, wanted SetTracking(true)", calls[0])
+	}
+
+	// The right ascension is sent to Alpaca in degrees:
+	if calls[1].Method != "SetSlewToCoordinatesAsync" || calls[1].Arguments[0] != 56.85 || calls[1].Arguments[1] != 24.1 {
+		t.Errorf("got %+v, wanted SetSlewToCoordinatesAsync(56.85, 24.1)", calls[1])
+	}
+}
+
+func TestTelescopeSync(t *testing.T) {
+	telescope := &mocks.Telescope{}
+
+	bridge := NewBridge()
+
+	bridge.AddTelescope("Telescope Simulator", telescope)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newSwitchVector device="Telescope Simulator" name="ON_COORD_SET"><oneSwitch name="SYNC">On</oneSwitch></newSwitchVector>`)
+
+	mode := client.waitFor("setSwitchVector", "ON_COORD_SET", Ok)
+
+	if mode.getValue("SYNC") != "On" || mode.getValue("TRACK") != "Off" {
+		t.Errorf("got %+v, wanted only SYNC On", mode)
+	}
+
+	client.send(`<newNumberVector device="Telescope Simulator" name="EQUATORIAL_EOD_COORD"><oneNumber name="RA">6</oneNumber><oneNumber name="DEC">-10</oneNumber></newNumberVector>`)
+
+	client.waitFor("setNumberVector", "EQUATORIAL_EOD_COORD", Ok)
+
+	calls := getCommands(telescope.GetCalls())
+
+	if len(calls) != 1 || calls[0].Method != "SetSyncToCoordinates" || calls[0].Arguments[0] != 90. || calls[0].Arguments[1] != -10. {
+		t.Errorf("got %+v, wanted only SetSyncToCoordinates(90, -10)", calls)
+	}
+}
+
+func TestTelescopeSlewWithoutTracking(t *testing.T) {
+	telescope := &mocks.Telescope{}
+
+	bridge := NewBridge()
+
+	bridge.AddTelescope("Telescope Simulator", telescope)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newSwitchVector device="Telescope Simulator" name="ON_COORD_SET"><oneSwitch name="SLEW">On</oneSwitch></newSwitchVector>`)
+
+	client.waitFor("setSwitchVector", "ON_COORD_SET", Ok)
+
+	client.send(`<newNumberVector device="Telescope Simulator" name="EQUATORIAL_EOD_COORD"><oneNumber name="RA">6</oneNumber><oneNumber name="DEC">-10</oneNumber></newNumberVector>`)
+
+	client.waitFor("setNumberVector", "EQUATORIAL_EOD_COORD", Ok)
+
+	var got []string = []string{}
+
+	for _, call := range getCommands(telescope.GetCalls()) {
+		got = append(got, fmt.Sprintf("%s%v", call.Method, call.Arguments))
+	}
+
+	// Alpaca only slews while tracking, so tracking is stopped once the slew is over:
+	var want []string = []string{"SetTracking[true]", "SetSlewToCoordinatesAsync[90 -10]", "SetTracking[false]"}
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestTelescopeJ2000(t *testing.T) {
+	telescope := &mocks.Telescope{
+		GetEquatorialSystemFunc: func() (string, error) { return alpacago.J2000.String(), nil },
+		GetRightAscensionFunc:   func() (float64, error) { return 3.79, nil },
+		GetDeclinationFunc:      func() (float64, error) { return 24.1, nil },
+	}
+
+	bridge := NewBridge()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bridge.now = func() time.Time { return now }
+
+	bridge.AddTelescope("Telescope Simulator", telescope)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newSwitchVector device="Telescope Simulator" name="ON_COORD_SET"><oneSwitch name="SYNC">On</oneSwitch></newSwitchVector>`)
+
+	client.waitFor("setSwitchVector", "ON_COORD_SET", Ok)
+
+	client.send(`<getProperties version="1.7"/>`)
+
+	// The clients are sent the coordinates of date:
+	jnow := alpacago.J2000ToJNow(alpacago.EquatorialCoordinate{RightAscension: 3.79, Declination: 24.1}, now)
+
+	coordinates := client.waitFor("defNumberVector", "EQUATORIAL_EOD_COORD", "")
+
+	if got, want := coordinates.getValue("RA"), formatFloat(jnow.RightAscension); got != want {
+		t.Errorf("got RA %q, wanted %q", got, want)
+	}
+
+	if got, want := coordinates.getValue("DEC"), formatFloat(jnow.Declination); got != want {
+		t.Errorf("got DEC %q, wanted %q", got, want)
+	}
+
+	client.send(`<newNumberVector device="Telescope Simulator" name="EQUATORIAL_EOD_COORD"><oneNumber name="RA">6</oneNumber><oneNumber name="DEC">-10</oneNumber></newNumberVector>`)
+
+	client.waitFor("setNumberVector", "EQUATORIAL_EOD_COORD", Ok)
+
+	// ... and the mount is synced to J2000 coordinates:
+	j2000 := alpacago.JNowToJ2000(alpacago.EquatorialCoordinate{RightAscension: 6, Declination: -10}, now)
+
+	calls := getCommands(telescope.GetCalls())
+
+	if len(calls) != 1 || calls[0].Arguments[0] != j2000.RightAscension*15 || calls[0].Arguments[1] != j2000.Declination {
+		t.Errorf("got %+v, wanted SetSyncToCoordinates(%f, %f)", calls, j2000.RightAscension*15, j2000.Declination)
+	}
+}
+
+func TestCameraExposure(t *testing.T) {
+	camera := &mocks.Camera{
+		IsImageReadyFunc: func() (bool, error) { return true, nil },
+		GetExposureFunc:  func() ([][]uint32, uint32, error) { return [][]uint32{{1, 2}, {3, 4}}, 2, nil },
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddCamera("CCD Simulator", camera)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<enableBLOB device="CCD Simulator">Also</enableBLOB>`)
+
+	client.send(`<newNumberVector device="CCD Simulator" name="CCD_EXPOSURE"><oneNumber name="CCD_EXPOSURE_VALUE">1.5</oneNumber></newNumberVector>`)
+
+	image := client.waitFor("setBLOBVector", "CCD1", Ok)
+
+	data, err := base64.StdEncoding.DecodeString(image.getValue("CCD1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data)%fitsBlockSize != 0 || !bytes.HasPrefix(data, []byte("SIMPLE  =")) {
+		t.Errorf("got %d bytes, wanted a FITS image", len(data))
+	}
+
+	client.waitFor("setNumberVector", "CCD_EXPOSURE", Ok)
+
+	calls := camera.GetCalls()
+
+	if calls[0].Method != "StartExposure" || calls[0].Arguments[0] != 1.5 || calls[0].Arguments[1] != true {
+		t.Errorf("got %+v, wanted StartExposure(1.5, true)", calls[0])
+	}
+}
+
+func TestFilterWheelSlot(t *testing.T) {
+	position := atomic.Int32{}
+
+	filterWheel := &mocks.FilterWheel{
+		GetNamesFunc:    func() ([]string, error) { return []string{"Red", "Green", "Blue"}, nil },
+		GetPositionFunc: func() (int32, error) { return position.Load(), nil },
+		SetPositionFunc: func(p int32) error { position.Store(p); return nil },
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddFilterWheel("Filter Simulator", filterWheel)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<getProperties version="1.7" device="Filter Simulator" name="FILTER_NAME"/>`)
+
+	names := client.waitFor("defTextVector", "FILTER_NAME", "")
+
+	if got := names.getValue("FILTER_SLOT_NAME_3"); got != "Blue" {
+		t.Errorf("got %q, wanted Blue", got)
+	}
+
+	client.send(`<newNumberVector device="Filter Simulator" name="FILTER_SLOT"><oneNumber name="FILTER_SLOT_VALUE">3</oneNumber></newNumberVector>`)
+
+	slot := client.waitFor("setNumberVector", "FILTER_SLOT", Ok)
+
+	if got := slot.getValue("FILTER_SLOT_VALUE"); got != "3" {
+		t.Errorf("got %q, wanted 3", got)
+	}
+
+	var got int32 = position.Load()
+
+	var want int32 = 2
+
+	if got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+}
+
+func TestFocuserAlert(t *testing.T) {
+	focuser := &mocks.Focuser{
+		IsAbsoluteFunc: func() (bool, error) { return true, nil },
+		SetMoveFunc:    func(position int32) error { return errors.New("the focuser is not connected") },
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddFocuser("Focuser Simulator", focuser)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newNumberVector device="Focuser Simulator" name="ABS_FOCUS_POSITION"><oneNumber name="FOCUS_ABSOLUTE_POSITION">1000</oneNumber></newNumberVector>`)
+
+	position := client.waitFor("setNumberVector", "ABS_FOCUS_POSITION", Alert)
+
+	if position.Message != "the focuser is not connected" {
+		t.Errorf("got %q, wanted the focuser error", position.Message)
+	}
+}
+
+func TestFocuserRelative(t *testing.T) {
+	moves := int32(0)
+
+	focuser := &mocks.Focuser{
+		IsAbsoluteFunc: func() (bool, error) { return false, nil },
+		SetMoveFunc: func(position int32) error {
+			atomic.AddInt32(&moves, 1)
+			return nil
+		},
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddFocuser("Focuser Simulator", focuser)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newNumberVector device="Focuser Simulator" name="ABS_FOCUS_POSITION"><oneNumber name="FOCUS_ABSOLUTE_POSITION">1000</oneNumber></newNumberVector>`)
+
+	if position := client.waitFor("setNumberVector", "ABS_FOCUS_POSITION", Alert); position.Message == "" {
+		t.Errorf("got no message, wanted the move refused")
+	}
+
+	client.send(`<getProperties version="1.7" device="Focuser Simulator" name="ABS_FOCUS_POSITION"/>`)
+
+	var got Permission = client.waitFor("defNumberVector", "ABS_FOCUS_POSITION", "").Perm
+
+	var want Permission = ReadOnly
+
+	if got != want || atomic.LoadInt32(&moves) != 0 {
+		t.Errorf("got %q and %d moves, wanted %q and none", got, moves, want)
+	}
+}
+
+/*
+newStalledClient()
+
+@returns a client of the bridge whose peer never reads, so its writes stall.
+*/
+func newStalledClient(t *testing.T, bridge *Bridge) *client {
+	conn, peer := net.Pipe()
+
+	t.Cleanup(func() { peer.Close() })
+
+	c := newClient(conn)
+
+	bridge.mu.Lock()
+
+	bridge.clients[c] = struct{}{}
+
+	bridge.mu.Unlock()
+
+	return c
+}
+
+func TestStalledClientDropped(t *testing.T) {
+	bridge := NewBridge()
+
+	bridge.WriteTimeout = 10 * time.Millisecond
+
+	stalled := newStalledClient(t, bridge)
+
+	go stalled.write(bridge.WriteTimeout)
+
+	vector := &Vector{Device: "Focuser Simulator", Name: "ABS_FOCUS_POSITION", Kind: NumberKind}
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		bridge.update(vector, Busy, "", nil)
+	}
+
+	// The updates are queued, rather than waiting on the stalled client:
+	if elapsed := time.Since(start); elapsed > bridge.WriteTimeout {
+		t.Errorf("got updates taking %v, wanted them queued", elapsed)
+	}
+
+	select {
+	case <-stalled.done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("got the stalled client still connected, wanted it dropped")
+	}
+}
+
+func TestSlowClientDropped(t *testing.T) {
+	bridge := NewBridge()
+
+	// Without a writer, the client's queue fills:
+	slow := newStalledClient(t, bridge)
+
+	vector := &Vector{Device: "Focuser Simulator", Name: "ABS_FOCUS_POSITION", Kind: NumberKind}
+
+	for i := 0; i <= clientQueueSize; i++ {
+		bridge.update(vector, Busy, "", nil)
+	}
+
+	select {
+	case <-slow.done:
+	default:
+		t.Errorf("got the slow client still connected, wanted it dropped once %d elements were queued", clientQueueSize)
+	}
+}
+
+func TestDomeShutter(t *testing.T) {
+	status := atomic.Value{}
+
+	status.Store("closed")
+
+	dome := &mocks.Dome{
+		OpenShutterFunc:      func() error { status.Store("open"); return nil },
+		GetShutterStatusFunc: func() (string, error) { return status.Load().(string), nil },
+	}
+
+	bridge := NewBridge()
+
+	bridge.AddDome("Dome Simulator", dome)
+
+	client := newTestClient(t, bridge)
+
+	client.send(`<newSwitchVector device="Dome Simulator" name="DOME_SHUTTER"><oneSwitch name="SHUTTER_OPEN">On</oneSwitch></newSwitchVector>`)
+
+	shutter := client.waitFor("setSwitchVector", "DOME_SHUTTER", Ok)
+
+	if shutter.getValue("SHUTTER_OPEN") != "On" || shutter.getValue("SHUTTER_CLOSE") != "Off" {
+		t.Errorf("got %+v, wanted the shutter open", shutter)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"24.1", 24.1},
+		{"3:47:24", 3.79},
+		{"-24 6 0", -24.1},
+		{"3:47.4", 3.79},
+	}
+
+	for _, test := range tests {
+		got, err := parseNumber(test.value)
+
+		if err != nil || fmt.Sprintf("%.6f", got) != fmt.Sprintf("%.6f", test.want) {
+			t.Errorf("got %f (%v) for %q, wanted %f", got, err, test.value, test.want)
+		}
+	}
+
+	if _, err := parseNumber("north"); err == nil {
+		t.Errorf("got nil, wanted an error")
+	}
+}
+
+func TestEncodeFITS(t *testing.T) {
+	data := EncodeFITS([][]uint32{{1, 2, 3}, {4, 5, 70000}}, 1.5, time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC))
+
+	if len(data)%fitsBlockSize != 0 {
+		t.Errorf("got %d bytes, wanted a multiple of %d", len(data), fitsBlockSize)
+	}
+
+	for _, card := range []string{getFITSCard("BITPIX", "32"), getFITSCard("NAXIS1", "2"), getFITSCard("NAXIS2", "3")} {
+		if !bytes.Contains(data, []byte(card)) {
+			t.Errorf("got no %q card", card)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+package indi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/observerly/alpacago/pkg/alpacago"
+)
+
+/*
+The DRIVER_INTERFACE bits, which tell clients the kind of device.
+*/
+const (
+	telescopeInterface   = 1
+	ccdInterface         = 2
+	focuserInterface     = 8
+	filterWheelInterface = 16
+	domeInterface        = 32
+)
+
+type deviceConnector interface {
+	IsConnected() (bool, error)
+	SetConnected(connected bool) error
+}
+
+/*
+getAbortVector()
+
+@returns a vector with a single ABORT switch e.g., TELESCOPE_ABORT_MOTION.
+*/
+func getAbortVector(name string, label string) *Vector {
+	return &Vector{
+		Name:  name,
+		Label: label,
+		Group: "Main Control",
+		Kind:  SwitchKind,
+		Perm:  ReadWrite,
+		Rule:  AtMostOne,
+		Members: []Member{
+			{Name: "ABORT", Label: "Abort"},
+		},
+	}
+}
+
+/*
+isJ2000Telescope()
+
+INDI's EQUATORIAL_EOD_COORD are coordinates of date (JNow), which a topocentric mount already works in.
+Mounts which do not say which system they work in (Other) are taken to be topocentric, as most are.
+
+@returns whether the telescope works in J2000 coordinates, which must be converted to and from JNow.
+*/
+func isJ2000Telescope(telescope alpacago.TelescopeAPI) (bool, error) {
+	system, err := telescope.GetEquatorialSystem()
+
+	if err != nil {
+		return false, err
+	}
+
+	switch system {
+	case alpacago.J2000.String():
+		return true, nil
+	case alpacago.J2050.String(), alpacago.B1950.String():
+		return false, fmt.Errorf("unsupported equatorial system %q", system)
+	default:
+		return false, nil
+	}
+}
+
+/*
+AddTelescope()
+
+Serves the telescope as an INDI telescope, with equatorial coordinates of date (JNow, with RA in hours, as
+INDI expects), ON_COORD_SET (to slew, slew and track, or sync to the coordinates) and
+TELESCOPE_ABORT_MOTION. The coordinates of a J2000 mount are converted to and from JNow.
+*/
+func (b *Bridge) AddTelescope(name string, telescope alpacago.TelescopeAPI) {
+	d := b.addDevice(name, telescope, telescopeInterface)
+
+	coordinates := &Vector{
+		Name:  "EQUATORIAL_EOD_COORD",
+		Label: "Eq. Coordinates",
+		Group: "Main Control",
+		Kind:  NumberKind,
+		Perm:  ReadWrite,
+		Members: []Member{
+			{Name: "RA", Label: "RA (hh:mm:ss)", Format: "%010.6m", Min: 0, Max: 24},
+			{Name: "DEC", Label: "DEC (dd:mm:ss)", Format: "%010.6m", Min: -90, Max: 90},
+		},
+	}
+
+	onCoordinateSet := &Vector{
+		Name:  "ON_COORD_SET",
+		Label: "On Set",
+		Group: "Main Control",
+		Kind:  SwitchKind,
+		Perm:  ReadWrite,
+		Rule:  OneOfMany,
+		Members: []Member{
+			{Name: "TRACK", Label: "Track", On: true},
+			{Name: "SLEW", Label: "Slew"},
+			{Name: "SYNC", Label: "Sync"},
+		},
+	}
+
+	abort := getAbortVector("TELESCOPE_ABORT_MOTION", "Abort Motion")
+
+	getCoordinates := func() (map[string]float64, error) {
+		ra, err := telescope.GetRightAscension()
+
+		if err != nil {
+			return nil, err
+		}
+
+		dec, err := telescope.GetDeclination()
+
+		if err != nil {
+			return nil, err
+		}
+
+		j2000, err := isJ2000Telescope(telescope)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c := alpacago.EquatorialCoordinate{RightAscension: ra, Declination: dec}
+
+		if j2000 {
+			c = alpacago.J2000ToJNow(c, b.now())
+		}
+
+		return map[string]float64{"RA": c.RightAscension, "DEC": c.Declination}, nil
+	}
+
+	b.addProperty(d, coordinates, func(ctx context.Context, values map[string]float64) error {
+		mode := ""
+
+		b.modify(func() {
+			for _, m := range onCoordinateSet.Members {
+				if m.On {
+					mode = m.Name
+				}
+			}
+		})
+
+		j2000, err := isJ2000Telescope(telescope)
+
+		if err != nil {
+			return err
+		}
+
+		c := alpacago.EquatorialCoordinate{RightAscension: values["RA"], Declination: values["DEC"]}
+
+		if j2000 {
+			c = alpacago.JNowToJ2000(c, b.now())
+		}
+
+		// Alpaca takes the right ascension in degrees, rather than hours:
+		ra, dec := c.RightAscension*15, c.Declination
+
+		if mode == "SYNC" {
+			if err := telescope.SetSyncToCoordinates(ra, dec); err != nil {
+				return err
+			}
+
+			b.update(coordinates, Ok, "", func() { setNumbers(coordinates, values) })
+
+			return nil
+		}
+
+		// Alpaca only slews to equatorial coordinates while tracking, so SLEW stops tracking once there:
+		if err := telescope.SetTracking(true); err != nil {
+			return err
+		}
+
+		if err := telescope.SetSlewToCoordinatesAsync(ra, dec); err != nil {
+			return err
+		}
+
+		b.update(coordinates, Busy, "", nil)
+
+		// The clients follow the slew through the coordinates, updated while Busy:
+		err = alpacago.WaitWhileMoving(ctx, b.PollInterval, func() (bool, error) {
+			slewing, err := telescope.IsSlewing()
+
+			if current, err := getCoordinates(); err == nil && slewing {
+				b.update(coordinates, Busy, "", func() { setNumbers(coordinates, current) })
+			}
+
+			return slewing, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if mode == "SLEW" {
+			if err := telescope.SetTracking(false); err != nil {
+				return err
+			}
+		}
+
+		current, err := getCoordinates()
+
+		if err != nil {
+			return err
+		}
+
+		b.update(coordinates, Ok, "", func() { setNumbers(coordinates, current) })
+
+		return nil
+	}, nil)
+
+	b.addProperty(d, onCoordinateSet, nil, func(ctx context.Context, on map[string]bool) error {
+		b.update(onCoordinateSet, Ok, "", func() { setSwitches(onCoordinateSet, on) })
+
+		return nil
+	})
+
+	b.addProperty(d, abort, nil, func(ctx context.Context, on map[string]bool) error {
+		if err := telescope.SetAbortSlew(); err != nil {
+			return err
+		}
+
+		b.update(abort, Ok, "", nil)
+
+		return nil
+	})
+
+	d.refreshers = append(d.refreshers, func() {
+		if current, err := getCoordinates(); err == nil {
+			b.modify(func() { setNumbers(coordinates, current) })
+		}
+	})
+}
+
+/*
+AddCamera()
+
+Serves the camera as an INDI CCD, with CCD_EXPOSURE and CCD_ABORT_EXPOSURE. Light frames are exposed, and
+sent to the clients which enabled BLOBs as FITS images in CCD1.
+*/
+func (b *Bridge) AddCamera(name string, camera alpacago.CameraAPI) {
+	d := b.addDevice(name, camera, ccdInterface)
+
+	exposure := &Vector{
+		Name:  "CCD_EXPOSURE",
+		Label: "Expose",
+		Group: "Main Control",
+		Kind:  NumberKind,
+		Perm:  ReadWrite,
+		Members: []Member{
+			{Name: "CCD_EXPOSURE_VALUE", Label: "Duration (s)", Format: "%5.2f", Min: 0, Max: 3600, Step: 1},
+		},
+	}
+
+	abort := getAbortVector("CCD_ABORT_EXPOSURE", "Abort")
+
+	image := &Vector{
+		Name:  "CCD1",
+		Label: "Image Data",
+		Group: "Image Info",
+		Kind:  BLOBKind,
+		Perm:  ReadOnly,
+		Members: []Member{
+			{Name: "CCD1", Label: "Image"},
+		},
+	}
+
+	// Cancels the exposure in progress, if any:
+	cancel := func() {}
+
+	b.addProperty(d, exposure, func(ctx context.Context, values map[string]float64) error {
+		duration, start := values["CCD_EXPOSURE_VALUE"], time.Now()
+
+		if err := camera.StartExposure(duration, true); err != nil {
+			return err
+		}
+
+		exposing, stop := context.WithCancel(ctx)
+
+		defer stop()
+
+		b.update(exposure, Busy, "", func() {
+			setNumbers(exposure, values)
+			cancel = stop
+		})
+
+		if err := alpacago.WaitUntil(exposing, b.PollInterval, camera.IsImageReady); err != nil {
+			if exposing.Err() != nil && ctx.Err() == nil {
+				return errors.New("the exposure was aborted")
+			}
+
+			return err
+		}
+
+		pixels, _, err := camera.GetExposure()
+
+		if err != nil {
+			return err
+		}
+
+		b.update(image, Ok, "", func() {
+			image.Members[0].BLOBFormat, image.Members[0].BLOB = ".fits", EncodeFITS(pixels, duration, start)
+		})
+
+		b.update(exposure, Ok, "", func() { setNumbers(exposure, map[string]float64{"CCD_EXPOSURE_VALUE": 0}) })
+
+		return nil
+	}, nil)
+
+	b.addProperty(d, abort, nil, func(ctx context.Context, on map[string]bool) error {
+		if err := camera.AbortExposure(); err != nil {
+			return err
+		}
+
+		b.modify(func() { cancel() })
+
+		b.update(abort, Ok, "", nil)
+
+		return nil
+	})
+
+	b.addProperty(d, image, nil, nil)
+}
+
+/*
+AddFocuser()
+
+Serves the focuser as an INDI focuser, with ABS_FOCUS_POSITION and FOCUS_ABORT_MOTION. A relative focuser
+cannot move to a position, so its ABS_FOCUS_POSITION is read-only.
+*/
+func (b *Bridge) AddFocuser(name string, focuser alpacago.FocuserAPI) {
+	d := b.addDevice(name, focuser, focuserInterface)
+
+	position := &Vector{
+		Name:  "ABS_FOCUS_POSITION",
+		Label: "Absolute Position",
+		Group: "Main Control",
+		Kind:  NumberKind,
+		Perm:  ReadWrite,
+		Members: []Member{
+			{Name: "FOCUS_ABSOLUTE_POSITION", Label: "Steps", Format: "%6.0f", Min: 0, Step: 1},
+		},
+	}
+
+	abort := getAbortVector("FOCUS_ABORT_MOTION", "Abort Motion")
+
+	getPosition := func() (map[string]float64, error) {
+		current, err := focuser.GetPosition()
+
+		return map[string]float64{"FOCUS_ABSOLUTE_POSITION": float64(current)}, err
+	}
+
+	b.addProperty(d, position, func(ctx context.Context, values map[string]float64) error {
+		// A relative focuser would take the position as a number of steps to move by:
+		absolute, err := focuser.IsAbsolute()
+
+		if err != nil {
+			return err
+		}
+
+		if !absolute {
+			return errors.New("the focuser is relative, so cannot move to an absolute position")
+		}
+
+		if err := focuser.SetMove(int32(values["FOCUS_ABSOLUTE_POSITION"])); err != nil {
+			return err
+		}
+
+		b.update(position, Busy, "", nil)
+
+		err = alpacago.WaitWhileMoving(ctx, b.PollInterval, func() (bool, error) {
+			moving, err := focuser.IsMoving()
+
+			if current, err := getPosition(); err == nil && moving {
+				b.update(position, Busy, "", func() { setNumbers(position, current) })
+			}
+
+			return moving, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		current, err := getPosition()
+
+		if err != nil {
+			return err
+		}
+
+		b.update(position, Ok, "", func() { setNumbers(position, current) })
+
+		return nil
+	}, nil)
+
+	b.addProperty(d, abort, nil, func(ctx context.Context, on map[string]bool) error {
+		if err := focuser.SetHalt(); err != nil {
+			return err
+		}
+
+		b.update(abort, Ok, "", nil)
+
+		return nil
+	})
+
+	d.refreshers = append(d.refreshers, func() {
+		if current, err := getPosition(); err == nil {
+			b.modify(func() { setNumbers(position, current) })
+		}
+
+		if maximum, err := focuser.GetMaxStep(); err == nil {
+			b.modify(func() { position.Members[0].Max = float64(maximum) })
+		}
+
+		if absolute, err := focuser.IsAbsolute(); err == nil {
+			b.modify(func() {
+				position.Perm = ReadWrite
+
+				if !absolute {
+					position.Perm = ReadOnly
+				}
+			})
+		}
+	})
+}
+
+/*
+AddFilterWheel()
+
+Serves the filter wheel as an INDI filter wheel, with FILTER_SLOT (numbered from 1, as INDI expects) and
+FILTER_NAME.
+*/
+func (b *Bridge) AddFilterWheel(name string, filterWheel alpacago.FilterWheelAPI) {
+	d := b.addDevice(name, filterWheel, filterWheelInterface)
+
+	slot := &Vector{
+		Name:  "FILTER_SLOT",
+		Label: "Filter",
+		Group: "Main Control",
+		Kind:  NumberKind,
+		Perm:  ReadWrite,
+		Members: []Member{
+			{Name: "FILTER_SLOT_VALUE", Label: "Filter", Format: "%3.0f", Min: 1, Max: 1, Step: 1},
+		},
+	}
+
+	names := &Vector{
+		Name:  "FILTER_NAME",
+		Label: "Filter",
+		Group: "Main Control",
+		Kind:  TextKind,
+		Perm:  ReadOnly,
+	}
+
+	b.addProperty(d, slot, func(ctx context.Context, values map[string]float64) error {
+		// Alpaca numbers the filter positions from 0:
+		target := int32(values["FILTER_SLOT_VALUE"]) - 1
+
+		if err := filterWheel.SetPosition(target); err != nil {
+			return err
+		}
+
+		b.update(slot, Busy, "", nil)
+
+		// The position is -1 while the wheel is moving:
+		err := alpacago.WaitUntil(ctx, b.PollInterval, func() (bool, error) {
+			current, err := filterWheel.GetPosition()
+
+			return current == target, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		b.update(slot, Ok, "", func() { setNumbers(slot, values) })
+
+		return nil
+	}, nil)
+
+	b.addProperty(d, names, nil, nil)
+
+	d.refreshers = append(d.refreshers, func() {
+		if current, err := filterWheel.GetPosition(); err == nil && current >= 0 {
+			b.modify(func() { setNumbers(slot, map[string]float64{"FILTER_SLOT_VALUE": float64(current + 1)}) })
+		}
+
+		if filters, err := filterWheel.GetNames(); err == nil {
+			members := []Member{}
+
+			for i, filter := range filters {
+				members = append(members, Member{Name: fmt.Sprintf("FILTER_SLOT_NAME_%d", i+1), Label: fmt.Sprintf("Filter #%d", i+1), Text: filter})
+			}
+
+			b.modify(func() {
+				names.Members, slot.Members[0].Max = members, float64(max(len(filters), 1))
+			})
+		}
+	})
+}
+
+/*
+AddDome()
+
+Serves the dome as an INDI dome, with DOME_SHUTTER and DOME_ABORT_MOTION.
+*/
+func (b *Bridge) AddDome(name string, dome alpacago.DomeAPI) {
+	d := b.addDevice(name, dome, domeInterface)
+
+	shutter := &Vector{
+		Name:  "DOME_SHUTTER",
+		Label: "Shutter",
+		Group: "Main Control",
+		Kind:  SwitchKind,
+		Perm:  ReadWrite,
+		Rule:  OneOfMany,
+		Members: []Member{
+			{Name: "SHUTTER_OPEN", Label: "Open"},
+			{Name: "SHUTTER_CLOSE", Label: "Close", On: true},
+		},
+	}
+
+	abort := getAbortVector("DOME_ABORT_MOTION", "Abort Motion")
+
+	b.addProperty(d, shutter, nil, func(ctx context.Context, on map[string]bool) error {
+		want, operate := "closed", dome.CloseShutter
+
+		if on["SHUTTER_OPEN"] {
+			want, operate = "open", dome.OpenShutter
+		}
+
+		if err := operate(); err != nil {
+			return err
+		}
+
+		b.update(shutter, Busy, "", func() { setSwitches(shutter, on) })
+
+		err := alpacago.WaitUntil(ctx, b.PollInterval, func() (bool, error) {
+			status, err := dome.GetShutterStatus()
+
+			if err == nil && status == "error" {
+				return false, errors.New("the shutter is in error")
+			}
+
+			return status == want, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		b.update(shutter, Ok, "", nil)
+
+		return nil
+	})
+
+	b.addProperty(d, abort, nil, func(ctx context.Context, on map[string]bool) error {
+		if err := dome.AbortSlew(); err != nil {
+			return err
+		}
+
+		b.update(abort, Ok, "", nil)
+
+		return nil
+	})
+
+	d.refreshers = append(d.refreshers, func() {
+		if status, err := dome.GetShutterStatus(); err == nil && (status == "open" || status == "closed") {
+			b.modify(func() {
+				setSwitches(shutter, map[string]bool{"SHUTTER_OPEN": status == "open", "SHUTTER_CLOSE": status == "closed"})
+			})
+		}
+	})
+}
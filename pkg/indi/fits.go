@@ -0,0 +1,93 @@
+package indi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+fitsBlockSize is the size of a FITS header or data block (bytes).
+*/
+const fitsBlockSize = 2880
+
+/*
+getFITSCard()
+
+@returns the 80 character FITS header card e.g., "BITPIX  =                   16".
+*/
+func getFITSCard(keyword string, value string) string {
+	if keyword == "END" {
+		return fmt.Sprintf("%-80s", keyword)
+	}
+
+	return fmt.Sprintf("%-8s= %20s%-50s", keyword, value, "")
+}
+
+/*
+EncodeFITS()
+
+@returns the image (indexed as image[x][y], as returned by Camera.GetExposure()) as a FITS primary HDU of
+unsigned 16 bit integers, or of unsigned 32 bit integers if any pixel exceeds 65535.
+*/
+func EncodeFITS(image [][]uint32, exposure float64, start time.Time) []byte {
+	width, height := len(image), 0
+
+	if width > 0 {
+		height = len(image[0])
+	}
+
+	maximum := uint32(0)
+
+	for x := range image {
+		for y := range image[x] {
+			maximum = max(maximum, image[x][y])
+		}
+	}
+
+	bitpix, bzero := 16, "32768"
+
+	if maximum > 65535 {
+		bitpix, bzero = 32, "2147483648"
+	}
+
+	var header strings.Builder
+
+	for _, card := range [][2]string{
+		{"SIMPLE", "T"},
+		{"BITPIX", fmt.Sprintf("%d", bitpix)},
+		{"NAXIS", "2"},
+		{"NAXIS1", fmt.Sprintf("%d", width)},
+		{"NAXIS2", fmt.Sprintf("%d", height)},
+		{"BZERO", bzero},
+		{"BSCALE", "1"},
+		{"EXPTIME", fmt.Sprintf("%g", exposure)},
+		{"DATE-OBS", fmt.Sprintf("'%s'", start.UTC().Format("2006-01-02T15:04:05.000"))},
+		{"END", ""},
+	} {
+		header.WriteString(getFITSCard(card[0], card[1]))
+	}
+
+	var data bytes.Buffer
+
+	data.WriteString(header.String())
+
+	data.Write(bytes.Repeat([]byte{' '}, (fitsBlockSize-data.Len()%fitsBlockSize)%fitsBlockSize))
+
+	// FITS images are stored row by row, as big-endian signed integers offset by BZERO:
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if bitpix == 16 {
+				binary.Write(&data, binary.BigEndian, int16(int32(image[x][y])-32768))
+			} else {
+				binary.Write(&data, binary.BigEndian, int32(int64(image[x][y])-2147483648))
+			}
+		}
+	}
+
+	data.Write(make([]byte, (fitsBlockSize-data.Len()%fitsBlockSize)%fitsBlockSize))
+
+	return data.Bytes()
+}
@@ -0,0 +1,292 @@
+// Package indi bridges Alpaca devices to INDI clients (e.g., KStars/Ekos), serving them as INDI devices over
+// the INDI XML protocol on TCP.
+//
+// @see https://www.indilib.org/develop/developer-manual/104-scripting.html
+package indi
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PropertyState string
+
+const (
+	Idle  PropertyState = "Idle"
+	Ok    PropertyState = "Ok"
+	Busy  PropertyState = "Busy"
+	Alert PropertyState = "Alert"
+)
+
+type Permission string
+
+const (
+	ReadOnly  Permission = "ro"
+	WriteOnly Permission = "wo"
+	ReadWrite Permission = "rw"
+)
+
+type SwitchRule string
+
+const (
+	OneOfMany SwitchRule = "OneOfMany"
+	AtMostOne SwitchRule = "AtMostOne"
+	AnyOfMany SwitchRule = "AnyOfMany"
+)
+
+type VectorKind uint8
+
+const (
+	NumberKind VectorKind = iota
+	SwitchKind
+	TextKind
+	BLOBKind
+)
+
+func (k VectorKind) String() string {
+	name := []string{"Number", "Switch", "Text", "BLOB"}
+
+	i := uint8(k)
+
+	switch {
+	case i <= uint8(BLOBKind):
+		return name[i]
+	default:
+		return fmt.Sprintf("VectorKind(%d)", i)
+	}
+}
+
+/*
+Member is a single element of a property vector e.g., the RA of EQUATORIAL_EOD_COORD.
+*/
+type Member struct {
+	Name  string
+	Label string
+	// Number members: the printf-style (or INDI sexagesimal, e.g., %010.6m) format, and limits
+	Format string
+	Min    float64
+	Max    float64
+	Step   float64
+	Number float64
+	// Switch members
+	On bool
+	// Text members
+	Text string
+	// BLOB members: the format e.g., ".fits", and the data
+	BLOBFormat string
+	BLOB       []byte
+}
+
+/*
+Vector is an INDI property vector, holding its current values and state.
+*/
+type Vector struct {
+	Device  string
+	Name    string
+	Label   string
+	Group   string
+	Kind    VectorKind
+	State   PropertyState
+	Perm    Permission
+	Rule    SwitchRule
+	Timeout int
+	Members []Member
+	// The message sent with the next update e.g., the error which put the vector in Alert
+	Message string
+}
+
+/*
+GetMember()
+
+@returns the named member of the vector, or nil.
+*/
+func (v *Vector) GetMember(name string) *Member {
+	for i := range v.Members {
+		if v.Members[i].Name == name {
+			return &v.Members[i]
+		}
+	}
+
+	return nil
+}
+
+type memberElement struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr"`
+	Label   string `xml:"label,attr,omitempty"`
+	Format  string `xml:"format,attr,omitempty"`
+	Min     string `xml:"min,attr,omitempty"`
+	Max     string `xml:"max,attr,omitempty"`
+	Step    string `xml:"step,attr,omitempty"`
+	Size    string `xml:"size,attr,omitempty"`
+	EncLen  string `xml:"enclen,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+type vectorElement struct {
+	XMLName   xml.Name
+	Device    string          `xml:"device,attr"`
+	Name      string          `xml:"name,attr"`
+	Label     string          `xml:"label,attr,omitempty"`
+	Group     string          `xml:"group,attr,omitempty"`
+	State     PropertyState   `xml:"state,attr,omitempty"`
+	Perm      Permission      `xml:"perm,attr,omitempty"`
+	Rule      SwitchRule      `xml:"rule,attr,omitempty"`
+	Timeout   string          `xml:"timeout,attr,omitempty"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Message   string          `xml:"message,attr,omitempty"`
+	Members   []memberElement `xml:""`
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func getSwitchValue(on bool) string {
+	if on {
+		return "On"
+	}
+
+	return "Off"
+}
+
+/*
+getElement()
+
+@returns the vector as a defXVector element (def is true), defining the property to a client, or as a
+setXVector element, updating its values and state.
+*/
+func (v *Vector) getElement(def bool, timestamp time.Time) vectorElement {
+	prefix, memberPrefix := "set", "one"
+
+	if def {
+		prefix, memberPrefix = "def", "def"
+	}
+
+	element := vectorElement{
+		XMLName:   xml.Name{Local: prefix + v.Kind.String() + "Vector"},
+		Device:    v.Device,
+		Name:      v.Name,
+		State:     v.State,
+		Timeout:   strconv.Itoa(v.Timeout),
+		Timestamp: timestamp.UTC().Format("2006-01-02T15:04:05"),
+		Message:   v.Message,
+	}
+
+	if def {
+		element.Label, element.Group, element.Perm = v.Label, v.Group, v.Perm
+
+		if v.Kind == SwitchKind {
+			element.Rule = v.Rule
+		}
+
+		// Properties without a permission set (e.g., images) are read-only:
+		if element.Perm == "" {
+			element.Perm = ReadOnly
+		}
+	}
+
+	for _, m := range v.Members {
+		member := memberElement{XMLName: xml.Name{Local: memberPrefix + v.Kind.String()}, Name: m.Name}
+
+		if def {
+			member.Label = m.Label
+		}
+
+		switch v.Kind {
+		case NumberKind:
+			member.Value = formatFloat(m.Number)
+
+			if def {
+				member.Format, member.Min, member.Max, member.Step = m.Format, formatFloat(m.Min), formatFloat(m.Max), formatFloat(m.Step)
+			}
+		case SwitchKind:
+			member.Value = getSwitchValue(m.On)
+		case TextKind:
+			member.Value = m.Text
+		case BLOBKind:
+			// BLOB data is only sent in updates, base64 encoded:
+			if !def {
+				member.Format, member.Size, member.Value = m.BLOBFormat, strconv.Itoa(len(m.BLOB)), base64.StdEncoding.EncodeToString(m.BLOB)
+				member.EncLen = strconv.Itoa(len(member.Value))
+			}
+		}
+
+		element.Members = append(element.Members, member)
+	}
+
+	return element
+}
+
+/*
+incomingElement is a client message e.g., getProperties, newNumberVector, newSwitchVector or enableBLOB.
+*/
+type incomingElement struct {
+	XMLName xml.Name
+	Version string           `xml:"version,attr"`
+	Device  string           `xml:"device,attr"`
+	Name    string           `xml:"name,attr"`
+	Members []incomingMember `xml:",any"`
+	Value   string           `xml:",chardata"`
+}
+
+type incomingMember struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr"`
+	Value   string `xml:",chardata"`
+}
+
+/*
+getValues()
+
+@returns the member values of a new vector, keyed by member name.
+*/
+func (e *incomingElement) getValues() map[string]string {
+	values := map[string]string{}
+
+	for _, member := range e.Members {
+		values[member.Name] = strings.TrimSpace(member.Value)
+	}
+
+	return values
+}
+
+/*
+parseNumber()
+
+@returns the number, which may be decimal or sexagesimal e.g., "3:47:24.5", "-24 6 0" or "3:47.4".
+*/
+func parseNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ':' || r == ' ' || r == ';' })
+
+	if len(fields) == 0 || len(fields) > 3 {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+
+	sign := 1.
+
+	if strings.HasPrefix(fields[0], "-") {
+		sign, fields[0] = -1, strings.TrimPrefix(fields[0], "-")
+	}
+
+	number := 0.
+
+	for i, field := range fields {
+		n, err := strconv.ParseFloat(field, 64)
+
+		if err != nil || (i > 0 && n < 0) {
+			return 0, fmt.Errorf("invalid number %q", value)
+		}
+
+		number += n / math.Pow(60, float64(i))
+	}
+
+	return sign * number, nil
+}